@@ -4,14 +4,20 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 
 	"github.com/hashicorp/hcl/v2"
 )
 
+// jsonDiagWriter buffers diagnostics so that Flush can write them all as a
+// single indented JSON document, in the format produced by
+// hcl.NewDiagnosticJSONWriter. Nothing is written if there are no
+// diagnostics.
 type jsonDiagWriter struct {
 	w     io.Writer
+	files map[string]*hcl.File
 	diags hcl.Diagnostics
 }
 
@@ -32,66 +38,18 @@ func (wr *jsonDiagWriter) Flush() error {
 		return nil
 	}
 
-	type PosJSON struct {
-		Line   int `json:"line"`
-		Column int `json:"column"`
-		Byte   int `json:"byte"`
-	}
-	type RangeJSON struct {
-		Filename string  `json:"filename"`
-		Start    PosJSON `json:"start"`
-		End      PosJSON `json:"end"`
-	}
-	type DiagnosticJSON struct {
-		Severity string     `json:"severity"`
-		Summary  string     `json:"summary"`
-		Detail   string     `json:"detail,omitempty"`
-		Subject  *RangeJSON `json:"subject,omitempty"`
-	}
-	type DiagnosticsJSON struct {
-		Diagnostics []DiagnosticJSON `json:"diagnostics"`
-	}
-
-	diagsJSON := make([]DiagnosticJSON, 0, len(wr.diags))
-	for _, diag := range wr.diags {
-		var diagJSON DiagnosticJSON
-
-		switch diag.Severity {
-		case hcl.DiagError:
-			diagJSON.Severity = "error"
-		case hcl.DiagWarning:
-			diagJSON.Severity = "warning"
-		default:
-			diagJSON.Severity = "(unknown)" // should never happen
-		}
-
-		diagJSON.Summary = diag.Summary
-		diagJSON.Detail = diag.Detail
-		if diag.Subject != nil {
-			diagJSON.Subject = &RangeJSON{}
-			sJSON := diagJSON.Subject
-			rng := diag.Subject
-			sJSON.Filename = rng.Filename
-			sJSON.Start.Line = rng.Start.Line
-			sJSON.Start.Column = rng.Start.Column
-			sJSON.Start.Byte = rng.Start.Byte
-			sJSON.End.Line = rng.End.Line
-			sJSON.End.Column = rng.End.Column
-			sJSON.End.Byte = rng.End.Byte
-		}
-
-		diagsJSON = append(diagsJSON, diagJSON)
-	}
-
-	src, err := json.MarshalIndent(DiagnosticsJSON{diagsJSON}, "", "  ")
+	var buf bytes.Buffer
+	err := hcl.NewDiagnosticJSONWriter(&buf, wr.files).WriteDiagnostics(wr.diags)
 	if err != nil {
 		return err
 	}
-	_, err = wr.w.Write(src)
+	var src bytes.Buffer
+	err = json.Indent(&src, bytes.TrimSpace(buf.Bytes()), "", "  ")
 	if err != nil {
 		return err
 	}
-	_, err = wr.w.Write([]byte{'\n'})
+	src.WriteByte('\n')
+	_, err = wr.w.Write(src.Bytes())
 	return err
 }
 
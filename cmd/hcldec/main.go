@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/cmd/internal/specfile"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	flag "github.com/spf13/pflag"
@@ -30,7 +31,7 @@ var vars = &varSpecs{}
 var (
 	specFile    = flag.StringP("spec", "s", "", "path to spec file (required)")
 	outputFile  = flag.StringP("out", "o", "", "write to the given file, instead of stdout")
-	diagsFormat = flag.StringP("diags", "", "", "format any returned diagnostics in the given format; currently only \"json\" is accepted")
+	diagsFormat = flag.StringP("diags", "", "", "format any returned diagnostics in the given format; either \"json\" or \"sarif\"")
 	showVarRefs = flag.BoolP("var-refs", "", false, "rather than decoding input, produce a JSON description of the variables referenced by it")
 	withType    = flag.BoolP("with-type", "", false, "include an additional object level at the top describing the HCL-oriented type of the result value")
 	showVersion = flag.BoolP("version", "v", false, "show the version number and immediately exit")
@@ -64,9 +65,11 @@ func main() {
 		}
 		diagWr = hcl.NewDiagnosticTextWriter(os.Stderr, parser.Files(), uint(w), color)
 	case "json":
-		diagWr = &jsonDiagWriter{w: os.Stderr}
+		diagWr = &jsonDiagWriter{w: os.Stderr, files: parser.Files()}
+	case "sarif":
+		diagWr = hcl.NewDiagnosticSARIFWriter(os.Stderr, parser.Files(), "hcldec")
 	default:
-		fmt.Fprintf(os.Stderr, "Invalid diagnostics format %q: only \"json\" is supported.\n", *diagsFormat)
+		fmt.Fprintf(os.Stderr, "Invalid diagnostics format %q: must be either \"json\" or \"sarif\".\n", *diagsFormat)
 		os.Exit(2)
 	}
 
@@ -86,7 +89,7 @@ func realmain(args []string) error {
 
 	var diags hcl.Diagnostics
 
-	specContent, specDiags := loadSpecFile(*specFile)
+	specContent, specDiags := specfile.Load(parser, *specFile)
 	diags = append(diags, specDiags...)
 	if specDiags.HasErrors() {
 		err := diagWr.WriteDiagnostics(diags)
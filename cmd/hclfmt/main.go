@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"golang.org/x/term"
@@ -22,24 +23,37 @@ const versionStr = "0.0.1-dev"
 
 var (
 	check       = flag.Bool("check", false, "perform a syntax check on the given files and produce diagnostics")
+	diagsFormat = flag.String("diags", "text", "format diagnostics produced by -check in the given format: \"text\", \"json\" or \"sarif\"")
 	reqNoChange = flag.Bool("require-no-change", false, "return a non-zero status if any files are changed during formatting")
 	overwrite   = flag.Bool("w", false, "overwrite source files instead of writing to stdout")
 	showVersion = flag.Bool("version", false, "show the version number and immediately exit")
+
+	configFile     = flag.String("config", "", "read formatting options from the given HCL file, which flags then override")
+	indentWidth    = flag.Int("indent", 2, "indent each nesting level by the given number of spaces")
+	useTabs        = flag.Bool("tabs", false, "indent with tab characters instead of spaces")
+	noAlign        = flag.Bool("no-align", false, "don't vertically align equals signs and comments")
+	collapseBlanks = flag.Bool("collapse-blank-lines", false, "replace consecutive blank lines with a single blank line")
+	maxWidth       = flag.Int("max-width", 0, "break long lines to fit within the given number of columns, if not zero")
+	trailingCommas = flag.String("trailing-commas", "preserve", "trailing commas in multi-line tuples: \"preserve\", \"always\" or \"never\"")
 )
 
+// formatConfig is the content of the file given by -config, each of whose
+// attributes corresponds to the formatting flag of the same name.
+type formatConfig struct {
+	Indent             *int    `hcl:"indent,optional"`
+	Tabs               *bool   `hcl:"tabs,optional"`
+	Align              *bool   `hcl:"align,optional"`
+	CollapseBlankLines *bool   `hcl:"collapse_blank_lines,optional"`
+	TrailingCommas     *string `hcl:"trailing_commas,optional"`
+	MaxWidth           *int    `hcl:"max_width,optional"`
+}
+
 var parser = hclparse.NewParser()
-var diagWr hcl.DiagnosticWriter // initialized in init
+var diagWr hcl.DiagnosticWriter // initialized in realmain
+var checkDiags hcl.Diagnostics
 var checkErrs = false
 var changed []string
-
-func init() {
-	color := term.IsTerminal(int(os.Stderr.Fd()))
-	w, _, err := term.GetSize(int(os.Stdout.Fd()))
-	if err != nil {
-		w = 80
-	}
-	diagWr = hcl.NewDiagnosticTextWriter(os.Stderr, parser.Files(), uint(w), color)
-}
+var formatOpts hclwrite.FormatOptions // initialized in realmain
 
 func main() {
 	err := realmain()
@@ -59,7 +73,39 @@ func realmain() error {
 		return nil
 	}
 
-	err := processFiles()
+	switch *diagsFormat {
+	case "text":
+		color := term.IsTerminal(int(os.Stderr.Fd()))
+		w, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			w = 80
+		}
+		diagWr = hcl.NewDiagnosticTextWriter(os.Stderr, parser.Files(), uint(w), color)
+	case "json":
+		diagWr = hcl.NewDiagnosticJSONWriter(os.Stderr, parser.Files())
+	case "sarif":
+		diagWr = hcl.NewDiagnosticSARIFWriter(os.Stderr, parser.Files(), "hclfmt")
+	default:
+		return fmt.Errorf("invalid diagnostics format %q: must be \"text\", \"json\" or \"sarif\"", *diagsFormat)
+	}
+
+	opts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+	formatOpts = opts
+
+	err = processFiles()
+
+	// Diagnostics from all files are written together at the end, because
+	// some formats (such as SARIF) can only represent a single set of
+	// diagnostics per document.
+	if *check {
+		if diagErr := diagWr.WriteDiagnostics(checkDiags); diagErr != nil {
+			return fmt.Errorf("failed to write diagnostics: %w", diagErr)
+		}
+	}
+
 	if err != nil {
 		return err
 	}
@@ -124,17 +170,14 @@ func processFile(fn string, in *os.File) error {
 
 	if *check {
 		_, diags := parser.ParseHCL(inSrc, fn)
-		err = diagWr.WriteDiagnostics(diags)
-		if err != nil {
-			return fmt.Errorf("failed to write diagnostics: %w", err)
-		}
+		checkDiags = append(checkDiags, diags...)
 		if diags.HasErrors() {
 			checkErrs = true
 			return nil
 		}
 	}
 
-	outSrc := hclwrite.Format(inSrc)
+	outSrc := hclwrite.FormatWithOptions(inSrc, formatOpts)
 
 	if !bytes.Equal(inSrc, outSrc) {
 		changed = append(changed, fn)
@@ -153,6 +196,78 @@ func processFile(fn string, in *os.File) error {
 	return err
 }
 
+// formatOptions returns the formatting options given by the -config file,
+// if any, overridden by any formatting flags that were set explicitly.
+func formatOptions() (hclwrite.FormatOptions, error) {
+	var config formatConfig
+	if *configFile != "" {
+		f, diags := parser.ParseHCLFile(*configFile)
+		if !diags.HasErrors() {
+			diags = gohcl.DecodeBody(f.Body, nil, &config)
+		}
+		if diags.HasErrors() {
+			//nolint:errcheck // we're already returning an error
+			diagWr.WriteDiagnostics(diags)
+			return hclwrite.FormatOptions{}, fmt.Errorf("invalid config file %s", *configFile)
+		}
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "indent":
+			config.Indent = indentWidth
+		case "tabs":
+			config.Tabs = useTabs
+		case "no-align":
+			align := !*noAlign
+			config.Align = &align
+		case "collapse-blank-lines":
+			config.CollapseBlankLines = collapseBlanks
+		case "trailing-commas":
+			config.TrailingCommas = trailingCommas
+		case "max-width":
+			config.MaxWidth = maxWidth
+		}
+	})
+
+	var opts hclwrite.FormatOptions
+	if config.Indent != nil {
+		if *config.Indent < 1 {
+			return opts, fmt.Errorf("invalid indent %d: must be at least 1", *config.Indent)
+		}
+		opts.IndentWidth = *config.Indent
+	}
+	if config.Tabs != nil {
+		opts.UseTabs = *config.Tabs
+	}
+	if config.Align != nil {
+		opts.NoAlignAssignments = !*config.Align
+		opts.NoAlignComments = !*config.Align
+	}
+	if config.CollapseBlankLines != nil {
+		opts.CollapseBlankLines = *config.CollapseBlankLines
+	}
+	if config.TrailingCommas != nil {
+		switch *config.TrailingCommas {
+		case "preserve":
+			opts.TrailingCommas = hclwrite.TrailingCommasPreserve
+		case "always":
+			opts.TrailingCommas = hclwrite.TrailingCommasAlways
+		case "never":
+			opts.TrailingCommas = hclwrite.TrailingCommasNever
+		default:
+			return opts, fmt.Errorf("invalid trailing commas setting %q: must be \"preserve\", \"always\" or \"never\"", *config.TrailingCommas)
+		}
+	}
+	if config.MaxWidth != nil {
+		if *config.MaxWidth < 0 {
+			return opts, fmt.Errorf("invalid max width %d: must not be negative", *config.MaxWidth)
+		}
+		opts.MaxWidth = *config.MaxWidth
+	}
+	return opts, nil
+}
+
 func usage() {
 	fmt.Fprintf(os.Stderr, "usage: hclfmt [flags] [path ...]\n")
 	flag.PrintDefaults()
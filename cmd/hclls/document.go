@@ -0,0 +1,74 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"net/url"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// document is an open text document, along with the results of parsing and
+// decoding it.
+type document struct {
+	uri      string
+	filename string
+	src      []byte
+
+	// lines converts between the source positions used by HCL and the
+	// UTF-16 based positions used by the protocol.
+	lines *hcl.LineIndex
+
+	file        *hcl.File
+	body        *hclsyntax.Body
+	diags       hcl.Diagnostics
+	validSyntax bool
+}
+
+func (s *server) newDocument(uri string, src []byte) *document {
+	filename := uri
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		filename = u.Path
+	}
+	doc := &document{
+		uri:      uri,
+		filename: filename,
+		src:      src,
+		lines:    hcl.NewLineIndex(src, filename),
+	}
+
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	doc.file = file
+	doc.body = file.Body.(*hclsyntax.Body)
+	doc.validSyntax = !diags.HasErrors()
+	if doc.validSyntax && s.spec != nil {
+		// Decoding a file with syntax errors tends to produce confusing
+		// additional errors, so we only decode valid files.
+		_, decDiags := hcldec.Decode(file.Body, s.spec, s.ctx)
+		diags = append(diags, decDiags...)
+	}
+	doc.diags = diags
+	return doc
+}
+
+// Pos converts a position in the protocol, whose character offset is in
+// UTF-16 code units, to the corresponding position in the source.
+func (d *document) Pos(p position) hcl.Pos {
+	return d.lines.PosForUTF16Pos(hcl.UTF16Pos(p))
+}
+
+// Position converts a position in the source to a position in the protocol.
+func (d *document) Position(pos hcl.Pos) position {
+	return position(d.lines.UTF16PosForPos(pos))
+}
+
+func (d *document) Range(rng hcl.Range) lspRange {
+	r := d.lines.UTF16RangeForRange(rng)
+	return lspRange{
+		Start: position(r.Start),
+		End:   position(r.End),
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/cmd/internal/specfile"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"golang.org/x/term"
+)
+
+const versionStr = "0.0.1-dev"
+
+var (
+	specFile    = flag.String("spec", "", "path to spec file (required)")
+	showVersion = flag.Bool("version", false, "show the version number and immediately exit")
+)
+
+func main() {
+	err := realmain()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func realmain() error {
+	flag.Usage = usage
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(versionStr)
+		return nil
+	}
+
+	if *specFile == "" {
+		return fmt.Errorf("the -spec=... argument is required")
+	}
+
+	parser := hclparse.NewParser()
+	specContent, diags := specfile.Load(parser, *specFile)
+	if diags.HasErrors() {
+		color := term.IsTerminal(int(os.Stderr.Fd()))
+		diagWr := hcl.NewDiagnosticTextWriter(os.Stderr, parser.Files(), 80, color)
+		if err := diagWr.WriteDiagnostics(diags); err != nil {
+			return fmt.Errorf("failed writing diagnostics: %w", err)
+		}
+		os.Exit(2)
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{},
+		Functions: map[string]function.Function{},
+	}
+	for name, val := range specContent.Variables {
+		ctx.Variables[name] = val
+	}
+	for name, f := range specContent.Functions {
+		ctx.Functions[name] = f
+	}
+
+	s := newServer(newConn(os.Stdin, os.Stdout), specContent.RootSpec, ctx)
+	return s.Run()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: hclls -spec=<spec-file>\n")
+	fmt.Fprintf(os.Stderr, "\nRuns a language server for configuration files decoded using the given spec,\ncommunicating over stdin and stdout.\n\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
@@ -0,0 +1,482 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hcled"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// server is a language server for configuration files that are decoded
+// using a particular spec.
+type server struct {
+	conn *conn
+
+	spec   hcldec.Spec
+	schema *hcled.Schema
+	ctx    *hcl.EvalContext
+
+	docs     map[string]*document
+	shutdown bool
+}
+
+func newServer(c *conn, spec hcldec.Spec, ctx *hcl.EvalContext) *server {
+	return &server{
+		conn:   c,
+		spec:   spec,
+		schema: completionSchema(spec),
+		ctx:    ctx,
+		docs:   map[string]*document{},
+	}
+}
+
+// errExit is returned by the handler for the exit notification to stop the
+// server.
+var errExit = errors.New("exit")
+
+// Run handles messages until the client sends the exit notification or the
+// connection is closed. It returns an error if the client exits without
+// first requesting shutdown.
+func (s *server) Run() error {
+	for {
+		content, err := s.conn.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			err = s.conn.Reply(json.RawMessage("null"), nil, &responseError{
+				Code:    codeParseError,
+				Message: err.Error(),
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := s.handle(&req)
+		if err == errExit {
+			if !s.shutdown {
+				return errors.New("client exited without requesting shutdown")
+			}
+			return nil
+		}
+		if req.isNotification() {
+			continue
+		}
+		var rErr *responseError
+		if err != nil && !errors.As(err, &rErr) {
+			rErr = &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if err := s.conn.Reply(req.ID, result, rErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:           textDocumentSyncFull,
+				DocumentFormattingProvider: true,
+				DocumentSymbolProvider:     true,
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				CompletionProvider: &completionOptions{
+					TriggerCharacters: []string{"."},
+				},
+			},
+			ServerInfo: serverInfo{
+				Name:    "hclls",
+				Version: versionStr,
+			},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "exit":
+		return nil, errExit
+
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, []byte(params.TextDocument.Text))
+
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// We use full document synchronization, so the last change has the
+		// complete new content.
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.update(params.TextDocument.URI, []byte(text))
+
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+
+	case "textDocument/formatting":
+		var params documentFormattingParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.formatting(doc), nil
+
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return documentSymbols(doc, hcled.Symbols(doc.file)), nil
+
+	case "textDocument/hover", "textDocument/definition", "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		pos := doc.Pos(params.Position)
+		switch req.Method {
+		case "textDocument/hover":
+			return s.hover(doc, pos), nil
+		case "textDocument/definition":
+			return s.definition(doc, pos), nil
+		default:
+			return s.completion(doc, pos), nil
+		}
+
+	default:
+		if req.isNotification() {
+			// Unknown notifications, such as "initialized", are ignored.
+			return nil, nil
+		}
+		return nil, &responseError{
+			Code:    codeMethodNotFound,
+			Message: fmt.Sprintf("unsupported method %q", req.Method),
+		}
+	}
+}
+
+func (s *server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("document %q is not open", uri)
+	}
+	return doc, nil
+}
+
+// update records the new content of a document and publishes its
+// diagnostics.
+func (s *server) update(uri string, src []byte) error {
+	doc := s.newDocument(uri, src)
+	s.docs[uri] = doc
+
+	diags := []diagnostic{}
+	for _, diag := range doc.diags {
+		var rng lspRange
+		if diag.Subject != nil {
+			if diag.Subject.Filename != doc.filename {
+				// Problems in other files, such as the spec file, are
+				// not the concern of this document.
+				continue
+			}
+			rng = doc.Range(*diag.Subject)
+		}
+		severity := severityError
+		if diag.Severity == hcl.DiagWarning {
+			severity = severityWarning
+		}
+		message := diag.Summary
+		if diag.Detail != "" {
+			message += ": " + diag.Detail
+		}
+		diags = append(diags, diagnostic{
+			Range:    rng,
+			Severity: severity,
+			Source:   "hclls",
+			Message:  message,
+		})
+	}
+	return s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diags,
+	})
+}
+
+func (s *server) formatting(doc *document) []textEdit {
+	if !doc.validSyntax {
+		// Formatting invalid input could make things worse.
+		return nil
+	}
+	formatted := hclwrite.Format(doc.src)
+	if string(formatted) == string(doc.src) {
+		return []textEdit{}
+	}
+	return []textEdit{
+		{
+			Range: lspRange{
+				Start: position{},
+				End:   doc.Position(hcl.Pos{Byte: len(doc.src)}),
+			},
+			NewText: string(formatted),
+		},
+	}
+}
+
+func documentSymbols(doc *document, syms []hcled.Symbol) []documentSymbol {
+	ret := []documentSymbol{}
+	for _, sym := range syms {
+		ds := documentSymbol{
+			Name:           sym.Name,
+			Kind:           symbolKindProperty,
+			Range:          doc.Range(sym.Range),
+			SelectionRange: doc.Range(sym.NameRange),
+		}
+		if sym.Kind == hcled.SymbolBlock {
+			parts := []string{sym.Name}
+			for _, label := range sym.Labels {
+				parts = append(parts, fmt.Sprintf("%q", label))
+			}
+			ds.Name = strings.Join(parts, " ")
+			ds.Kind = symbolKindStruct
+			ds.Children = documentSymbols(doc, sym.Children)
+		}
+		ret = append(ret, ds)
+	}
+	return ret
+}
+
+func (s *server) hover(doc *document, pos hcl.Pos) *hover {
+	if traversal, rng := traversalAtPos(doc.body, pos); traversal != nil {
+		val, diags := traversal.TraverseAbs(s.ctx)
+		if diags.HasErrors() {
+			return nil
+		}
+		content := fmt.Sprintf("`%s`: %s", string(rng.SliceBytes(doc.src)), val.Type().FriendlyName())
+		if val.IsWhollyKnown() && !val.IsNull() && val.Type().IsPrimitiveType() {
+			content += fmt.Sprintf("\n\n```\n%s\n```", hclwrite.TokensForValue(val).Bytes())
+		}
+		return s.hoverResult(doc, content, rng)
+	}
+
+	schema := s.schema
+	body := doc.body
+	for _, block := range doc.file.BlocksAtPos(pos) {
+		synBlock := findSyntaxBlock(body, block)
+		if synBlock == nil {
+			return nil
+		}
+		blockS := findBlockSchema(schema, block.Type)
+		if block.TypeRange.ContainsPos(pos) {
+			if blockS == nil {
+				return nil
+			}
+			content := fmt.Sprintf("**%s** block", block.Type)
+			if len(blockS.LabelNames) > 0 {
+				content += fmt.Sprintf(" with labels %s", strings.Join(blockS.LabelNames, ", "))
+			}
+			return s.hoverResult(doc, content, block.TypeRange)
+		}
+		if blockS == nil {
+			return nil
+		}
+		schema = blockS.Body
+		body = synBlock.Body
+	}
+	if schema == nil {
+		return nil
+	}
+	for _, attr := range body.Attributes {
+		if !attr.NameRange.ContainsPos(pos) {
+			continue
+		}
+		for _, attrS := range schema.Attributes {
+			if attrS.Name != attr.Name {
+				continue
+			}
+			content := fmt.Sprintf("**%s** optional attribute", attr.Name)
+			if attrS.Required {
+				content = fmt.Sprintf("**%s** required attribute", attr.Name)
+			}
+			return s.hoverResult(doc, content, attr.NameRange)
+		}
+	}
+	return nil
+}
+
+func (s *server) hoverResult(doc *document, content string, rng hcl.Range) *hover {
+	lspRng := doc.Range(rng)
+	return &hover{
+		Contents: markupContent{
+			Kind:  "markdown",
+			Value: content,
+		},
+		Range: &lspRng,
+	}
+}
+
+// definition finds the block referred to by a traversal at the given
+// position, where the traversal's root name is the block type and its
+// following steps are the block's labels.
+func (s *server) definition(doc *document, pos hcl.Pos) *location {
+	traversal, _ := traversalAtPos(doc.body, pos)
+	if traversal == nil {
+		return nil
+	}
+	var names []string
+	for _, step := range traversal[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		names = append(names, attr.Name)
+	}
+
+Blocks:
+	for _, block := range doc.body.Blocks {
+		if block.Type != traversal.RootName() || len(block.Labels) > len(names) {
+			continue
+		}
+		for i, label := range block.Labels {
+			if names[i] != label {
+				continue Blocks
+			}
+		}
+		return &location{
+			URI:   doc.uri,
+			Range: doc.Range(block.DefRange()),
+		}
+	}
+	return nil
+}
+
+func (s *server) completion(doc *document, pos hcl.Pos) []completionItem {
+	items := []completionItem{}
+	for _, c := range hcled.Completions(doc.file, pos, s.schema, s.ctx) {
+		var kind int
+		switch c.Kind {
+		case hcled.CompletionAttribute:
+			kind = completionKindProperty
+		case hcled.CompletionBlockType:
+			kind = completionKindStruct
+		case hcled.CompletionBlockLabel:
+			kind = completionKindValue
+		case hcled.CompletionVariable:
+			kind = completionKindVariable
+		case hcled.CompletionTraversalAttr:
+			kind = completionKindField
+		case hcled.CompletionFunction:
+			kind = completionKindFunction
+		}
+		items = append(items, completionItem{
+			Label:  c.Name,
+			Kind:   kind,
+			Detail: c.Detail,
+			TextEdit: &textEdit{
+				Range:   doc.Range(c.Range),
+				NewText: c.Insert,
+			},
+		})
+	}
+	return items
+}
+
+// traversalAtPos returns the absolute traversal whose source range contains
+// the given position, if any, along with that range.
+func traversalAtPos(body *hclsyntax.Body, pos hcl.Pos) (hcl.Traversal, hcl.Range) {
+	var ret hcl.Traversal
+	var rng hcl.Range
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		if expr, ok := node.(*hclsyntax.ScopeTraversalExpr); ok && expr.SrcRange.ContainsPos(pos) {
+			ret = expr.Traversal
+			rng = expr.SrcRange
+		}
+		return nil
+	})
+	return ret, rng
+}
+
+func findSyntaxBlock(body *hclsyntax.Body, block *hcl.Block) *hclsyntax.Block {
+	for _, candidate := range body.Blocks {
+		if candidate.TypeRange == block.TypeRange {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func findBlockSchema(schema *hcled.Schema, typeName string) *hcled.BlockSchema {
+	if schema == nil {
+		return nil
+	}
+	for i := range schema.Blocks {
+		if schema.Blocks[i].Type == typeName {
+			return &schema.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// completionSchema returns the schema of the bodies described by the given
+// spec, including the bodies of any nested blocks.
+func completionSchema(spec hcldec.Spec) *hcled.Schema {
+	implied := hcldec.ImpliedSchema(spec)
+	nested := hcldec.ChildBlockTypes(spec)
+	ret := &hcled.Schema{
+		Attributes: implied.Attributes,
+	}
+	for _, blockS := range implied.Blocks {
+		block := hcled.BlockSchema{
+			Type:       blockS.Type,
+			LabelNames: blockS.LabelNames,
+		}
+		if nestedSpec, ok := nested[blockS.Type]; ok {
+			block.Body = completionSchema(nestedSpec)
+		}
+		ret.Blocks = append(ret.Blocks, block)
+	}
+	return ret
+}
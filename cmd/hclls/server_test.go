@@ -0,0 +1,355 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/cmd/internal/specfile"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+const testSpec = `
+variables {
+  region = "us-east-1"
+}
+
+object {
+  attr "name" {
+    type     = string
+    required = true
+  }
+  attr "target" {
+    type = any
+  }
+  block_map "service" {
+    labels = ["name"]
+    object {
+      attr "port" {
+        type = number
+      }
+    }
+  }
+}
+`
+
+// testClient is a scripted language server client that talks to a server
+// running in the background.
+type testClient struct {
+	t      *testing.T
+	conn   *conn
+	nextID int
+	done   chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	specFilename := filepath.Join(t.TempDir(), "test.hcldec")
+	if err := os.WriteFile(specFilename, []byte(testSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content, diags := specfile.Load(hclparse.NewParser(), specFilename)
+	if diags.HasErrors() {
+		t.Fatalf("invalid spec: %s", diags.Error())
+	}
+	ctx := &hcl.EvalContext{
+		Variables: content.Variables,
+		Functions: content.Functions,
+	}
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	s := newServer(newConn(serverR, serverW), content.RootSpec, ctx)
+	c := &testClient{
+		t:    t,
+		conn: newConn(clientR, clientW),
+		done: make(chan error, 1),
+	}
+	go func() {
+		c.done <- s.Run()
+		serverW.Close()
+	}()
+	return c
+}
+
+// Call sends a request and returns the raw result of the response, failing
+// the test if the response is an error.
+func (c *testClient) Call(method string, params interface{}) json.RawMessage {
+	c.t.Helper()
+	c.nextID++
+	id := c.nextID
+	c.send(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+	var resp response
+	c.receive(&resp)
+	if string(resp.ID) != mustMarshal(c.t, id) {
+		c.t.Fatalf("response has id %s; want %d", resp.ID, id)
+	}
+	if resp.Error != nil {
+		c.t.Fatalf("%s failed: %s", method, resp.Error.Message)
+	}
+	return resp.Result
+}
+
+func (c *testClient) Notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+// Diagnostics reads the next message, which must be a diagnostics
+// notification.
+func (c *testClient) Diagnostics() publishDiagnosticsParams {
+	c.t.Helper()
+	var msg struct {
+		Method string                   `json:"method"`
+		Params publishDiagnosticsParams `json:"params"`
+	}
+	c.receive(&msg)
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("got %q notification; want diagnostics", msg.Method)
+	}
+	return msg.Params
+}
+
+func (c *testClient) send(msg interface{}) {
+	c.t.Helper()
+	if err := c.conn.Write(msg); err != nil {
+		c.t.Fatalf("failed to send: %s", err)
+	}
+}
+
+func (c *testClient) receive(v interface{}) {
+	c.t.Helper()
+	content, err := c.conn.Read()
+	if err != nil {
+		c.t.Fatalf("failed to receive: %s", err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		c.t.Fatalf("invalid message %s: %s", content, err)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+func decode[T any](t *testing.T, raw json.RawMessage) T {
+	t.Helper()
+	var ret T
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		t.Fatalf("invalid result %s: %s", raw, err)
+	}
+	return ret
+}
+
+func TestServer(t *testing.T) {
+	const uri = "file:///work/main.conf"
+	c := newTestClient(t)
+
+	init := decode[initializeResult](t, c.Call("initialize", map[string]interface{}{}))
+	if init.ServerInfo.Name != "hclls" || !init.Capabilities.HoverProvider {
+		t.Errorf("wrong initialize result %#v", init)
+	}
+	c.Notify("initialized", map[string]interface{}{})
+
+	// Opening a document publishes its decode diagnostics.
+	c.Notify("textDocument/didOpen", didOpenTextDocumentParams{
+		TextDocument: textDocumentItem{
+			URI:  uri,
+			Text: "service \"web\" {\n  port = \"http\"\n}\n",
+		},
+	})
+	diags := c.Diagnostics()
+	if len(diags.Diagnostics) != 2 {
+		t.Fatalf("wrong diagnostics %#v", diags)
+	}
+	if got, want := diags.Diagnostics[0].Range, (lspRange{Start: position{0, 0}, End: position{0, 0}}); got != want {
+		t.Errorf("wrong range for missing attribute %#v; want %#v", got, want)
+	}
+	if got, want := diags.Diagnostics[1].Range, (lspRange{Start: position{1, 9}, End: position{1, 15}}); got != want {
+		t.Errorf("wrong range for invalid attribute %#v; want %#v", got, want)
+	}
+
+	// Changing it to have a syntax error publishes the parse diagnostics.
+	c.Notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": "name = \n"}},
+	})
+	diags = c.Diagnostics()
+	if len(diags.Diagnostics) != 1 || diags.Diagnostics[0].Range.Start != (position{0, 7}) {
+		t.Fatalf("wrong diagnostics %#v", diags)
+	}
+
+	src := "name = \"a\"\ntarget = service.web\n\nservice \"web\" {\n  port = 8080\n}\n"
+	c.Notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 3},
+		"contentChanges": []map[string]interface{}{{"text": src}},
+	})
+	// The spec doesn't define a variable for the block that the target
+	// refers to, so decoding fails.
+	if diags := c.Diagnostics(); len(diags.Diagnostics) != 1 || !strings.HasPrefix(diags.Diagnostics[0].Message, "Unknown variable") {
+		t.Fatalf("wrong diagnostics %#v", diags)
+	}
+
+	doc := textDocumentIdentifier{URI: uri}
+
+	t.Run("formatting", func(t *testing.T) {
+		edits := decode[[]textEdit](t, c.Call("textDocument/formatting", documentFormattingParams{TextDocument: doc}))
+		want := []textEdit{
+			{
+				Range:   lspRange{End: position{Line: 6}},
+				NewText: "name   = \"a\"\ntarget = service.web\n\nservice \"web\" {\n  port = 8080\n}\n",
+			},
+		}
+		if diff := cmp.Diff(want, edits); diff != "" {
+			t.Errorf("wrong edits\n%s", diff)
+		}
+	})
+
+	t.Run("document symbols", func(t *testing.T) {
+		symbols := decode[[]documentSymbol](t, c.Call("textDocument/documentSymbol", documentSymbolParams{TextDocument: doc}))
+		var got []string
+		for _, sym := range symbols {
+			got = append(got, sym.Name)
+			for _, child := range sym.Children {
+				got = append(got, sym.Name+" > "+child.Name)
+			}
+		}
+		want := []string{"name", "target", `service "web"`, `service "web" > port`}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("wrong symbols\n%s", diff)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		h := decode[*hover](t, c.Call("textDocument/hover", textDocumentPositionParams{
+			TextDocument: doc,
+			Position:     position{Line: 4, Character: 3},
+		}))
+		if h == nil || h.Contents.Value != "**port** optional attribute" {
+			t.Errorf("wrong hover %#v", h)
+		}
+		h = decode[*hover](t, c.Call("textDocument/hover", textDocumentPositionParams{
+			TextDocument: doc,
+			Position:     position{Line: 0, Character: 1},
+		}))
+		if h == nil || h.Contents.Value != "**name** required attribute" {
+			t.Errorf("wrong hover %#v", h)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		loc := decode[*location](t, c.Call("textDocument/definition", textDocumentPositionParams{
+			TextDocument: doc,
+			Position:     position{Line: 1, Character: 12},
+		}))
+		want := &location{
+			URI:   uri,
+			Range: lspRange{Start: position{Line: 3}, End: position{Line: 3, Character: 13}},
+		}
+		if diff := cmp.Diff(want, loc); diff != "" {
+			t.Errorf("wrong location\n%s", diff)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		c.Notify("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": 4},
+			"contentChanges": []map[string]interface{}{{"text": "service \"web\" {\n  p\n}\ntarget = reg\n"}},
+		})
+		c.Diagnostics()
+
+		items := decode[[]completionItem](t, c.Call("textDocument/completion", textDocumentPositionParams{
+			TextDocument: doc,
+			Position:     position{Line: 1, Character: 3},
+		}))
+		want := []completionItem{
+			{
+				Label:  "port",
+				Kind:   completionKindProperty,
+				Detail: "optional",
+				TextEdit: &textEdit{
+					Range:   lspRange{Start: position{1, 2}, End: position{1, 3}},
+					NewText: "port",
+				},
+			},
+		}
+		if diff := cmp.Diff(want, items); diff != "" {
+			t.Errorf("wrong completions\n%s", diff)
+		}
+
+		items = decode[[]completionItem](t, c.Call("textDocument/completion", textDocumentPositionParams{
+			TextDocument: doc,
+			Position:     position{Line: 3, Character: 12},
+		}))
+		if len(items) != 1 || items[0].Label != "region" || items[0].Kind != completionKindVariable {
+			t.Errorf("wrong completions %#v", items)
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		c.send(map[string]interface{}{"jsonrpc": "2.0", "id": "x", "method": "workspace/unknown"})
+		var resp response
+		c.receive(&resp)
+		if resp.Error == nil || resp.Error.Code != codeMethodNotFound {
+			t.Errorf("wrong response %#v", resp)
+		}
+	})
+
+	c.Notify("textDocument/didClose", didCloseTextDocumentParams{TextDocument: doc})
+	if diags := c.Diagnostics(); diags.URI != uri || len(diags.Diagnostics) != 0 {
+		t.Errorf("wrong diagnostics on close %#v", diags)
+	}
+
+	c.Call("shutdown", nil)
+	c.Notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("server failed: %s", err)
+	}
+}
+
+func TestDocumentPositions(t *testing.T) {
+	s := &server{}
+	doc := s.newDocument("file:///a.conf", []byte("a = \"😀x\"\nb = 1\n"))
+
+	tests := []struct {
+		Pos  position
+		Byte int
+	}{
+		{position{0, 0}, 0},
+		{position{0, 5}, 5},
+		{position{0, 7}, 9}, // after the emoji, which is two UTF-16 units
+		{position{0, 100}, 11},
+		{position{1, 2}, 14},
+		{position{5, 0}, 18},
+	}
+	for _, test := range tests {
+		pos := doc.Pos(test.Pos)
+		if pos.Byte != test.Byte {
+			t.Errorf("%#v is at byte %d; want %d", test.Pos, pos.Byte, test.Byte)
+		}
+		if got := doc.Position(pos); test.Pos.Character != 100 && test.Pos.Line != 5 && got != test.Pos {
+			t.Errorf("byte %d is at %#v; want %#v", pos.Byte, got, test.Pos)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 // Copyright IBM Corp. 2014, 2025
 // SPDX-License-Identifier: MPL-2.0
 
-package main
+// Package specfile loads the spec files that describe how to decode a
+// configuration file, as used by the hcldec and hclls commands. The format
+// is described in cmd/hcldec/spec-format.md.
+package specfile
 
 import (
 	"fmt"
@@ -10,11 +13,13 @@ import (
 	"github.com/hashicorp/hcl/v2/ext/userfunc"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 )
 
-type specFileContent struct {
+// Content is the result of loading a spec file.
+type Content struct {
 	Variables map[string]cty.Value
 	Functions map[string]function.Function
 	RootSpec  hcldec.Spec
@@ -24,10 +29,12 @@ var specCtx = &hcl.EvalContext{
 	Functions: specFuncs,
 }
 
-func loadSpecFile(filename string) (specFileContent, hcl.Diagnostics) {
+// Load reads and decodes the spec file with the given name, using the given
+// parser so that the file is available for rendering diagnostics.
+func Load(parser *hclparse.Parser, filename string) (Content, hcl.Diagnostics) {
 	file, diags := parser.ParseHCLFile(filename)
 	if diags.HasErrors() {
-		return specFileContent{RootSpec: errSpec}, diags
+		return Content{RootSpec: errSpec}, diags
 	}
 
 	vars, funcs, specBody, declDiags := decodeSpecDecls(file.Body)
@@ -36,7 +43,7 @@ func loadSpecFile(filename string) (specFileContent, hcl.Diagnostics) {
 	spec, specDiags := decodeSpecRoot(specBody)
 	diags = append(diags, specDiags...)
 
-	return specFileContent{
+	return Content{
 		Variables: vars,
 		Functions: funcs,
 		RootSpec:  spec,
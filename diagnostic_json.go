@@ -0,0 +1,440 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DiagnosticJSONFormatVersion is the version of the JSON diagnostics format
+// produced by the writer returned from NewDiagnosticJSONWriter.
+//
+// The major version will be incremented for any change that is not backward
+// compatible with existing readers. The minor version will be incremented
+// when new properties are added.
+const DiagnosticJSONFormatVersion = "1.0"
+
+type diagnosticJSONWriter struct {
+	files map[string]*File
+	wr    io.Writer
+}
+
+// NewDiagnosticJSONWriter creates a DiagnosticWriter that writes diagnostics
+// to the given writer as JSON.
+//
+// Each call to WriteDiagnostic or WriteDiagnostics produces a single JSON
+// object followed by a newline, with the following structure:
+//
+//	{
+//	  "format_version": "1.0",
+//	  "diagnostics": [
+//	    {
+//	      "severity": "error",
+//	      "summary": "Unsupported attribute",
+//	      "detail": "An attribute named \"foo\" is not expected here.",
+//	      "subject": {
+//	        "filename": "example.hcl",
+//	        "start": {"line": 1, "column": 1, "byte": 0},
+//	        "end": {"line": 1, "column": 4, "byte": 3}
+//	      },
+//	      "context": { ... },
+//	      "snippet": {
+//	        "context": "resource \"foo\"",
+//	        "code": "foo = 1",
+//	        "start_line": 1,
+//	        "highlight_start_offset": 0,
+//	        "highlight_end_offset": 3,
+//	        "values": [
+//	          {"traversal": "var.bar", "statement": "as \"baz\""}
+//	        ]
+//	      },
+//	      "extra": { ... }
+//	    }
+//	  ]
+//	}
+//
+// The "snippet" property is present only if the diagnostic has a subject
+// range and the source code for the subject file is present in the given
+// files map. The "values" property within it describes the values of any
+// variables referenced by the diagnostic's Expression in its EvalContext,
+// similar to the text produced by NewDiagnosticTextWriter.
+//
+// The "extra" property is the result of serializing the diagnostic's Extra
+// value using package encoding/json. It is omitted if Extra is nil or if it
+// cannot be serialized.
+//
+// Use ReadDiagnosticsJSON to decode the output of this writer.
+func NewDiagnosticJSONWriter(wr io.Writer, files map[string]*File) DiagnosticWriter {
+	return &diagnosticJSONWriter{
+		files: files,
+		wr:    wr,
+	}
+}
+
+func (w *diagnosticJSONWriter) WriteDiagnostic(diag *Diagnostic) error {
+	if diag == nil {
+		return errors.New("nil diagnostic")
+	}
+	return w.WriteDiagnostics(Diagnostics{diag})
+}
+
+func (w *diagnosticJSONWriter) WriteDiagnostics(diags Diagnostics) error {
+	raw := diagnosticsJSON{
+		FormatVersion: DiagnosticJSONFormatVersion,
+		Diagnostics:   make([]diagnosticJSON, 0, len(diags)),
+	}
+	for _, diag := range diags {
+		if diag == nil {
+			return errors.New("nil diagnostic")
+		}
+		raw.Diagnostics = append(raw.Diagnostics, w.diagnosticJSON(diag))
+	}
+
+	src, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to serialize diagnostics: %w", err)
+	}
+	src = append(src, '\n')
+	_, err = w.wr.Write(src)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	return nil
+}
+
+func (w *diagnosticJSONWriter) diagnosticJSON(diag *Diagnostic) diagnosticJSON {
+	ret := diagnosticJSON{
+		Summary: diag.Summary,
+		Detail:  diag.Detail,
+	}
+
+	switch diag.Severity {
+	case DiagError:
+		ret.Severity = "error"
+	case DiagWarning:
+		ret.Severity = "warning"
+	default:
+		// should never happen
+		ret.Severity = "invalid"
+	}
+
+	if diag.Subject != nil {
+		ret.Subject = newDiagnosticRangeJSON(*diag.Subject)
+	}
+	if diag.Context != nil {
+		ret.Context = newDiagnosticRangeJSON(*diag.Context)
+	}
+
+	extra := diag.Extra
+	if fromJSON, ok := extra.(*DiagnosticJSONExtra); ok {
+		// This diagnostic was previously decoded by ReadDiagnosticsJSON, so
+		// we'll preserve the information from the original JSON as long as
+		// we don't have anything more specific to replace it with below.
+		ret.Snippet = fromJSON.Snippet
+		extra = nil
+		if len(fromJSON.Extra) != 0 {
+			ret.Extra = fromJSON.Extra
+		}
+	}
+	if extra != nil {
+		// If the Extra value can't be serialized then we'll just omit it,
+		// since it's always optional and diagnostics must make sense
+		// without it.
+		if src, err := json.Marshal(extra); err == nil {
+			ret.Extra = src
+		}
+	}
+
+	if snippet := w.snippet(diag); snippet != nil {
+		ret.Snippet = snippet
+	}
+
+	return ret
+}
+
+func (w *diagnosticJSONWriter) snippet(diag *Diagnostic) *DiagnosticJSONSnippet {
+	if diag.Subject == nil {
+		return nil
+	}
+	file := w.files[diag.Subject.Filename]
+	if file == nil || file.Bytes == nil {
+		return nil
+	}
+
+	snipRange := *diag.Subject
+	highlightRange := snipRange
+	if diag.Context != nil {
+		// Show enough of the source code to include both the subject
+		// and context ranges, which overlap in all reasonable
+		// situations.
+		snipRange = RangeOver(snipRange, *diag.Context)
+	}
+	// We can't illustrate an empty range, so we'll turn such ranges into
+	// single-character ranges, which might not be totally valid (may point
+	// off the end of a line, or off the end of the file) but are good
+	// enough for the bounds checks we do below.
+	if snipRange.Empty() {
+		snipRange.End.Byte++
+		snipRange.End.Column++
+	}
+	if highlightRange.Empty() {
+		highlightRange.End.Byte++
+		highlightRange.End.Column++
+	}
+
+	ret := &DiagnosticJSONSnippet{
+		Context: contextString(file, diag.Subject.Start.Byte),
+	}
+
+	code, startLine, codeStartByte := diagnosticSnippetLines(file, diag.Subject.Filename, snipRange)
+	if startLine == 0 {
+		// The subject range doesn't overlap the file at all, so there's
+		// nothing useful we can show.
+		return nil
+	}
+	ret.Code = code
+	ret.StartLine = startLine
+
+	start := highlightRange.Start.Byte - codeStartByte
+	end := highlightRange.End.Byte - codeStartByte
+	start = min(max(start, 0), len(ret.Code))
+	end = min(max(end, start), len(ret.Code))
+	ret.HighlightStartOffset = start
+	ret.HighlightEndOffset = end
+
+	if diag.Expression != nil && diag.EvalContext != nil {
+		values := diagnosticExpressionValues(diag.Expression, diag.EvalContext)
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].traversal < values[j].traversal
+		})
+		ret.Values = make([]DiagnosticJSONExpressionValue, len(values))
+		for i, v := range values {
+			ret.Values[i] = DiagnosticJSONExpressionValue{
+				Traversal: v.traversal,
+				Statement: v.statement,
+			}
+		}
+	}
+
+	return ret
+}
+
+// diagnosticSnippetLines returns the full text of all of the lines in the
+// given file that overlap the given range, without a trailing newline,
+// along with the line number and byte offset of the start of the first
+// of those lines.
+//
+// If no lines overlap the given range then the returned line number is zero.
+func diagnosticSnippetLines(file *File, filename string, rng Range) (code string, startLine, startByte int) {
+	sc := NewRangeScanner(file.Bytes, filename, bufio.ScanLines)
+	var buf strings.Builder
+	for sc.Scan() {
+		lineRange := sc.Range()
+		if !lineRange.Overlaps(rng) {
+			continue
+		}
+		if startLine == 0 {
+			startLine = lineRange.Start.Line
+			startByte = lineRange.Start.Byte
+		} else {
+			buf.WriteByte('\n')
+		}
+		buf.Write(sc.Bytes())
+	}
+	return buf.String(), startLine, startByte
+}
+
+// ReadDiagnosticsJSON reads diagnostics in the format produced by
+// NewDiagnosticJSONWriter from the given reader until it is exhausted.
+//
+// The reader may contain any number of JSON objects as produced by separate
+// calls to the writer's methods, and the result is the concatenation of all
+// of the diagnostics they contain.
+//
+// Expression and EvalContext cannot be recovered from the JSON
+// representation, so they are always nil in the result. Instead, each
+// diagnostic's Extra field is set to a *DiagnosticJSONExtra value which
+// describes the source code snippet, if any, and which wraps the JSON
+// representation of the original Extra value.
+//
+// The returned error is non-nil if the input is not valid JSON or does not
+// conform to a supported version of the format. In that case the diagnostics
+// decoded so far are returned along with the error.
+func ReadDiagnosticsJSON(r io.Reader) (Diagnostics, error) {
+	var diags Diagnostics
+	dec := json.NewDecoder(r)
+	for {
+		var raw diagnosticsJSON
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return diags, nil
+		}
+		if err != nil {
+			return diags, fmt.Errorf("invalid diagnostics JSON: %w", err)
+		}
+
+		major, _, _ := strings.Cut(raw.FormatVersion, ".")
+		if major != "1" {
+			return diags, fmt.Errorf("unsupported diagnostics format version %q", raw.FormatVersion)
+		}
+
+		for i, rawDiag := range raw.Diagnostics {
+			diag := &Diagnostic{
+				Summary: rawDiag.Summary,
+				Detail:  rawDiag.Detail,
+			}
+			switch rawDiag.Severity {
+			case "error":
+				diag.Severity = DiagError
+			case "warning":
+				diag.Severity = DiagWarning
+			default:
+				return diags, fmt.Errorf("diagnostic %d has unsupported severity %q", i, rawDiag.Severity)
+			}
+			if rawDiag.Subject != nil {
+				rng := rawDiag.Subject.Range()
+				diag.Subject = &rng
+			}
+			if rawDiag.Context != nil {
+				rng := rawDiag.Context.Range()
+				diag.Context = &rng
+			}
+			if rawDiag.Snippet != nil || len(rawDiag.Extra) != 0 {
+				diag.Extra = &DiagnosticJSONExtra{
+					Snippet: rawDiag.Snippet,
+					Extra:   rawDiag.Extra,
+				}
+			}
+			diags = append(diags, diag)
+		}
+	}
+}
+
+// DiagnosticJSONExtra is the type of the Extra field of diagnostics returned
+// from ReadDiagnosticsJSON.
+//
+// It implements DiagnosticExtraUnwrapper, returning the JSON representation
+// of the original diagnostic's Extra value as a json.RawMessage, if it had
+// one.
+type DiagnosticJSONExtra struct {
+	// Snippet is the source code snippet that was included in the JSON
+	// representation of the diagnostic, or nil if there was none.
+	Snippet *DiagnosticJSONSnippet
+
+	// Extra is the raw JSON representation of the original diagnostic's
+	// Extra value, or nil if there was none.
+	Extra json.RawMessage
+}
+
+var _ DiagnosticExtraUnwrapper = (*DiagnosticJSONExtra)(nil)
+
+func (e *DiagnosticJSONExtra) UnwrapDiagnosticExtra() interface{} {
+	if len(e.Extra) == 0 {
+		return nil
+	}
+	return e.Extra
+}
+
+// DiagnosticJSONSnippet describes the "snippet" property of a diagnostic in
+// the JSON diagnostics format.
+type DiagnosticJSONSnippet struct {
+	// Context is a description of the construct containing the subject,
+	// such as a block header, or an empty string if no such description
+	// is available.
+	Context string `json:"context,omitempty"`
+
+	// Code is the full text of the source lines overlapping the subject and
+	// context ranges, without a trailing newline.
+	Code string `json:"code"`
+
+	// StartLine is the line number in the source file of the first line
+	// in Code.
+	StartLine int `json:"start_line"`
+
+	// HighlightStartOffset and HighlightEndOffset are byte offsets into
+	// Code delimiting the subject range.
+	HighlightStartOffset int `json:"highlight_start_offset"`
+	HighlightEndOffset   int `json:"highlight_end_offset"`
+
+	// Values describes the values of the variables referenced by the
+	// expression that the diagnostic relates to, if any.
+	Values []DiagnosticJSONExpressionValue `json:"values,omitempty"`
+}
+
+// DiagnosticJSONExpressionValue describes the value of one variable
+// referenced by the expression that a diagnostic relates to.
+type DiagnosticJSONExpressionValue struct {
+	// Traversal is the variable reference in HCL-like syntax, such as
+	// "var.foo[0]".
+	Traversal string `json:"traversal"`
+
+	// Statement is an English-language description of the value, suitable
+	// for display after Traversal, such as "as \"bar\"" or "set to null".
+	Statement string `json:"statement"`
+}
+
+type diagnosticsJSON struct {
+	FormatVersion string           `json:"format_version"`
+	Diagnostics   []diagnosticJSON `json:"diagnostics"`
+}
+
+type diagnosticJSON struct {
+	Severity string                 `json:"severity"`
+	Summary  string                 `json:"summary"`
+	Detail   string                 `json:"detail,omitempty"`
+	Subject  *diagnosticRangeJSON   `json:"subject,omitempty"`
+	Context  *diagnosticRangeJSON   `json:"context,omitempty"`
+	Snippet  *DiagnosticJSONSnippet `json:"snippet,omitempty"`
+	Extra    json.RawMessage        `json:"extra,omitempty"`
+}
+
+type diagnosticRangeJSON struct {
+	Filename string            `json:"filename"`
+	Start    diagnosticPosJSON `json:"start"`
+	End      diagnosticPosJSON `json:"end"`
+}
+
+type diagnosticPosJSON struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+func newDiagnosticRangeJSON(rng Range) *diagnosticRangeJSON {
+	return &diagnosticRangeJSON{
+		Filename: rng.Filename,
+		Start: diagnosticPosJSON{
+			Line:   rng.Start.Line,
+			Column: rng.Start.Column,
+			Byte:   rng.Start.Byte,
+		},
+		End: diagnosticPosJSON{
+			Line:   rng.End.Line,
+			Column: rng.End.Column,
+			Byte:   rng.End.Byte,
+		},
+	}
+}
+
+func (r *diagnosticRangeJSON) Range() Range {
+	return Range{
+		Filename: r.Filename,
+		Start: Pos{
+			Line:   r.Start.Line,
+			Column: r.Start.Column,
+			Byte:   r.Start.Byte,
+		},
+		End: Pos{
+			Line:   r.End.Line,
+			Column: r.End.Column,
+			Byte:   r.End.Byte,
+		},
+	}
+}
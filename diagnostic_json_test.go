@@ -0,0 +1,211 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDiagnosticJSONWriter(t *testing.T) {
+	tests := []struct {
+		Input *Diagnostic
+		Want  string
+	}{
+		{
+			&Diagnostic{
+				Severity: DiagError,
+				Summary:  "Splines not reticulated",
+				Detail:   "All splines must be pre-reticulated.",
+			},
+			`{"format_version":"1.0","diagnostics":[{"severity":"error","summary":"Splines not reticulated","detail":"All splines must be pre-reticulated."}]}`,
+		},
+		{
+			&Diagnostic{
+				Severity: DiagWarning,
+				Summary:  "Unsupported attribute",
+				Subject: &Range{
+					Start: Pos{Byte: 16, Column: 1, Line: 3},
+					End:   Pos{Byte: 19, Column: 4, Line: 3},
+				},
+				Extra: map[string]string{"hint": "bam"},
+			},
+			`{"format_version":"1.0","diagnostics":[{"severity":"warning","summary":"Unsupported attribute","subject":{"filename":"","start":{"line":3,"column":1,"byte":16},"end":{"line":3,"column":4,"byte":19}},"snippet":{"context":"hardcoded-context","code":"baz = 3","start_line":3,"highlight_start_offset":0,"highlight_end_offset":3},"extra":{"hint":"bam"}}]}`,
+		},
+		{
+			&Diagnostic{
+				Severity: DiagError,
+				Summary:  "Test of including relevant variable values",
+				Subject: &Range{
+					Start: Pos{Byte: 50, Column: 11, Line: 5},
+					End:   Pos{Byte: 58, Column: 19, Line: 5},
+				},
+				Context: &Range{
+					Start: Pos{Byte: 24, Column: 1, Line: 4},
+					End:   Pos{Byte: 60, Column: 2, Line: 6},
+				},
+				Expression: &diagnosticTestExpr{
+					vars: []Traversal{
+						{TraverseRoot{Name: "foo"}},
+						{TraverseRoot{Name: "null"}},
+						{TraverseRoot{Name: "unknown"}},
+					},
+				},
+				EvalContext: &EvalContext{
+					Variables: map[string]cty.Value{
+						"foo":     cty.StringVal("foo value"),
+						"null":    cty.NullVal(cty.String),
+						"unknown": cty.UnknownVal(cty.String),
+					},
+				},
+				Extra: func() {}, // can't be serialized, so omitted
+			},
+			`{"format_version":"1.0","diagnostics":[{"severity":"error","summary":"Test of including relevant variable values","subject":{"filename":"","start":{"line":5,"column":11,"byte":50},"end":{"line":5,"column":19,"byte":58}},"context":{"filename":"","start":{"line":4,"column":1,"byte":24},"end":{"line":6,"column":2,"byte":60}},"snippet":{"context":"hardcoded-context","code":"block \"party\" {\n  pizza = \"cheese\"\n}","start_line":4,"highlight_start_offset":26,"highlight_end_offset":34,"values":[{"traversal":"foo","statement":"as \"foo value\""},{"traversal":"null","statement":"set to null"}]}}]}`,
+		},
+		{
+			&Diagnostic{
+				Severity: DiagError,
+				Summary:  "Source not available",
+				Subject: &Range{
+					Filename: "other.hcl",
+					Start:    Pos{Byte: 0, Column: 1, Line: 1},
+					End:      Pos{Byte: 1, Column: 2, Line: 1},
+				},
+			},
+			`{"format_version":"1.0","diagnostics":[{"severity":"error","summary":"Source not available","subject":{"filename":"other.hcl","start":{"line":1,"column":1,"byte":0},"end":{"line":1,"column":2,"byte":1}}}]}`,
+		},
+	}
+
+	files := map[string]*File{
+		"": &File{
+			Bytes: []byte(testDiagnosticTextWriterSource),
+			Nav:   &diagnosticTestNav{},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			bwr := &bytes.Buffer{}
+			dwr := NewDiagnosticJSONWriter(bwr, files)
+			err := dwr.WriteDiagnostic(test.Input)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := bwr.String()
+			want := test.Want + "\n"
+			if got != want {
+				t.Errorf("wrong result\n\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestReadDiagnosticsJSON(t *testing.T) {
+	subject := &Range{
+		Filename: "",
+		Start:    Pos{Byte: 16, Column: 1, Line: 3},
+		End:      Pos{Byte: 19, Column: 4, Line: 3},
+	}
+	input := Diagnostics{
+		{
+			Severity: DiagError,
+			Summary:  "First",
+			Detail:   "First detail.",
+		},
+		{
+			Severity: DiagWarning,
+			Summary:  "Second",
+			Subject:  subject,
+			Context:  subject,
+			Extra:    []int{1, 2},
+		},
+	}
+	files := map[string]*File{
+		"": &File{
+			Bytes: []byte(testDiagnosticTextWriterSource),
+		},
+	}
+
+	var buf bytes.Buffer
+	wr := NewDiagnosticJSONWriter(&buf, files)
+	if err := wr.WriteDiagnostics(input[:1]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := wr.WriteDiagnostic(input[1]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	firstOutput := buf.String()
+
+	got, err := ReadDiagnosticsJSON(strings.NewReader(firstOutput))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := Diagnostics{
+		{
+			Severity: DiagError,
+			Summary:  "First",
+			Detail:   "First detail.",
+		},
+		{
+			Severity: DiagWarning,
+			Summary:  "Second",
+			Subject:  subject,
+			Context:  subject,
+			Extra: &DiagnosticJSONExtra{
+				Snippet: &DiagnosticJSONSnippet{
+					Code:                 "baz = 3",
+					StartLine:            3,
+					HighlightStartOffset: 0,
+					HighlightEndOffset:   3,
+				},
+				Extra: json.RawMessage(`[1,2]`),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+
+	if raw, ok := DiagnosticExtra[json.RawMessage](got[1]); !ok || string(raw) != "[1,2]" {
+		t.Errorf("wrong unwrapped extra %q", raw)
+	}
+
+	// Writing the decoded diagnostics again, without any source files
+	// available, must produce the same JSON we started with.
+	buf.Reset()
+	wr = NewDiagnosticJSONWriter(&buf, nil)
+	if err := wr.WriteDiagnostics(got[:1]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := wr.WriteDiagnostics(got[1:]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if secondOutput := buf.String(); secondOutput != firstOutput {
+		t.Errorf("round-trip produced different result\n\ngot:\n%s\nwant:\n%s", secondOutput, firstOutput)
+	}
+}
+
+func TestReadDiagnosticsJSONInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":            `{`,
+		"unsupported version": `{"format_version":"2.0","diagnostics":[]}`,
+		"missing version":     `{"diagnostics":[]}`,
+		"bad severity":        `{"format_version":"1.0","diagnostics":[{"severity":"fatal","summary":"oops"}]}`,
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadDiagnosticsJSON(strings.NewReader(input))
+			if err == nil {
+				t.Fatalf("unexpected success")
+			}
+		})
+	}
+}
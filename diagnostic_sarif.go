@@ -0,0 +1,294 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+type diagnosticSARIFWriter struct {
+	files    map[string]*File
+	wr       io.Writer
+	toolName string
+}
+
+// NewDiagnosticSARIFWriter creates a DiagnosticWriter that writes diagnostics
+// to the given writer as a SARIF 2.1.0 log, as accepted by many code scanning
+// systems.
+//
+// Each call to WriteDiagnostic or WriteDiagnostics produces a complete SARIF
+// log containing a single run, followed by a newline. Callers that wish to
+// report diagnostics from multiple sources in a single log should therefore
+// collect them all together and write them with a single call to
+// WriteDiagnostics.
+//
+// The given tool name is used as the name of the run's tool driver, which
+// SARIF requires.
+//
+// SARIF has no direct equivalent of the HCL diagnostic summary, so each
+// distinct summary is treated as a separate rule whose ID is derived from
+// the summary text by converting it to lowercase and replacing each sequence
+// of non-alphanumeric characters with a single dash. For example, a
+// diagnostic with the summary "Unsupported argument" has the rule ID
+// "unsupported-argument".
+//
+// The subject range of each diagnostic becomes the region of its location,
+// and the context range, expanded to cover whole lines, becomes its context
+// region. Source code snippets are included for both if the source of the
+// file is present in the given files map. Columns are measured in Unicode
+// characters, as with Pos.Column, so the run declares a column kind of
+// "unicodeCodePoints".
+func NewDiagnosticSARIFWriter(wr io.Writer, files map[string]*File, toolName string) DiagnosticWriter {
+	return &diagnosticSARIFWriter{
+		files:    files,
+		wr:       wr,
+		toolName: toolName,
+	}
+}
+
+func (w *diagnosticSARIFWriter) WriteDiagnostic(diag *Diagnostic) error {
+	if diag == nil {
+		return errors.New("nil diagnostic")
+	}
+	return w.WriteDiagnostics(Diagnostics{diag})
+}
+
+func (w *diagnosticSARIFWriter) WriteDiagnostics(diags Diagnostics) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifToolComponent{
+				Name:  w.toolName,
+				Rules: []sarifRule{},
+			},
+		},
+		ColumnKind: "unicodeCodePoints",
+		Results:    make([]sarifResult, 0, len(diags)),
+	}
+	ruleIndex := make(map[string]int)
+
+	for _, diag := range diags {
+		if diag == nil {
+			return errors.New("nil diagnostic")
+		}
+
+		ruleID := sarifRuleID(diag.Summary)
+		idx, exists := ruleIndex[ruleID]
+		if !exists {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[ruleID] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               ruleID,
+				ShortDescription: &sarifMessage{Text: diag.Summary},
+			})
+		}
+
+		result := sarifResult{
+			RuleID:    ruleID,
+			RuleIndex: idx,
+			Message:   sarifMessage{Text: diag.Summary},
+		}
+		if diag.Detail != "" {
+			result.Message.Text = diag.Summary + "\n\n" + diag.Detail
+		}
+		switch diag.Severity {
+		case DiagError:
+			result.Level = "error"
+		case DiagWarning:
+			result.Level = "warning"
+		default:
+			// should never happen
+			result.Level = "none"
+		}
+		if diag.Subject != nil {
+			result.Locations = []sarifLocation{w.location(diag)}
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+	src, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to serialize diagnostics: %w", err)
+	}
+	src = append(src, '\n')
+	_, err = w.wr.Write(src)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	return nil
+}
+
+// sarifURI returns the URI reference used to identify the file with the
+// given name in a SARIF log.
+//
+// Most filenames become URI references containing only a path, with their
+// separators converted to slashes, but a Windows path that starts with a drive letter,
+// such as C:\x\y.hcl, would then be misread as a URI whose scheme is the
+// drive letter, and so we instead produce a file URI like file:///C:/x/y.hcl.
+// We recognize drive letters on all platforms, since diagnostics may refer
+// to files on another system.
+func sarifURI(filename string) string {
+	if len(filename) >= 3 && filename[1] == ':' && (filename[2] == '\\' || filename[2] == '/') &&
+		('a' <= filename[0] && filename[0] <= 'z' || 'A' <= filename[0] && filename[0] <= 'Z') {
+		path := "/" + strings.ReplaceAll(filename, "\\", "/")
+		return (&url.URL{Scheme: "file", Path: path}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(filename)}).String()
+}
+
+func (w *diagnosticSARIFWriter) location(diag *Diagnostic) sarifLocation {
+	subject := *diag.Subject
+	loc := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{
+			URI: sarifURI(subject.Filename),
+		},
+		Region: newSARIFRegion(subject),
+	}
+
+	file := w.files[subject.Filename]
+	if file != nil && file.Bytes != nil {
+		if subject.Start.Byte >= 0 && subject.Start.Byte <= subject.End.Byte && subject.End.Byte <= len(file.Bytes) {
+			loc.Region.Snippet = &sarifArtifactContent{
+				Text: string(subject.SliceBytes(file.Bytes)),
+			}
+		}
+
+		ctxRange := subject
+		if diag.Context != nil {
+			ctxRange = RangeOver(ctxRange, *diag.Context)
+		}
+		if ctxRange.Empty() {
+			// An empty range can't overlap anything, so we'll extend it
+			// to include the following character, if any.
+			ctxRange.End.Byte++
+			ctxRange.End.Column++
+		}
+		code, startLine, startByte := diagnosticSnippetLines(file, subject.Filename, ctxRange)
+		if startLine != 0 {
+			endLine := startLine + strings.Count(code, "\n")
+			loc.ContextRegion = &sarifRegion{
+				StartLine:  startLine,
+				EndLine:    endLine,
+				ByteOffset: startByte,
+				ByteLength: len(code),
+				Snippet:    &sarifArtifactContent{Text: code},
+			}
+		}
+	}
+
+	return sarifLocation{PhysicalLocation: loc}
+}
+
+func newSARIFRegion(rng Range) *sarifRegion {
+	return &sarifRegion{
+		StartLine:   rng.Start.Line,
+		StartColumn: rng.Start.Column,
+		EndLine:     rng.End.Line,
+		EndColumn:   rng.End.Column,
+		ByteOffset:  rng.Start.Byte,
+		ByteLength:  rng.End.Byte - rng.Start.Byte,
+	}
+}
+
+// sarifRuleID derives a SARIF rule ID from a diagnostic summary.
+func sarifRuleID(summary string) string {
+	var buf strings.Builder
+	pendingDash := false
+	for _, r := range summary {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingDash = true
+			continue
+		}
+		if pendingDash && buf.Len() != 0 {
+			buf.WriteByte('-')
+		}
+		pendingDash = false
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	if buf.Len() == 0 {
+		return "diagnostic"
+	}
+	return buf.String()
+}
+
+// The following types represent the subset of the SARIF 2.1.0 object model
+// that we use when writing diagnostics.
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifToolComponent `json:"driver"`
+}
+
+type sarifToolComponent struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+	ContextRegion    *sarifRegion          `json:"contextRegion,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int                   `json:"startLine,omitempty"`
+	StartColumn int                   `json:"startColumn,omitempty"`
+	EndLine     int                   `json:"endLine,omitempty"`
+	EndColumn   int                   `json:"endColumn,omitempty"`
+	ByteOffset  int                   `json:"byteOffset"`
+	ByteLength  int                   `json:"byteLength"`
+	Snippet     *sarifArtifactContent `json:"snippet,omitempty"`
+}
+
+type sarifArtifactContent struct {
+	Text string `json:"text"`
+}
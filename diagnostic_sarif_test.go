@@ -0,0 +1,203 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiagnosticSARIFWriter(t *testing.T) {
+	diags := Diagnostics{
+		{
+			Severity: DiagError,
+			Summary:  "Unsupported attribute",
+			Detail:   `"baz" is not a supported top-level attribute.`,
+			Subject: &Range{
+				Filename: "dir/test.hcl",
+				Start:    Pos{Byte: 16, Column: 1, Line: 3},
+				End:      Pos{Byte: 19, Column: 4, Line: 3},
+			},
+		},
+		{
+			Severity: DiagWarning,
+			Summary:  "Pizza not delivered",
+			Subject: &Range{
+				Filename: "dir/test.hcl",
+				Start:    Pos{Byte: 50, Column: 11, Line: 5},
+				End:      Pos{Byte: 58, Column: 19, Line: 5},
+			},
+			Context: &Range{
+				Filename: "dir/test.hcl",
+				Start:    Pos{Byte: 24, Column: 1, Line: 4},
+				End:      Pos{Byte: 60, Column: 2, Line: 6},
+			},
+		},
+		{
+			Severity: DiagError,
+			Summary:  "Unsupported attribute",
+			Subject: &Range{
+				Filename: "other.hcl",
+				Start:    Pos{Byte: 0, Column: 1, Line: 1},
+				End:      Pos{Byte: 3, Column: 4, Line: 1},
+			},
+		},
+		{
+			Severity: DiagError,
+			Summary:  "No source location",
+		},
+	}
+	files := map[string]*File{
+		"dir/test.hcl": &File{
+			Bytes: []byte(testDiagnosticTextWriterSource),
+		},
+	}
+
+	var buf bytes.Buffer
+	wr := NewDiagnosticSARIFWriter(&buf, files, "hcltest")
+	err := wr.WriteDiagnostics(diags)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "hcltest",
+          "rules": [
+            {"id": "unsupported-attribute", "shortDescription": {"text": "Unsupported attribute"}},
+            {"id": "pizza-not-delivered", "shortDescription": {"text": "Pizza not delivered"}},
+            {"id": "no-source-location", "shortDescription": {"text": "No source location"}}
+          ]
+        }
+      },
+      "columnKind": "unicodeCodePoints",
+      "results": [
+        {
+          "ruleId": "unsupported-attribute",
+          "ruleIndex": 0,
+          "level": "error",
+          "message": {"text": "Unsupported attribute\n\n\"baz\" is not a supported top-level attribute."},
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {"uri": "dir/test.hcl"},
+                "region": {
+                  "startLine": 3, "startColumn": 1, "endLine": 3, "endColumn": 4,
+                  "byteOffset": 16, "byteLength": 3,
+                  "snippet": {"text": "baz"}
+                },
+                "contextRegion": {
+                  "startLine": 3, "endLine": 3,
+                  "byteOffset": 16, "byteLength": 7,
+                  "snippet": {"text": "baz = 3"}
+                }
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "pizza-not-delivered",
+          "ruleIndex": 1,
+          "level": "warning",
+          "message": {"text": "Pizza not delivered"},
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {"uri": "dir/test.hcl"},
+                "region": {
+                  "startLine": 5, "startColumn": 11, "endLine": 5, "endColumn": 19,
+                  "byteOffset": 50, "byteLength": 8,
+                  "snippet": {"text": "\"cheese\""}
+                },
+                "contextRegion": {
+                  "startLine": 4, "endLine": 6,
+                  "byteOffset": 24, "byteLength": 36,
+                  "snippet": {"text": "block \"party\" {\n  pizza = \"cheese\"\n}"}
+                }
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "unsupported-attribute",
+          "ruleIndex": 0,
+          "level": "error",
+          "message": {"text": "Unsupported attribute"},
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {"uri": "other.hcl"},
+                "region": {
+                  "startLine": 1, "startColumn": 1, "endLine": 1, "endColumn": 4,
+                  "byteOffset": 0, "byteLength": 3
+                }
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "no-source-location",
+          "ruleIndex": 2,
+          "level": "error",
+          "message": {"text": "No source location"}
+        }
+      ]
+    }
+  ]
+}`
+
+	var gotVal, wantVal interface{}
+	if err := json.Unmarshal(buf.Bytes(), &gotVal); err != nil {
+		t.Fatalf("writer produced invalid JSON: %s", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantVal); err != nil {
+		t.Fatalf("invalid expected JSON: %s", err)
+	}
+	if diff := cmp.Diff(wantVal, gotVal); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestSARIFRuleID(t *testing.T) {
+	tests := map[string]string{
+		"Unsupported argument":        "unsupported-argument",
+		"Missing required argument":   "missing-required-argument",
+		"Invalid `for` expression!":   "invalid-for-expression",
+		"  Leading and trailing --  ": "leading-and-trailing",
+		"Übergröße":                   "übergröße",
+		"":                            "diagnostic",
+		"***":                         "diagnostic",
+	}
+
+	for input, want := range tests {
+		if got := sarifRuleID(input); got != want {
+			t.Errorf("wrong result for %q\ngot:  %s\nwant: %s", input, got, want)
+		}
+	}
+}
+
+func TestSARIFURI(t *testing.T) {
+	tests := map[string]string{
+		"test.hcl":            "test.hcl",
+		"dir/test file.hcl":   "dir/test%20file.hcl",
+		"/abs/test.hcl":       "/abs/test.hcl",
+		`C:\dir\test.hcl`:     "file:///C:/dir/test.hcl",
+		"c:/dir/test.hcl":     "file:///c:/dir/test.hcl",
+		`D:\with space\x.hcl`: "file:///D:/with%20space/x.hcl",
+	}
+
+	for input, want := range tests {
+		if got := sarifURI(input); got != want {
+			t.Errorf("wrong result for %q\ngot:  %s\nwant: %s", input, got, want)
+		}
+	}
+}
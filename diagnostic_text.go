@@ -163,38 +163,10 @@ func (w *diagnosticTextWriter) WriteDiagnostic(diag *Diagnostic) error {
 			// referenced in the given expression as additional context, for
 			// situations where the same expression is evaluated multiple
 			// times in different scopes.
-			expr := diag.Expression
-			ctx := diag.EvalContext
-
-			vars := expr.Variables()
-			stmts := make([]string, 0, len(vars))
-			seen := make(map[string]struct{}, len(vars))
-			for _, traversal := range vars {
-				val, diags := traversal.TraverseAbs(ctx)
-				if diags.HasErrors() {
-					// Skip anything that generates errors, since we probably
-					// already have the same error in our diagnostics set
-					// already.
-					continue
-				}
-
-				traversalStr := w.traversalStr(traversal)
-				if _, exists := seen[traversalStr]; exists {
-					continue // don't show duplicates when the same variable is referenced multiple times
-				}
-				switch {
-				case !val.IsKnown():
-					// Can't say anything about this yet, then.
-					continue
-				case val.IsNull():
-					stmts = append(stmts, fmt.Sprintf("%s set to null", traversalStr))
-				case val.IsMarked():
-					// Skip the marked values as it is not clear here how they should be rendered.
-					continue
-				default:
-					stmts = append(stmts, fmt.Sprintf("%s as %s", traversalStr, w.valueStr(val)))
-				}
-				seen[traversalStr] = struct{}{}
+			values := diagnosticExpressionValues(diag.Expression, diag.EvalContext)
+			stmts := make([]string, 0, len(values))
+			for _, v := range values {
+				stmts = append(stmts, v.traversal+" "+v.statement)
 			}
 
 			sort.Strings(stmts) // FIXME: Should maybe use a traversal-aware sort that can sort numeric indexes properly?
@@ -252,7 +224,7 @@ func (w *diagnosticTextWriter) WriteDiagnostics(diags Diagnostics) error {
 	return nil
 }
 
-func (w *diagnosticTextWriter) traversalStr(traversal Traversal) string {
+func diagnosticTraversalStr(traversal Traversal) string {
 	// This is a specialized subset of traversal rendering tailored to
 	// producing helpful contextual messages in diagnostics. It is not
 	// comprehensive nor intended to be used for other purposes.
@@ -268,7 +240,7 @@ func (w *diagnosticTextWriter) traversalStr(traversal Traversal) string {
 		case TraverseIndex:
 			buf.WriteByte('[')
 			if keyTy := tStep.Key.Type(); keyTy.IsPrimitiveType() {
-				buf.WriteString(w.valueStr(tStep.Key))
+				buf.WriteString(diagnosticValueStr(tStep.Key))
 			} else {
 				// We'll just use a placeholder for more complex values,
 				// since otherwise our result could grow ridiculously long.
@@ -280,7 +252,7 @@ func (w *diagnosticTextWriter) traversalStr(traversal Traversal) string {
 	return buf.String()
 }
 
-func (w *diagnosticTextWriter) valueStr(val cty.Value) string {
+func diagnosticValueStr(val cty.Value) string {
 	// This is a specialized subset of value rendering tailored to producing
 	// helpful but concise messages in diagnostics. It is not comprehensive
 	// nor intended to be used for other purposes.
@@ -336,6 +308,54 @@ func (w *diagnosticTextWriter) valueStr(val cty.Value) string {
 	}
 }
 
+// diagnosticExprValue is a single entry in the summary of variable values
+// that diagnostic writers can include for a diagnostic that has both an
+// Expression and an EvalContext.
+type diagnosticExprValue struct {
+	traversal string
+	statement string
+}
+
+// diagnosticExpressionValues returns a description of the value of each of
+// the distinct variables referenced by the given expression in the given
+// context, in no particular order.
+//
+// Variables that cannot be resolved, whose values are unknown, or whose
+// values are marked are excluded.
+func diagnosticExpressionValues(expr Expression, ctx *EvalContext) []diagnosticExprValue {
+	vars := expr.Variables()
+	ret := make([]diagnosticExprValue, 0, len(vars))
+	seen := make(map[string]struct{}, len(vars))
+	for _, traversal := range vars {
+		val, diags := traversal.TraverseAbs(ctx)
+		if diags.HasErrors() {
+			// Skip anything that generates errors, since we probably
+			// already have the same error in our diagnostics set
+			// already.
+			continue
+		}
+
+		traversalStr := diagnosticTraversalStr(traversal)
+		if _, exists := seen[traversalStr]; exists {
+			continue // don't show duplicates when the same variable is referenced multiple times
+		}
+		switch {
+		case !val.IsKnown():
+			// Can't say anything about this yet, then.
+			continue
+		case val.IsNull():
+			ret = append(ret, diagnosticExprValue{traversalStr, "set to null"})
+		case val.IsMarked():
+			// Skip the marked values as it is not clear here how they should be rendered.
+			continue
+		default:
+			ret = append(ret, diagnosticExprValue{traversalStr, "as " + diagnosticValueStr(val)})
+		}
+		seen[traversalStr] = struct{}{}
+	}
+	return ret
+}
+
 func contextString(file *File, offset int) string {
 	type contextStringer interface {
 		ContextString(offset int) string
@@ -0,0 +1,212 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// EvalBudget describes limits on the resources that may be consumed when
+// evaluating expressions, for applications that evaluate configuration from
+// untrusted sources.
+//
+// An EvalBudget is attached to an EvalContext using its Budget field, and
+// applies to all evaluation using that context and any of its descendants.
+// Expression implementations check the budget as they work and return an
+// error diagnostic as soon as any limit is exceeded. Such diagnostics have
+// an Extra value implementing EvalBudgetDiagExtra.
+//
+// Some limits are cumulative, so a budget should not be reused across
+// unrelated evaluations unless the caller intends them to share a single
+// allowance. A budget may be shared between goroutines.
+//
+// The zero value of each field means that the corresponding resource is not
+// limited.
+type EvalBudget struct {
+	// MaxForIterations is the maximum total number of elements that all
+	// "for" expressions evaluated with this budget may iterate over, in
+	// total. Nested "for" expressions therefore consume the product of
+	// their collection lengths.
+	MaxForIterations int
+
+	// MaxTemplateLength is the maximum length in bytes of the result of
+	// any single string template.
+	MaxTemplateLength int
+
+	// MaxCollectionLength is the maximum number of elements in a single
+	// collection, tuple or object value produced by a tuple or object
+	// constructor, a "for" expression, a splat expression or a function call.
+	//
+	// The result of a function call is checked only once the function has
+	// returned it, since HCL cannot predict the result size of an arbitrary
+	// function from its arguments. This limit therefore does not bound the
+	// memory or time used by the function itself, and applications that
+	// offer functions able to produce large results, such as "range", should
+	// limit those within the functions.
+	MaxCollectionLength int
+
+	// Deadline, if not the zero time, is a time after which evaluation
+	// will stop with an error.
+	Deadline time.Time
+
+	// Context, if set, allows the caller to cancel evaluation. Evaluation
+	// will stop with an error once the context is done. Any deadline on
+	// the context is respected in addition to the Deadline field.
+	Context context.Context
+
+	forIterations atomic.Int64
+}
+
+// Budget limit names returned from EvalBudgetDiagExtra.EvalBudgetLimit.
+const (
+	EvalBudgetLimitForIterations    = "for_iterations"
+	EvalBudgetLimitTemplateLength   = "template_length"
+	EvalBudgetLimitCollectionLength = "collection_length"
+	EvalBudgetLimitDeadline         = "deadline"
+)
+
+// EvalBudgetDiagExtra is an interface implemented by the Extra value of
+// diagnostics produced when evaluation exceeds an EvalBudget limit, so that
+// callers can distinguish these from errors in the configuration itself.
+type EvalBudgetDiagExtra interface {
+	// EvalBudgetLimit returns the name of the limit that was exceeded,
+	// which is one of the EvalBudgetLimit... constants.
+	EvalBudgetLimit() string
+}
+
+type evalBudgetDiagExtra string
+
+func (e evalBudgetDiagExtra) EvalBudgetLimit() string {
+	return string(e)
+}
+
+// ActiveBudget returns the budget that applies to evaluation in the
+// receiving context, which is the Budget of the nearest context in the
+// chain of ancestors that has one. Returns nil if no budget applies.
+//
+// It is safe to call ActiveBudget on a nil EvalContext.
+func (ctx *EvalContext) ActiveBudget() *EvalBudget {
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		if thisCtx.Budget != nil {
+			return thisCtx.Budget
+		}
+	}
+	return nil
+}
+
+// CheckDeadline returns an error diagnostic if the budget's deadline has
+// passed or its context has been cancelled. The given range is used as the
+// subject of any diagnostic.
+//
+// It is safe to call CheckDeadline on a nil budget, which never returns
+// diagnostics.
+func (b *EvalBudget) CheckDeadline(rng Range) Diagnostics {
+	if b == nil {
+		return nil
+	}
+	if b.Context != nil {
+		if err := b.Context.Err(); err != nil {
+			return b.diags(
+				EvalBudgetLimitDeadline,
+				"Evaluation cancelled",
+				fmt.Sprintf("Evaluation was stopped before completion: %s.", err),
+				rng,
+			)
+		}
+	}
+	if !b.Deadline.IsZero() && time.Now().After(b.Deadline) {
+		return b.diags(
+			EvalBudgetLimitDeadline,
+			"Evaluation took too long",
+			"Evaluation did not complete within the time allowed.",
+			rng,
+		)
+	}
+	return nil
+}
+
+// forDeadlineInterval is the number of "for" expression iterations between
+// each check of the deadline by ConsumeForIteration, which avoids reading
+// the clock for every element of a large collection.
+const forDeadlineInterval = 64
+
+// ConsumeForIteration records that a "for" expression is about to visit one
+// more element, returning an error diagnostic if doing so would exceed
+// MaxForIterations. It also returns any error from CheckDeadline, though
+// it checks the deadline only on the first of every few iterations, so
+// evaluation may continue briefly after the deadline has passed. The given
+// range is used as the subject of any diagnostic.
+//
+// It is safe to call ConsumeForIteration on a nil budget, which never
+// returns diagnostics.
+func (b *EvalBudget) ConsumeForIteration(rng Range) Diagnostics {
+	if b == nil {
+		return nil
+	}
+	n := b.forIterations.Add(1)
+	if b.MaxForIterations > 0 && n > int64(b.MaxForIterations) {
+		return b.diags(
+			EvalBudgetLimitForIterations,
+			"Too many iterations",
+			fmt.Sprintf("The 'for' expressions in this configuration would visit more than the maximum of %d elements.", b.MaxForIterations),
+			rng,
+		)
+	}
+	if n%forDeadlineInterval != 1 {
+		return nil
+	}
+	return b.CheckDeadline(rng)
+}
+
+// CheckTemplateLength returns an error diagnostic if a template result of
+// the given length in bytes would exceed MaxTemplateLength. The given range
+// is used as the subject of any diagnostic.
+//
+// It is safe to call CheckTemplateLength on a nil budget, which never
+// returns diagnostics.
+func (b *EvalBudget) CheckTemplateLength(length int, rng Range) Diagnostics {
+	if b == nil || b.MaxTemplateLength <= 0 || length <= b.MaxTemplateLength {
+		return nil
+	}
+	return b.diags(
+		EvalBudgetLimitTemplateLength,
+		"Template result too long",
+		fmt.Sprintf("The result of this template would be longer than the maximum of %d bytes.", b.MaxTemplateLength),
+		rng,
+	)
+}
+
+// CheckCollectionLength returns an error diagnostic if a collection, tuple
+// or object with the given number of elements would exceed
+// MaxCollectionLength. The given range is used as the subject of any
+// diagnostic.
+//
+// It is safe to call CheckCollectionLength on a nil budget, which never
+// returns diagnostics.
+func (b *EvalBudget) CheckCollectionLength(length int, rng Range) Diagnostics {
+	if b == nil || b.MaxCollectionLength <= 0 || length <= b.MaxCollectionLength {
+		return nil
+	}
+	return b.diags(
+		EvalBudgetLimitCollectionLength,
+		"Result too large",
+		fmt.Sprintf("The result of this expression would have more than the maximum of %d elements.", b.MaxCollectionLength),
+		rng,
+	)
+}
+
+func (b *EvalBudget) diags(limit, summary, detail string, rng Range) Diagnostics {
+	return Diagnostics{
+		{
+			Severity: DiagError,
+			Summary:  summary,
+			Detail:   detail,
+			Subject:  rng.Ptr(),
+			Extra:    evalBudgetDiagExtra(limit),
+		},
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvalBudgetConsumeForIterationDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	budget := &EvalBudget{Context: ctx}
+
+	for i := 1; i <= 2*forDeadlineInterval+1; i++ {
+		diags := budget.ConsumeForIteration(Range{})
+		want := i%forDeadlineInterval == 1
+		if got := diags.HasErrors(); got != want {
+			t.Fatalf("iteration %d returned errors %t; want %t", i, got, want)
+		}
+	}
+}
@@ -13,7 +13,49 @@ import (
 type EvalContext struct {
 	Variables map[string]cty.Value
 	Functions map[string]function.Function
-	parent    *EvalContext
+
+	// Resolver, if set, is consulted for any variable or function that is
+	// not present in the Variables or Functions maps of this context. This
+	// allows callers to populate the scope lazily, computing only the values
+	// that are actually referenced.
+	//
+	// A name defined by either the maps or the resolver of a context shadows
+	// any definition of the same name in its ancestors, as described for
+	// LookupVariable.
+	Resolver EvalContextResolver
+
+	// Budget, if set, limits the resources that may be consumed when
+	// evaluating expressions in this context and its descendants. Use
+	// ActiveBudget to find the budget that applies to a particular context.
+	Budget *EvalBudget
+
+	parent *EvalContext
+}
+
+// EvalContextResolver is the interface implemented by values that can be
+// assigned to the Resolver field of EvalContext.
+//
+// A resolver may be called more than once for the same name, including when
+// rendering diagnostics after evaluation has completed, so implementations
+// that are expensive to call should cache their results.
+type EvalContextResolver interface {
+	// ResolveVariable returns the value of the variable whose root name is
+	// given, which may be an unknown value. The given range is the source
+	// range of the reference, for use in diagnostics.
+	//
+	// If the resolver has no definition for the given name then it must
+	// return false as its second result, in which case the value and
+	// diagnostics are ignored.
+	ResolveVariable(name string, rng Range) (cty.Value, bool, Diagnostics)
+
+	// ResolveFunction returns the function of the given name. The given
+	// range is the source range of the function name in the call, for use
+	// in diagnostics.
+	//
+	// If the resolver has no definition for the given name then it must
+	// return false as its second result, in which case the function and
+	// diagnostics are ignored.
+	ResolveFunction(name string, rng Range) (function.Function, bool, Diagnostics)
 }
 
 // NewChild returns a new EvalContext that is a child of the receiver.
@@ -26,3 +68,42 @@ func (ctx *EvalContext) NewChild() *EvalContext {
 func (ctx *EvalContext) Parent() *EvalContext {
 	return ctx.parent
 }
+
+// LookupVariable returns the value of the variable with the given root name,
+// searching the receiver and then each of its ancestors in turn. Within each
+// context the Variables map is consulted before the Resolver, so that any
+// definition in a child context shadows those in its ancestors. The given
+// range is passed to the resolvers for use in their diagnostics.
+//
+// The second result is false if none of the contexts define the variable,
+// in which case the other results are meaningless.
+func (ctx *EvalContext) LookupVariable(name string, rng Range) (cty.Value, bool, Diagnostics) {
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		if val, exists := thisCtx.Variables[name]; exists {
+			return val, true, nil
+		}
+		if thisCtx.Resolver != nil {
+			if val, exists, diags := thisCtx.Resolver.ResolveVariable(name, rng); exists {
+				return val, true, diags
+			}
+		}
+	}
+	return cty.DynamicVal, false, nil
+}
+
+// LookupFunction is like LookupVariable, but for functions, using the
+// Functions maps of the contexts and the ResolveFunction method of their
+// resolvers.
+func (ctx *EvalContext) LookupFunction(name string, rng Range) (function.Function, bool, Diagnostics) {
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		if f, exists := thisCtx.Functions[name]; exists {
+			return f, true, nil
+		}
+		if thisCtx.Resolver != nil {
+			if f, exists, diags := thisCtx.Resolver.ResolveFunction(name, rng); exists {
+				return f, true, diags
+			}
+		}
+	}
+	return function.Function{}, false, nil
+}
@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+func TestEvalContextLookupVariable(t *testing.T) {
+	parent := &EvalContext{
+		Variables: map[string]cty.Value{
+			"a": cty.StringVal("parent map"),
+			"b": cty.StringVal("parent map"),
+		},
+		Resolver: testLookupResolver{
+			"a": cty.StringVal("parent resolver"),
+			"c": cty.StringVal("parent resolver"),
+		},
+	}
+	child := parent.NewChild()
+	child.Resolver = testLookupResolver{
+		"b": cty.StringVal("child resolver"),
+	}
+
+	tests := map[string]struct {
+		name  string
+		want  cty.Value
+		found bool
+	}{
+		"map before resolver in the same context": {"a", cty.StringVal("parent map"), true},
+		"child resolver shadows parent map":       {"b", cty.StringVal("child resolver"), true},
+		"parent resolver":                         {"c", cty.StringVal("parent resolver"), true},
+		"missing":                                 {"d", cty.DynamicVal, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, found, diags := child.LookupVariable(test.name, Range{})
+			if len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+			if found != test.found {
+				t.Fatalf("wrong found %t; want %t", found, test.found)
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+
+			traversal := Traversal{TraverseRoot{Name: test.name}}
+			got, diags = traversal.TraverseAbs(child)
+			if diags.HasErrors() != !test.found {
+				t.Errorf("wrong diagnostics from TraverseAbs: %s", diags.Error())
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result from TraverseAbs\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
+
+// testLookupResolver resolves variables from a map, and defines no functions.
+type testLookupResolver map[string]cty.Value
+
+func (r testLookupResolver) ResolveVariable(name string, rng Range) (cty.Value, bool, Diagnostics) {
+	val, ok := r[name]
+	return val, ok, nil
+}
+
+func (r testLookupResolver) ResolveFunction(name string, rng Range) (function.Function, bool, Diagnostics) {
+	return function.Function{}, false, nil
+}
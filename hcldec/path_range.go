@@ -0,0 +1,231 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcldec
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func pathSourceRange(body hcl.Body, blockLabels []blockLabel, spec Spec, path cty.Path) hcl.Range {
+	schema := ImpliedSchema(spec)
+	content, _, _ := body.PartialContent(schema)
+
+	return specPathSourceRange(content, blockLabels, spec, path)
+}
+
+// specPathSourceRange is the main implementation of SourceRangeForPath,
+// which descends into the given spec for each step of the given path for
+// as long as it is able to find a corresponding item in the content, and
+// then falls back to the spec's usual sourceRange implementation.
+func specPathSourceRange(content *hcl.BodyContent, blockLabels []blockLabel, spec Spec, path cty.Path) hcl.Range {
+	if len(path) == 0 {
+		return spec.sourceRange(content, blockLabels)
+	}
+
+	switch s := spec.(type) {
+	case ObjectSpec:
+		if name, ok := pathStepKey(path[0]); ok {
+			if childSpec, exists := s[name]; exists {
+				return specPathSourceRange(content, blockLabels, childSpec, path[1:])
+			}
+		}
+
+	case TupleSpec:
+		if idx, ok := pathStepIndex(path[0]); ok && idx < len(s) {
+			return specPathSourceRange(content, blockLabels, s[idx], path[1:])
+		}
+
+	case *AttrSpec:
+		if attr, exists := content.Attributes[s.Name]; exists {
+			return exprPathSourceRange(attr.Expr, path)
+		}
+
+	case *ExprSpec:
+		return exprPathSourceRange(s.Expr, path)
+
+	case *BlockSpec:
+		// The value of a single block is the value of its body, so the path
+		// applies directly to the nested spec.
+		if block := pathFindBlock(content, s.TypeName, 0); block != nil {
+			return pathSourceRange(block.Body, labelsForBlock(block), s.Nested, path)
+		}
+
+	case *BlockListSpec:
+		if idx, ok := pathStepIndex(path[0]); ok {
+			if block := pathFindBlock(content, s.TypeName, idx); block != nil {
+				return pathSourceRange(block.Body, labelsForBlock(block), s.Nested, path[1:])
+			}
+		}
+
+	case *BlockTupleSpec:
+		if idx, ok := pathStepIndex(path[0]); ok {
+			if block := pathFindBlock(content, s.TypeName, idx); block != nil {
+				return pathSourceRange(block.Body, labelsForBlock(block), s.Nested, path[1:])
+			}
+		}
+
+	case *BlockMapSpec:
+		return labeledBlocksPathSourceRange(content, blockLabels, s, s.TypeName, len(s.LabelNames), s.Nested, path)
+
+	case *BlockObjectSpec:
+		return labeledBlocksPathSourceRange(content, blockLabels, s, s.TypeName, len(s.LabelNames), s.Nested, path)
+
+	case *BlockAttrsSpec:
+		block, _ := s.findBlock(content)
+		if block == nil {
+			break
+		}
+		name, ok := pathStepKey(path[0])
+		if !ok {
+			return block.DefRange
+		}
+		attrs, _ := block.Body.JustAttributes()
+		if attr, exists := attrs[name]; exists {
+			return exprPathSourceRange(attr.Expr, path[1:])
+		}
+		return block.DefRange
+
+	case *DefaultSpec:
+		// As with sourceRange, we assume the primary spec is the one that
+		// will produce the result.
+		return specPathSourceRange(content, blockLabels, s.Primary, path)
+
+	case *RefineValueSpec:
+		return specPathSourceRange(content, blockLabels, s.Wrapped, path)
+
+	case *ValidateSpec:
+		return specPathSourceRange(content, blockLabels, s.Wrapped, path)
+	}
+
+	// For any other situation we can't find anything more specific than
+	// the range of the spec itself. This includes the transform specs,
+	// whose results may have no relationship to the structure of the value
+	// they wrap.
+	return spec.sourceRange(content, blockLabels)
+}
+
+// labeledBlocksPathSourceRange deals with the specs that produce nested maps
+// or objects with one level for each of the block labels.
+func labeledBlocksPathSourceRange(content *hcl.BodyContent, blockLabels []blockLabel, spec Spec, typeName string, labelCount int, nested Spec, path cty.Path) hcl.Range {
+	var keys []string
+	for _, step := range path {
+		if len(keys) == labelCount {
+			break
+		}
+		key, ok := pathStepKey(step)
+		if !ok {
+			break
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return spec.sourceRange(content, blockLabels)
+	}
+
+Blocks:
+	for _, block := range content.Blocks {
+		if block.Type != typeName || len(block.Labels) < labelCount {
+			continue
+		}
+		for i, key := range keys {
+			if block.Labels[i] != key {
+				continue Blocks
+			}
+		}
+
+		if len(keys) < labelCount {
+			// The path refers to a level of the result that corresponds
+			// to a label, so we'll return the range of that label in the
+			// first block that has it.
+			return block.LabelRanges[len(keys)-1]
+		}
+		childLabels := labelsForBlock(block)
+		return pathSourceRange(block.Body, childLabels[labelCount:], nested, path[labelCount:])
+	}
+
+	return spec.sourceRange(content, blockLabels)
+}
+
+// exprPathSourceRange returns the range of the most specific part of the
+// given expression that contributes to the value at the given path within
+// its result, by descending into static object and tuple constructors.
+func exprPathSourceRange(expr hcl.Expression, path cty.Path) hcl.Range {
+	for _, step := range path {
+		if idx, ok := pathStepIndex(step); ok {
+			elems, diags := hcl.ExprList(expr)
+			if diags.HasErrors() || idx >= len(elems) {
+				break
+			}
+			expr = elems[idx]
+			continue
+		}
+
+		key, ok := pathStepKey(step)
+		if !ok {
+			break
+		}
+		pairs, diags := hcl.ExprMap(expr)
+		if diags.HasErrors() {
+			break
+		}
+		found := false
+		for _, pair := range pairs {
+			keyVal, diags := pair.Key.Value(nil)
+			if diags.HasErrors() || !keyVal.IsKnown() || keyVal.IsNull() || keyVal.Type() != cty.String {
+				continue
+			}
+			if keyVal.AsString() == key {
+				expr = pair.Value
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return expr.Range()
+}
+
+func pathFindBlock(content *hcl.BodyContent, typeName string, idx int) *hcl.Block {
+	for _, block := range content.Blocks {
+		if block.Type != typeName {
+			continue
+		}
+		if idx == 0 {
+			return block
+		}
+		idx--
+	}
+	return nil
+}
+
+// pathStepKey returns the attribute name or string key that the given step
+// refers to, if any.
+func pathStepKey(step cty.PathStep) (string, bool) {
+	switch step := step.(type) {
+	case cty.GetAttrStep:
+		return step.Name, true
+	case cty.IndexStep:
+		if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+			return step.Key.AsString(), true
+		}
+	}
+	return "", false
+}
+
+// pathStepIndex returns the element index that the given step refers to, if
+// any.
+func pathStepIndex(step cty.PathStep) (int, bool) {
+	indexStep, ok := step.(cty.IndexStep)
+	if !ok || indexStep.Key.Type() != cty.Number || !indexStep.Key.IsKnown() || indexStep.Key.IsNull() {
+		return 0, false
+	}
+	idx, accuracy := indexStep.Key.AsBigFloat().Int64()
+	if accuracy != 0 || idx < 0 {
+		return 0, false
+	}
+	return int(idx), true
+}
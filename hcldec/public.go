@@ -55,6 +55,25 @@ func SourceRange(body hcl.Body, spec Spec) hcl.Range {
 	return sourceRange(body, nil, spec)
 }
 
+// SourceRangeForPath is like SourceRange but returns the range of the part of
+// the given body that contributes to the value at the given path within the
+// result of decoding it with the given spec.
+//
+// This is useful for applications that validate a decoded value and find a
+// problem with a nested part of it, such as an element of a list or an
+// attribute of an object, and wish to return a diagnostic that points at that
+// particular part of the configuration.
+//
+// The path is followed for as long as there is a corresponding item in the
+// body, including through the elements of object and tuple constructor
+// expressions. If the path cannot be followed all the way, perhaps because
+// the value at that point was produced by an expression that is not a
+// constructor, the result is the range of the deepest item that was found.
+// As with SourceRange, the result is best-effort.
+func SourceRangeForPath(body hcl.Body, spec Spec, path cty.Path) hcl.Range {
+	return pathSourceRange(body, nil, spec, path)
+}
+
 // ChildBlockTypes returns a map of all of the child block types declared
 // by the given spec, with block type names as keys and the associated
 // nested body specs as values.
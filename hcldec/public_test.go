@@ -1151,3 +1151,200 @@ b {
 	}
 
 }
+
+func TestSourceRangeForPath(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		spec   Spec
+		path   cty.Path
+		want   hcl.Range
+	}{
+		"empty path": {
+			"a = 1\n",
+			&AttrSpec{
+				Name: "a",
+			},
+			nil,
+			hcl.Range{
+				Start: hcl.Pos{Line: 1, Column: 5, Byte: 4},
+				End:   hcl.Pos{Line: 1, Column: 6, Byte: 5},
+			},
+		},
+		"object constructor attribute": {
+			"a = { b = 1, c = [2, 3] }\n",
+			ObjectSpec{
+				"a": &AttrSpec{
+					Name: "a",
+					Type: cty.DynamicPseudoType,
+				},
+			},
+			cty.GetAttrPath("a").GetAttr("c").IndexInt(1),
+			hcl.Range{
+				Start: hcl.Pos{Line: 1, Column: 22, Byte: 21},
+				End:   hcl.Pos{Line: 1, Column: 23, Byte: 22},
+			},
+		},
+		"object constructor missing attribute": {
+			"a = { b = 1 }\n",
+			ObjectSpec{
+				"a": &AttrSpec{
+					Name: "a",
+					Type: cty.DynamicPseudoType,
+				},
+			},
+			cty.GetAttrPath("a").GetAttr("nope"),
+			hcl.Range{
+				Start: hcl.Pos{Line: 1, Column: 5, Byte: 4},
+				End:   hcl.Pos{Line: 1, Column: 14, Byte: 13},
+			},
+		},
+		"tuple spec element": {
+			"a = 1\nb = 2\n",
+			TupleSpec{
+				&AttrSpec{Name: "a", Type: cty.Number},
+				&AttrSpec{Name: "b", Type: cty.Number},
+			},
+			cty.IndexIntPath(1),
+			hcl.Range{
+				Start: hcl.Pos{Line: 2, Column: 5, Byte: 10},
+				End:   hcl.Pos{Line: 2, Column: 6, Byte: 11},
+			},
+		},
+		"block list element": {
+			`
+b {
+  a = 1
+}
+b {
+  a = 2
+}
+`,
+			&BlockListSpec{
+				TypeName: "b",
+				Nested: ObjectSpec{
+					"a": &AttrSpec{Name: "a", Type: cty.Number},
+				},
+			},
+			cty.IndexIntPath(1).GetAttr("a"),
+			hcl.Range{
+				Start: hcl.Pos{Line: 6, Column: 7, Byte: 25},
+				End:   hcl.Pos{Line: 6, Column: 8, Byte: 26},
+			},
+		},
+		"block list element out of range": {
+			`
+b {
+  a = 1
+}
+`,
+			&BlockListSpec{
+				TypeName: "b",
+				Nested: ObjectSpec{
+					"a": &AttrSpec{Name: "a", Type: cty.Number},
+				},
+			},
+			cty.IndexIntPath(3).GetAttr("a"),
+			hcl.Range{
+				// Falls back to the range of the first block's content.
+				Start: hcl.Pos{Line: 2, Column: 3, Byte: 3},
+				End:   hcl.Pos{Line: 2, Column: 3, Byte: 3},
+			},
+		},
+		"block map element": {
+			`
+b "foo" {
+  a = 1
+}
+b "bar" {
+  a = 2
+}
+`,
+			&BlockMapSpec{
+				TypeName:   "b",
+				LabelNames: []string{"name"},
+				Nested: ObjectSpec{
+					"a": &AttrSpec{Name: "a", Type: cty.Number},
+				},
+			},
+			cty.IndexStringPath("bar").GetAttr("a"),
+			hcl.Range{
+				Start: hcl.Pos{Line: 6, Column: 7, Byte: 37},
+				End:   hcl.Pos{Line: 6, Column: 8, Byte: 38},
+			},
+		},
+		"block object partial labels": {
+			`
+b "foo" "x" {
+  a = 1
+}
+b "bar" "y" {
+  a = 2
+}
+`,
+			&BlockObjectSpec{
+				TypeName:   "b",
+				LabelNames: []string{"kind", "name"},
+				Nested: ObjectSpec{
+					"a": &AttrSpec{Name: "a", Type: cty.Number},
+				},
+			},
+			cty.GetAttrPath("bar"),
+			hcl.Range{
+				Start: hcl.Pos{Line: 5, Column: 3, Byte: 27},
+				End:   hcl.Pos{Line: 5, Column: 8, Byte: 32},
+			},
+		},
+		"block label": {
+			`
+b "foo" {
+}
+`,
+			&BlockSpec{
+				TypeName: "b",
+				Nested: ObjectSpec{
+					"name": &BlockLabelSpec{Index: 0, Name: "name"},
+				},
+			},
+			cty.GetAttrPath("name"),
+			hcl.Range{
+				Start: hcl.Pos{Line: 2, Column: 3, Byte: 3},
+				End:   hcl.Pos{Line: 2, Column: 8, Byte: 8},
+			},
+		},
+		"block attrs element": {
+			`
+tags {
+  foo = "a"
+  bar = "b"
+}
+`,
+			&BlockAttrsSpec{
+				TypeName:    "tags",
+				ElementType: cty.String,
+			},
+			cty.IndexStringPath("bar"),
+			hcl.Range{
+				Start: hcl.Pos{Line: 4, Column: 9, Byte: 28},
+				End:   hcl.Pos{Line: 4, Column: 12, Byte: 31},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			file, diags := hclsyntax.ParseConfig([]byte(test.config), "", hcl.Pos{Line: 1, Column: 1, Byte: 0})
+			if len(diags) != 0 {
+				t.Errorf("wrong number of diagnostics %d; want %d", len(diags), 0)
+				for _, diag := range diags {
+					t.Logf(" - %s", diag.Error())
+				}
+			}
+
+			got := SourceRangeForPath(file.Body, test.spec, test.path)
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
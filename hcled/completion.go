@@ -0,0 +1,619 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Schema describes the content expected in a body, including the content
+// of any nested blocks, for the purpose of offering completions and
+// classifying tokens.
+type Schema struct {
+	Attributes []hcl.AttributeSchema
+	Blocks     []BlockSchema
+}
+
+// BlockSchema describes a block type that may appear in a body.
+type BlockSchema struct {
+	Type       string
+	LabelNames []string
+
+	// LabelValues optionally gives the candidate values for each of the
+	// labels, in the same order as LabelNames. Labels with no candidate
+	// values are not completed.
+	LabelValues [][]string
+
+	// Body is the schema for the body of each block of this type, or nil if
+	// its content is not known.
+	Body *Schema
+}
+
+// CompletionKind describes what sort of item a Completion represents.
+type CompletionKind int
+
+const (
+	CompletionAttribute CompletionKind = iota
+	CompletionBlockType
+	CompletionBlockLabel
+	CompletionVariable
+	CompletionTraversalAttr
+	CompletionFunction
+)
+
+// Completion is a candidate for the text at a particular position in a file.
+type Completion struct {
+	Kind CompletionKind
+
+	// Name is the name of the suggested item, such as the name of an
+	// attribute or function.
+	Name string
+
+	// Detail is an optional short description of the item, such as the type
+	// of a variable or the signature of a function.
+	Detail string
+
+	// Insert is the text that should replace the text within Range if the
+	// completion is chosen. It is usually the same as Name, but a block
+	// label given outside of quotes is quoted, for example.
+	Insert string
+
+	// Range is the range of the partial word at the cursor, which the
+	// completion is intended to replace. It is empty if there is no such
+	// word.
+	Range hcl.Range
+}
+
+// Completions returns the candidates for the word at the given position in
+// the given native syntax file, using the given schema to suggest attribute
+// names, block types and block labels and the variables and functions in
+// the given context to suggest the terms of expressions. Either schema or
+// ctx may be nil, in which case the corresponding candidates are not
+// offered.
+//
+// Completions are based on the tokens before the given position, and so are
+// offered even if the file is incomplete, such as when it has unclosed
+// blocks or when the cursor is immediately after the dot of a traversal.
+// The result is nil if the file is not in the native syntax or if there are
+// no candidates.
+func Completions(file *hcl.File, pos hcl.Pos, schema *Schema, ctx *hcl.EvalContext) []Completion {
+	if _, isNative := file.Body.(*hclsyntax.Body); !isNative {
+		return nil
+	}
+	tokens, _ := hclsyntax.LexConfig(file.Bytes, file.Body.MissingItemRange().Filename, hcl.InitialPos)
+
+	c := completer{
+		file:   file,
+		pos:    pos,
+		schema: schema,
+		ctx:    ctx,
+	}
+	c.findPrefix(tokens)
+	return c.complete()
+}
+
+// completer analyzes the tokens before a position to decide which sort of
+// completions are appropriate there.
+type completer struct {
+	file   *hcl.File
+	pos    hcl.Pos
+	schema *Schema
+	ctx    *hcl.EvalContext
+
+	// tokens are the tokens before the prefix, excluding comments.
+	tokens hclsyntax.Tokens
+
+	// prefix is the part of the word at the cursor that has already been
+	// typed, and prefixRange is the range of that word.
+	prefix      string
+	prefixRange hcl.Range
+	quoted      bool
+
+	// none is set if the cursor is somewhere that we never complete, such
+	// as inside a comment.
+	none bool
+}
+
+// completionFrame is one level of the nesting of brackets and blocks around
+// the cursor.
+type completionFrame struct {
+	// open is the token that opened the frame, which is TokenNil for the
+	// root body.
+	open hclsyntax.Token
+
+	// If the frame is a body, body is true and schema is the schema for the
+	// body, which is nil if unknown. line is the tokens so far of the body
+	// item containing the cursor.
+	body   bool
+	schema *Schema
+	line   hclsyntax.Tokens
+}
+
+func (c *completer) findPrefix(all hclsyntax.Tokens) {
+	c.prefixRange = hcl.Range{
+		Filename: c.file.Body.MissingItemRange().Filename,
+		Start:    c.pos,
+		End:      c.pos,
+	}
+
+	for _, tok := range all {
+		if tok.Type == hclsyntax.TokenEOF || tok.Range.Start.Byte >= c.pos.Byte {
+			break
+		}
+		if tok.Type == hclsyntax.TokenComment {
+			// A single-line comment includes its terminating newline, so we
+			// treat it as a newline unless the cursor is inside it.
+			if tok.Range.End.Byte > c.pos.Byte {
+				c.none = true
+				return
+			}
+			if bytesEndWithNewline(tok.Bytes) {
+				tok.Type = hclsyntax.TokenNewline
+			} else {
+				continue
+			}
+		}
+		c.tokens = append(c.tokens, tok)
+	}
+
+	if len(c.tokens) == 0 {
+		return
+	}
+	last := c.tokens[len(c.tokens)-1]
+	if last.Range.End.Byte < c.pos.Byte {
+		return
+	}
+	switch last.Type {
+	case hclsyntax.TokenIdent, hclsyntax.TokenQuotedLit:
+		typed := c.pos.Byte - last.Range.Start.Byte
+		c.prefix = string(last.Bytes[:typed])
+		c.prefixRange.Start = last.Range.Start
+		c.quoted = last.Type == hclsyntax.TokenQuotedLit
+		c.tokens = c.tokens[:len(c.tokens)-1]
+	case hclsyntax.TokenNumberLit, hclsyntax.TokenStringLit:
+		c.none = true
+	}
+}
+
+func (c *completer) complete() []Completion {
+	if c.none {
+		return nil
+	}
+	stack := []*completionFrame{
+		{body: true, schema: c.schema},
+	}
+	for _, tok := range c.tokens {
+		top := stack[len(stack)-1]
+		bodyFrame := top
+		for i := len(stack) - 1; !bodyFrame.body; i-- {
+			bodyFrame = stack[i-1]
+		}
+
+		if top.body {
+			switch tok.Type {
+			case hclsyntax.TokenNewline:
+				top.line = nil
+				continue
+			case hclsyntax.TokenOBrace:
+				if header, ok := blockHeader(top.line); ok {
+					stack = append(stack, &completionFrame{
+						open:   tok,
+						body:   true,
+						schema: top.schema.block(header).body(),
+					})
+					top.line = nil
+					continue
+				}
+			case hclsyntax.TokenCBrace:
+				if len(stack) > 1 {
+					stack = stack[:len(stack)-1]
+					stack[len(stack)-1].line = nil
+				}
+				continue
+			}
+		}
+		bodyFrame.line = append(bodyFrame.line, tok)
+
+		if closesFrame(tok.Type) {
+			// We'll close the innermost frame opened by the corresponding
+			// bracket, discarding any unclosed frames inside it.
+			for i := len(stack) - 1; i > 0 && !stack[i].body; i-- {
+				if closerFor(stack[i].open.Type) == tok.Type {
+					stack = stack[:i]
+					break
+				}
+			}
+			continue
+		}
+		if closerFor(tok.Type) != hclsyntax.TokenNil {
+			stack = append(stack, &completionFrame{open: tok})
+		}
+	}
+
+	top := stack[len(stack)-1]
+	switch {
+	case top.body && len(top.line) == 0 && !c.quoted:
+		return c.bodyItemCompletions(top, len(stack) > 1)
+
+	case top.body && !c.quoted:
+		if header, ok := blockHeader(top.line); ok {
+			return c.labelCompletions(top.schema.block(header), len(header)-1)
+		}
+		if len(top.line) >= 2 && top.line[1].Type == hclsyntax.TokenEqual {
+			return c.exprCompletions()
+		}
+
+	case top.open.Type == hclsyntax.TokenOQuote && len(stack) > 1:
+		// A quoted label is the only string whose content we complete.
+		parent := stack[len(stack)-2]
+		if !parent.body || len(parent.line) == 0 {
+			return nil
+		}
+		if header, ok := blockHeader(parent.line[:len(parent.line)-1]); ok {
+			c.quoted = true
+			return c.labelCompletions(parent.schema.block(header), len(header)-1)
+		}
+
+	case !c.quoted && top.open.Type != hclsyntax.TokenOQuote && top.open.Type != hclsyntax.TokenOHeredoc:
+		return c.exprCompletions()
+	}
+	return nil
+}
+
+func (c *completer) bodyItemCompletions(frame *completionFrame, nested bool) []Completion {
+	if frame.schema == nil {
+		return nil
+	}
+
+	// We don't offer the names of attributes that are already defined, other
+	// than the one at the cursor.
+	body := c.file.Body
+	if nested {
+		body = nil
+		if block := c.file.InnermostBlockAtPos(c.pos); block != nil {
+			body = block.Body
+		}
+	}
+	defined := map[string]bool{}
+	if body != nil {
+		schema := &hcl.BodySchema{Attributes: frame.schema.Attributes}
+		content, _, _ := body.PartialContent(schema)
+		for name := range content.Attributes {
+			defined[name] = true
+		}
+		if attr := c.file.AttributeAtPos(c.pos); attr != nil {
+			delete(defined, attr.Name)
+		}
+	}
+
+	var ret []Completion
+	for _, attrS := range sortedAttributes(frame.schema.Attributes) {
+		if defined[attrS.Name] || !strings.HasPrefix(attrS.Name, c.prefix) {
+			continue
+		}
+		detail := "optional"
+		if attrS.Required {
+			detail = "required"
+		}
+		ret = append(ret, c.completion(CompletionAttribute, attrS.Name, detail, attrS.Name))
+	}
+	for _, blockS := range sortedBlocks(frame.schema.Blocks) {
+		if !strings.HasPrefix(blockS.Type, c.prefix) {
+			continue
+		}
+		ret = append(ret, c.completion(CompletionBlockType, blockS.Type, "block", blockS.Type))
+	}
+	return ret
+}
+
+func (c *completer) labelCompletions(blockS *BlockSchema, idx int) []Completion {
+	if blockS == nil || idx >= len(blockS.LabelNames) || idx >= len(blockS.LabelValues) {
+		return nil
+	}
+	values := append([]string(nil), blockS.LabelValues[idx]...)
+	sort.Strings(values)
+
+	var ret []Completion
+	for _, value := range values {
+		if !strings.HasPrefix(value, c.prefix) {
+			continue
+		}
+		insert := value
+		if !c.quoted {
+			insert = strconv.Quote(value)
+		}
+		ret = append(ret, c.completion(CompletionBlockLabel, value, blockS.LabelNames[idx], insert))
+	}
+	return ret
+}
+
+func (c *completer) exprCompletions() []Completion {
+	if c.ctx == nil {
+		return nil
+	}
+	if len(c.tokens) > 0 && c.tokens[len(c.tokens)-1].Type == hclsyntax.TokenDot {
+		return c.traversalCompletions()
+	}
+
+	var ret []Completion
+	// Resolvers can't enumerate their names, so we offer only the names in
+	// the maps, but look each one up through the whole context so that we
+	// describe the definition that a reference would actually use.
+	vars := map[string]cty.Value{}
+	funcs := map[string]string{}
+	for ctx := c.ctx; ctx != nil; ctx = ctx.Parent() {
+		for name := range ctx.Variables {
+			if _, seen := vars[name]; seen {
+				continue
+			}
+			if val, found, diags := c.ctx.LookupVariable(name, hcl.Range{}); found && !diags.HasErrors() {
+				vars[name] = val
+			}
+		}
+		for name := range ctx.Functions {
+			if _, seen := funcs[name]; seen {
+				continue
+			}
+			if fn, found, diags := c.ctx.LookupFunction(name, hcl.Range{}); found && !diags.HasErrors() {
+				funcs[name] = functionSignature(name, fn.Params(), fn.VarParam())
+			}
+		}
+	}
+	for _, name := range sortedKeys(vars) {
+		if strings.HasPrefix(name, c.prefix) {
+			ret = append(ret, c.completion(CompletionVariable, name, vars[name].Type().FriendlyName(), name))
+		}
+	}
+	for _, name := range sortedKeys(funcs) {
+		if strings.HasPrefix(name, c.prefix) {
+			ret = append(ret, c.completion(CompletionFunction, name, funcs[name], name))
+		}
+	}
+	return ret
+}
+
+// traversalCompletions offers the attributes of the value of the traversal
+// that ends with the dot before the cursor.
+func (c *completer) traversalCompletions() []Completion {
+	traversal := traversalBefore(c.tokens[:len(c.tokens)-1])
+	if traversal == nil {
+		return nil
+	}
+	val, found, diags := c.ctx.LookupVariable(traversal.RootName(), traversal.SourceRange())
+	if !found || diags.HasErrors() {
+		return nil
+	}
+
+	// We walk the values where they are known, since they can tell us the
+	// keys of maps, but otherwise use just the types.
+	ty := val.Type()
+	for _, step := range traversal[1:] {
+		if val.IsKnown() && !val.IsNull() {
+			next, diags := step.TraversalStep(val)
+			if diags.HasErrors() {
+				return nil
+			}
+			val, ty = next, next.Type()
+			continue
+		}
+		val = cty.UnknownVal(cty.DynamicPseudoType)
+		switch {
+		case ty.IsObjectType():
+			attr, ok := step.(hcl.TraverseAttr)
+			if !ok || !ty.HasAttribute(attr.Name) {
+				return nil
+			}
+			ty = ty.AttributeType(attr.Name)
+		case ty.IsMapType() || ty.IsListType():
+			ty = ty.ElementType()
+		default:
+			return nil
+		}
+	}
+
+	attrs := map[string]cty.Type{}
+	switch {
+	case ty.IsObjectType():
+		for name, attrTy := range ty.AttributeTypes() {
+			attrs[name] = attrTy
+		}
+	case ty.IsMapType() && val.IsKnown() && !val.IsNull():
+		for it := val.ElementIterator(); it.Next(); {
+			key, _ := it.Element()
+			if hclsyntax.ValidIdentifier(key.AsString()) {
+				attrs[key.AsString()] = ty.ElementType()
+			}
+		}
+	}
+
+	var ret []Completion
+	for _, name := range sortedKeys(attrs) {
+		if strings.HasPrefix(name, c.prefix) {
+			ret = append(ret, c.completion(CompletionTraversalAttr, name, attrs[name].FriendlyName(), name))
+		}
+	}
+	return ret
+}
+
+func (c *completer) completion(kind CompletionKind, name, detail, insert string) Completion {
+	return Completion{
+		Kind:   kind,
+		Name:   name,
+		Detail: detail,
+		Insert: insert,
+		Range:  c.prefixRange,
+	}
+}
+
+// traversalBefore returns the absolute traversal formed by the attribute and
+// index steps at the end of the given tokens, or nil if there is no such
+// traversal.
+func traversalBefore(tokens hclsyntax.Tokens) hcl.Traversal {
+	var steps hcl.Traversal
+	i := len(tokens) - 1
+	for i >= 0 {
+		switch {
+		case tokens[i].Type == hclsyntax.TokenIdent:
+			name := string(tokens[i].Bytes)
+			if i == 0 || tokens[i-1].Type != hclsyntax.TokenDot {
+				if i > 0 && tokens[i-1].Type == hclsyntax.TokenDoubleColon {
+					return nil
+				}
+				return append(hcl.Traversal{hcl.TraverseRoot{Name: name}}, steps...)
+			}
+			steps = append(hcl.Traversal{hcl.TraverseAttr{Name: name}}, steps...)
+			i -= 2
+
+		case tokens[i].Type == hclsyntax.TokenCBrack:
+			key, n := indexKeyBefore(tokens[:i])
+			if n == 0 {
+				return nil
+			}
+			steps = append(hcl.Traversal{hcl.TraverseIndex{Key: key}}, steps...)
+			i -= n + 1
+
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// indexKeyBefore interprets the literal index key and open bracket at the
+// end of the given tokens, returning the key and the number of tokens it
+// occupies, which is zero if there is no such key.
+func indexKeyBefore(tokens hclsyntax.Tokens) (cty.Value, int) {
+	n := len(tokens)
+	switch {
+	case n >= 2 && tokens[n-1].Type == hclsyntax.TokenNumberLit && tokens[n-2].Type == hclsyntax.TokenOBrack:
+		key, err := cty.ParseNumberVal(string(tokens[n-1].Bytes))
+		if err != nil {
+			return cty.NilVal, 0
+		}
+		return key, 2
+	case n >= 4 && tokens[n-1].Type == hclsyntax.TokenCQuote && tokens[n-2].Type == hclsyntax.TokenQuotedLit && tokens[n-3].Type == hclsyntax.TokenOQuote && tokens[n-4].Type == hclsyntax.TokenOBrack:
+		return cty.StringVal(string(tokens[n-2].Bytes)), 4
+	}
+	return cty.NilVal, 0
+}
+
+// blockHeader returns the type and labels of the block header formed by the
+// given tokens, if they are a valid block header.
+func blockHeader(tokens hclsyntax.Tokens) ([]string, bool) {
+	if len(tokens) == 0 || tokens[0].Type != hclsyntax.TokenIdent {
+		return nil, false
+	}
+	header := []string{string(tokens[0].Bytes)}
+	for i := 1; i < len(tokens); i++ {
+		switch tokens[i].Type {
+		case hclsyntax.TokenIdent:
+			header = append(header, string(tokens[i].Bytes))
+		case hclsyntax.TokenOQuote:
+			if i+2 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenQuotedLit && tokens[i+2].Type == hclsyntax.TokenCQuote {
+				header = append(header, string(tokens[i+1].Bytes))
+				i += 2
+			} else if i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenCQuote {
+				header = append(header, "")
+				i++
+			} else {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+	return header, true
+}
+
+func (s *Schema) block(header []string) *BlockSchema {
+	if s == nil || len(header) == 0 {
+		return nil
+	}
+	for i := range s.Blocks {
+		if s.Blocks[i].Type == header[0] {
+			return &s.Blocks[i]
+		}
+	}
+	return nil
+}
+
+func (s *BlockSchema) body() *Schema {
+	if s == nil {
+		return nil
+	}
+	return s.Body
+}
+
+func closerFor(ty hclsyntax.TokenType) hclsyntax.TokenType {
+	switch ty {
+	case hclsyntax.TokenOBrace:
+		return hclsyntax.TokenCBrace
+	case hclsyntax.TokenOBrack:
+		return hclsyntax.TokenCBrack
+	case hclsyntax.TokenOParen:
+		return hclsyntax.TokenCParen
+	case hclsyntax.TokenOQuote:
+		return hclsyntax.TokenCQuote
+	case hclsyntax.TokenOHeredoc:
+		return hclsyntax.TokenCHeredoc
+	case hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+		return hclsyntax.TokenTemplateSeqEnd
+	default:
+		return hclsyntax.TokenNil
+	}
+}
+
+func closesFrame(ty hclsyntax.TokenType) bool {
+	switch ty {
+	case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenCQuote, hclsyntax.TokenCHeredoc, hclsyntax.TokenTemplateSeqEnd:
+		return true
+	default:
+		return false
+	}
+}
+
+// functionSignature returns a description of the parameters of a function,
+// like "join(separator, lists...)".
+func functionSignature(name string, params []function.Parameter, varParam *function.Parameter) string {
+	names := make([]string, 0, len(params)+1)
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	if varParam != nil {
+		names = append(names, varParam.Name+"...")
+	}
+	return name + "(" + strings.Join(names, ", ") + ")"
+}
+
+func bytesEndWithNewline(b []byte) bool {
+	return len(b) > 0 && b[len(b)-1] == '\n'
+}
+
+func sortedAttributes(attrs []hcl.AttributeSchema) []hcl.AttributeSchema {
+	ret := append([]hcl.AttributeSchema(nil), attrs...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func sortedBlocks(blocks []BlockSchema) []BlockSchema {
+	ret := append([]BlockSchema(nil), blocks...)
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Type < ret[j].Type })
+	return ret
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
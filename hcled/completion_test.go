@@ -0,0 +1,306 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestCompletions(t *testing.T) {
+	schema := &Schema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "name", Required: true},
+			{Name: "count"},
+		},
+		Blocks: []BlockSchema{
+			{
+				Type:        "resource",
+				LabelNames:  []string{"type", "name"},
+				LabelValues: [][]string{{"aws_instance", "aws_vpc", "google_network"}},
+				Body: &Schema{
+					Attributes: []hcl.AttributeSchema{
+						{Name: "ami"},
+						{Name: "tags"},
+					},
+					Blocks: []BlockSchema{
+						{Type: "lifecycle"},
+					},
+				},
+			},
+			{Type: "locals"},
+		},
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"region": cty.StringVal("us-east-1"),
+				"zones":  cty.ListVal([]cty.Value{cty.StringVal("a")}),
+				"nested": cty.UnknownVal(cty.Object(map[string]cty.Type{
+					"inner": cty.Object(map[string]cty.Type{"deep": cty.Bool}),
+				})),
+			}),
+			"tags": cty.MapVal(map[string]cty.Value{
+				"env":       cty.StringVal("prod"),
+				"not an id": cty.StringVal("x"),
+			}),
+		},
+		Functions: map[string]function.Function{
+			"upper": stdlib.UpperFunc,
+			"join":  stdlib.JoinFunc,
+		},
+	}
+	ctx = ctx.NewChild()
+	ctx.Variables = map[string]cty.Value{
+		"each": cty.ObjectVal(map[string]cty.Value{"key": cty.StringVal("k")}),
+	}
+
+	type want struct {
+		Kind   CompletionKind
+		Name   string
+		Insert string
+	}
+	tests := map[string]struct {
+		Src  string
+		Want []want
+	}{
+		"empty file": {
+			"|",
+			[]want{
+				{CompletionAttribute, "count", "count"},
+				{CompletionAttribute, "name", "name"},
+				{CompletionBlockType, "locals", "locals"},
+				{CompletionBlockType, "resource", "resource"},
+			},
+		},
+		"attribute prefix": {
+			"n|",
+			[]want{
+				{CompletionAttribute, "name", "name"},
+			},
+		},
+		"defined attributes excluded": {
+			"name = \"a\"\n|\n",
+			[]want{
+				{CompletionAttribute, "count", "count"},
+				{CompletionBlockType, "locals", "locals"},
+				{CompletionBlockType, "resource", "resource"},
+			},
+		},
+		"attribute being named": {
+			"na|me = \"a\"\n",
+			[]want{
+				{CompletionAttribute, "name", "name"},
+			},
+		},
+		"after comment": {
+			"# comment\nl|",
+			[]want{
+				{CompletionBlockType, "locals", "locals"},
+			},
+		},
+		"inside comment": {
+			"# comm|ent\n",
+			nil,
+		},
+		"nested body": {
+			"resource \"a\" \"b\" {\n  |\n}\n",
+			[]want{
+				{CompletionAttribute, "ami", "ami"},
+				{CompletionAttribute, "tags", "tags"},
+				{CompletionBlockType, "lifecycle", "lifecycle"},
+			},
+		},
+		"unclosed block": {
+			"resource \"a\" \"b\" {\n  ami = 1\n  t|",
+			[]want{
+				{CompletionAttribute, "tags", "tags"},
+			},
+		},
+		"after closed block": {
+			"resource \"a\" \"b\" {\n}\nc|",
+			[]want{
+				{CompletionAttribute, "count", "count"},
+			},
+		},
+		"unknown block body": {
+			"locals {\n  |\n}\n",
+			nil,
+		},
+		"unquoted label": {
+			"resource aws|",
+			[]want{
+				{CompletionBlockLabel, "aws_instance", `"aws_instance"`},
+				{CompletionBlockLabel, "aws_vpc", `"aws_vpc"`},
+			},
+		},
+		"quoted label": {
+			"resource \"aws_v|",
+			[]want{
+				{CompletionBlockLabel, "aws_vpc", "aws_vpc"},
+			},
+		},
+		"empty quoted label": {
+			"resource \"|\"",
+			[]want{
+				{CompletionBlockLabel, "aws_instance", "aws_instance"},
+				{CompletionBlockLabel, "aws_vpc", "aws_vpc"},
+				{CompletionBlockLabel, "google_network", "google_network"},
+			},
+		},
+		"second label": {
+			"resource \"aws_vpc\" \"|\"",
+			nil,
+		},
+		"expression": {
+			"count = |",
+			[]want{
+				{CompletionVariable, "each", "each"},
+				{CompletionVariable, "tags", "tags"},
+				{CompletionVariable, "var", "var"},
+				{CompletionFunction, "join", "join"},
+				{CompletionFunction, "upper", "upper"},
+			},
+		},
+		"expression prefix": {
+			"count = [1, u|]",
+			[]want{
+				{CompletionFunction, "upper", "upper"},
+			},
+		},
+		"function argument": {
+			"resource \"a\" \"b\" {\n  ami = upper(v|\n",
+			[]want{
+				{CompletionVariable, "var", "var"},
+			},
+		},
+		"template interpolation": {
+			"name = \"${e|}\"",
+			[]want{
+				{CompletionVariable, "each", "each"},
+			},
+		},
+		"template literal": {
+			"name = \"e|\"",
+			nil,
+		},
+		"traversal": {
+			"name = var.|\n",
+			[]want{
+				{CompletionTraversalAttr, "nested", "nested"},
+				{CompletionTraversalAttr, "region", "region"},
+				{CompletionTraversalAttr, "zones", "zones"},
+			},
+		},
+		"traversal prefix": {
+			"name = var.re|",
+			[]want{
+				{CompletionTraversalAttr, "region", "region"},
+			},
+		},
+		"traversal through unknown": {
+			"name = var.nested.inner.|",
+			[]want{
+				{CompletionTraversalAttr, "deep", "deep"},
+			},
+		},
+		"traversal of map": {
+			"name = tags.|",
+			[]want{
+				{CompletionTraversalAttr, "env", "env"},
+			},
+		},
+		"traversal of parent scope variable": {
+			"name = each.|",
+			[]want{
+				{CompletionTraversalAttr, "key", "key"},
+			},
+		},
+		"traversal with index": {
+			"name = var[\"nested\"].|",
+			[]want{
+				{CompletionTraversalAttr, "inner", "inner"},
+			},
+		},
+		"traversal of primitive": {
+			"name = var.region.|",
+			nil,
+		},
+		"traversal of unknown variable": {
+			"name = nope.|",
+			nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			offset := strings.Index(test.Src, "|")
+			src := test.Src[:offset] + test.Src[offset+1:]
+			file, _ := hclsyntax.ParseConfig([]byte(src), "test.hcl", hcl.InitialPos)
+			pos := posForOffset(src, offset)
+
+			var got []want
+			for _, c := range Completions(file, pos, schema, ctx) {
+				got = append(got, want{c.Kind, c.Name, c.Insert})
+				if c.Range.End != pos {
+					t.Errorf("completion %q ends at %#v; want %#v", c.Name, c.Range.End, pos)
+				}
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong completions\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompletionsRange(t *testing.T) {
+	src := "name = var.reg\n"
+	file, _ := hclsyntax.ParseConfig([]byte(src), "test.hcl", hcl.InitialPos)
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"region": cty.StringVal("us-east-1"),
+			}),
+		},
+	}
+
+	got := Completions(file, posForOffset(src, 14), nil, ctx)
+	want := []Completion{
+		{
+			Kind:   CompletionTraversalAttr,
+			Name:   "region",
+			Detail: "string",
+			Insert: "region",
+			Range: hcl.Range{
+				Filename: "test.hcl",
+				Start:    hcl.Pos{Line: 1, Column: 12, Byte: 11},
+				End:      hcl.Pos{Line: 1, Column: 15, Byte: 14},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong completions\n%s", diff)
+	}
+}
+
+func posForOffset(src string, offset int) hcl.Pos {
+	pos := hcl.InitialPos
+	for _, r := range src[:offset] {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	pos.Byte = offset
+	return pos
+}
@@ -0,0 +1,262 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apparentlymart/go-textseg/v15/textseg"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Resolver is implemented by applications to describe what the references
+// in their configuration refer to, since that depends entirely on how the
+// application interprets its configuration.
+type Resolver interface {
+	// ResolveReference returns the target of the given traversal, or false
+	// if the traversal doesn't refer to anything the application knows
+	// about.
+	ResolveReference(traversal hcl.Traversal) (Target, bool)
+}
+
+// Target describes the object that a traversal refers to.
+type Target struct {
+	// DefRange is the range of the name of the object in its definition,
+	// such as the name of an attribute or a label of a block. This is the
+	// range that is replaced when the object is renamed, and so if it is
+	// a quoted string then the quotes must be included.
+	DefRange hcl.Range
+
+	// Steps is the number of leading steps of the traversal that refer to
+	// the object itself. Any further steps access attributes or elements of
+	// the object. For example, if "local.x" refers to a local value then
+	// the traversal "local.x.y" has two steps referring to that value.
+	//
+	// The last of these steps names the object, and so it is the one that
+	// is changed when the object is renamed.
+	Steps int
+}
+
+// Edit is a change to the source of a file, as returned by Rename.
+type Edit struct {
+	// Range is the range of the source to replace, whose Filename field
+	// identifies the file to change.
+	Range hcl.Range
+
+	NewText string
+}
+
+// Definition returns the range of the definition of the object referred to
+// by the reference at the given position in the given file, or false if
+// there is no reference there or the resolver doesn't recognize it.
+func Definition(file *hcl.File, pos hcl.Pos, resolver Resolver) (hcl.Range, bool) {
+	for _, traversal := range fileTraversals(file) {
+		if !traversal.SourceRange().ContainsPos(pos) {
+			continue
+		}
+		target, ok := resolver.ResolveReference(traversal)
+		if !ok {
+			return hcl.Range{}, false
+		}
+		return target.DefRange, true
+	}
+	return hcl.Range{}, false
+}
+
+// References returns the ranges of all of the references in the given files
+// to the object whose definition has the given range, in order of file and
+// then position. Each range covers only the steps of a traversal that refer
+// to the object, and not any further attribute or element access.
+//
+// References whose source can't be located exactly, such as those in a JSON
+// string after an escape sequence, are not included.
+func References(files []*hcl.File, target hcl.Range, resolver Resolver) []hcl.Range {
+	var ret []hcl.Range
+	for _, file := range files {
+		refs, _ := fileReferences(file, target, resolver)
+		for _, ref := range refs {
+			ret = append(ret, hcl.RangeBetween(ref[0].SourceRange(), ref[len(ref)-1].SourceRange()))
+		}
+	}
+	return ret
+}
+
+// Rename returns the edits required to rename the object whose definition
+// has the given range to the given new name, changing both its definition
+// and all of the references to it in the given files. The edits are in
+// order of file and then position.
+//
+// The file containing the definition must be among the given files, so that
+// its source is available. Rename returns an error if the source of any
+// reference can't be located exactly, such as one in a JSON string after an
+// escape sequence, rather than returning an incomplete or incorrect edit.
+func Rename(files []*hcl.File, target hcl.Range, newName string, resolver Resolver) ([]Edit, error) {
+	if !hclsyntax.ValidIdentifier(newName) {
+		return nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+
+	var ret []Edit
+	var defFound bool
+	for _, file := range files {
+		var edits []Edit
+		filename := file.Body.MissingItemRange().Filename
+		if filename == target.Filename {
+			src := target.SliceBytes(file.Bytes)
+			if len(src) == 0 {
+				return nil, fmt.Errorf("definition range %s is not within its file", target)
+			}
+			newText := newName
+			if len(src) >= 2 && src[0] == '"' && src[len(src)-1] == '"' {
+				newText = `"` + newName + `"`
+			}
+			edits = append(edits, Edit{
+				Range:   target,
+				NewText: newText,
+			})
+			defFound = true
+		}
+
+		refs, unmatched := fileReferences(file, target, resolver)
+		if len(unmatched) != 0 {
+			return nil, fmt.Errorf("the source of the reference at %s could not be located", unmatched[0])
+		}
+		for _, ref := range refs {
+			edits = append(edits, renameStep(ref[len(ref)-1], newName))
+		}
+		sort.SliceStable(edits, func(i, j int) bool {
+			return edits[i].Range.Start.Byte < edits[j].Range.Start.Byte
+		})
+		ret = append(ret, edits...)
+	}
+	if !defFound {
+		return nil, fmt.Errorf("the file %s containing the definition was not given", target.Filename)
+	}
+
+	return ret, nil
+}
+
+// renameStep returns an edit that changes the name in the given traversal
+// step to the given name.
+func renameStep(step hcl.Traverser, newName string) Edit {
+	switch step := step.(type) {
+	case hcl.TraverseRoot:
+		return Edit{
+			Range:   step.SrcRange,
+			NewText: newName,
+		}
+	case hcl.TraverseAttr:
+		// The range of an attribute step usually includes its leading dot,
+		// so we'll change only the name at the end of it.
+		rng := step.SrcRange
+		nameLen := len(step.Name)
+		if nameLen < rng.End.Byte-rng.Start.Byte {
+			columns, _ := textseg.TokenCount([]byte(step.Name), textseg.ScanGraphemeClusters)
+			rng.Start = hcl.Pos{
+				Line:   rng.End.Line,
+				Column: rng.End.Column - columns,
+				Byte:   rng.End.Byte - nameLen,
+			}
+		}
+		return Edit{
+			Range:   rng,
+			NewText: newName,
+		}
+	default:
+		// Any other step is an index, and since the new name is a valid
+		// identifier it can be written as a quoted string with no escapes.
+		return Edit{
+			Range:   step.SourceRange(),
+			NewText: `["` + newName + `"]`,
+		}
+	}
+}
+
+// fileReferences returns the parts of the traversals in the given file
+// that refer to the object with the given definition range.
+//
+// The ranges of traversals within a JSON string are calculated from its
+// decoded value, so an earlier escape sequence shifts them away from the
+// source they came from. The ranges of any references whose steps don't
+// match their source are returned separately, in unmatched.
+func fileReferences(file *hcl.File, target hcl.Range, resolver Resolver) (refs []hcl.Traversal, unmatched []hcl.Range) {
+	for _, traversal := range fileTraversals(file) {
+		resolved, ok := resolver.ResolveReference(traversal)
+		if !ok || resolved.DefRange != target {
+			continue
+		}
+		if resolved.Steps < 1 || resolved.Steps > len(traversal) {
+			continue
+		}
+		ref := traversal[:resolved.Steps]
+		if !traversalMatchesSource(ref, file.Bytes) {
+			unmatched = append(unmatched, ref.SourceRange())
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, unmatched
+}
+
+// traversalMatchesSource returns true if the source ranges of each of the
+// steps of the given traversal cover the source of that step in the given
+// file source.
+func traversalMatchesSource(traversal hcl.Traversal, src []byte) bool {
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			if string(step.SrcRange.SliceBytes(src)) != step.Name {
+				return false
+			}
+		case hcl.TraverseAttr:
+			if string(renameStep(step, "").Range.SliceBytes(src)) != step.Name {
+				return false
+			}
+		case hcl.TraverseIndex:
+			// We parse the source of the index back into a traversal, after
+			// a placeholder root name, to compare its key.
+			stepSrc := append([]byte("_"), step.SrcRange.SliceBytes(src)...)
+			parsed, diags := hclsyntax.ParseTraversalAbs(stepSrc, "", hcl.InitialPos)
+			if diags.HasErrors() || len(parsed) != 2 {
+				return false
+			}
+			index, ok := parsed[1].(hcl.TraverseIndex)
+			if !ok || !index.Key.RawEquals(step.Key) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// fileTraversals returns all of the traversals in the expressions of the
+// given file, in source order.
+//
+// For syntaxes other than the native syntax we can't tell blocks from
+// attributes without a schema, so we treat everything as an attribute, which
+// is enough to find the traversals in the values.
+func fileTraversals(file *hcl.File) []hcl.Traversal {
+	var ret []hcl.Traversal
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+			if attr, ok := node.(*hclsyntax.Attribute); ok {
+				ret = append(ret, attr.Expr.Variables()...)
+			}
+			return nil
+		})
+	} else {
+		attrs, _ := file.Body.JustAttributes()
+		for _, attr := range attrs {
+			ret = append(ret, attr.Expr.Variables()...)
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].SourceRange().Start.Byte < ret[j].SourceRange().Start.Byte
+	})
+	return ret
+}
@@ -0,0 +1,243 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+const referencesMainSrc = `locals {
+  x = 1
+  y = local.x + 1
+}
+
+module "web" {
+  count = local.x
+  name  = "${local.y}-${module.web.id}"
+}
+
+output = local.x.foo
+other  = var.x
+`
+
+const referencesOtherSrc = `{
+  "value": "${local.x}",
+  "nested": {"a": ["${module.web.id}"]}
+}
+`
+
+// testResolver resolves "local.NAME" to the attributes of a "locals" block
+// and "module.NAME" to the label of a "module" block, in the given body.
+type testResolver struct {
+	body *hclsyntax.Body
+}
+
+func (r testResolver) ResolveReference(traversal hcl.Traversal) (Target, bool) {
+	if len(traversal) < 2 {
+		return Target{}, false
+	}
+	name, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return Target{}, false
+	}
+	for _, block := range r.body.Blocks {
+		switch {
+		case traversal.RootName() == "local" && block.Type == "locals":
+			if attr, ok := block.Body.Attributes[name.Name]; ok {
+				return Target{DefRange: attr.NameRange, Steps: 2}, true
+			}
+		case traversal.RootName() == "module" && block.Type == "module":
+			if len(block.Labels) == 1 && block.Labels[0] == name.Name {
+				return Target{DefRange: block.LabelRanges[0], Steps: 2}, true
+			}
+		}
+	}
+	return Target{}, false
+}
+
+func parseReferencesFiles(t *testing.T) ([]*hcl.File, testResolver) {
+	t.Helper()
+	main := parseStructureFile(t, "main.hcl", referencesMainSrc)
+	other := parseStructureFile(t, "other.json", referencesOtherSrc)
+	return []*hcl.File{main, other}, testResolver{main.Body.(*hclsyntax.Body)}
+}
+
+func TestDefinition(t *testing.T) {
+	files, resolver := parseReferencesFiles(t)
+	main := files[0]
+
+	tests := map[string]struct {
+		At      string // text to find in the source, where the cursor is at its start
+		Want    string // the text of the definition range, or empty if none
+		WantPos int    // the byte offset of the definition range
+	}{
+		"local":              {"local.y", "y", strings.Index(referencesMainSrc, "y =")},
+		"local in template":  {"local.y}", "y", strings.Index(referencesMainSrc, "y =")},
+		"module":             {"module.web.id", `"web"`, strings.Index(referencesMainSrc, `"web"`)},
+		"attribute of local": {"local.x.foo", "x", strings.Index(referencesMainSrc, "x =")},
+		"unknown":            {"var.x", "", 0},
+		"not a reference":    {"count", "", 0},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			offset := strings.Index(referencesMainSrc, test.At)
+			got, ok := Definition(main, posForOffset(referencesMainSrc, offset), resolver)
+			if test.Want == "" {
+				if ok {
+					t.Fatalf("unexpected definition %s", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("no definition found")
+			}
+			if text := rangeText(referencesMainSrc, got); text != test.Want || got.Start.Byte != test.WantPos {
+				t.Errorf("wrong definition %q at %d; want %q at %d", text, got.Start.Byte, test.Want, test.WantPos)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	files, resolver := parseReferencesFiles(t)
+	body := files[0].Body.(*hclsyntax.Body)
+
+	type ref struct {
+		Filename string
+		Text     string
+	}
+	tests := map[string]struct {
+		Target hcl.Range
+		Want   []ref
+	}{
+		"local": {
+			body.Blocks[0].Body.Attributes["x"].NameRange,
+			[]ref{
+				{"main.hcl", "local.x"},
+				{"main.hcl", "local.x"},
+				{"main.hcl", "local.x"},
+				{"other.json", "local.x"},
+			},
+		},
+		"module": {
+			body.Blocks[1].LabelRanges[0],
+			[]ref{
+				{"main.hcl", "module.web"},
+				{"other.json", "module.web"},
+			},
+		},
+		"unreferenced": {
+			body.Attributes["other"].NameRange,
+			nil,
+		},
+	}
+
+	srcs := map[string]string{
+		"main.hcl":   referencesMainSrc,
+		"other.json": referencesOtherSrc,
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []ref
+			for _, rng := range References(files, test.Target, resolver) {
+				got = append(got, ref{rng.Filename, rangeText(srcs[rng.Filename], rng)})
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	files, resolver := parseReferencesFiles(t)
+	body := files[0].Body.(*hclsyntax.Body)
+
+	tests := map[string]struct {
+		Target  hcl.Range
+		NewName string
+		Other   string // replaces the source of other.json, if set
+		Want    map[string]string
+		WantErr string
+	}{
+		"local": {
+			Target:  body.Blocks[0].Body.Attributes["x"].NameRange,
+			NewName: "z",
+			Want: map[string]string{
+				"main.hcl": strings.NewReplacer(
+					"x = 1", "z = 1",
+					"local.x", "local.z",
+				).Replace(referencesMainSrc),
+				"other.json": strings.Replace(referencesOtherSrc, "local.x", "local.z", 1),
+			},
+		},
+		"module": {
+			Target:  body.Blocks[1].LabelRanges[0],
+			NewName: "api",
+			Want: map[string]string{
+				"main.hcl": strings.NewReplacer(
+					`"web"`, `"api"`,
+					"module.web", "module.api",
+				).Replace(referencesMainSrc),
+				"other.json": strings.Replace(referencesOtherSrc, "module.web", "module.api", 1),
+			},
+		},
+		"reference after escape in JSON": {
+			Target:  body.Blocks[0].Body.Attributes["x"].NameRange,
+			NewName: "z",
+			Other:   `{"v": "café \"q\" ${local.x}"}`,
+			WantErr: "the source of the reference at other.json:1,19-26 could not be located",
+		},
+		"invalid name": {
+			Target:  body.Blocks[0].Body.Attributes["x"].NameRange,
+			NewName: "not valid",
+			WantErr: `"not valid" is not a valid identifier`,
+		},
+		"definition not given": {
+			Target:  hcl.Range{Filename: "missing.hcl"},
+			NewName: "z",
+			WantErr: "the file missing.hcl containing the definition was not given",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			files := files
+			if test.Other != "" {
+				files = []*hcl.File{files[0], parseStructureFile(t, "other.json", test.Other)}
+			}
+			edits, err := Rename(files, test.Target, test.NewName, resolver)
+			if test.WantErr != "" {
+				if err == nil || err.Error() != test.WantErr {
+					t.Fatalf("wrong error %v; want %q", err, test.WantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got := map[string]string{
+				"main.hcl":   referencesMainSrc,
+				"other.json": referencesOtherSrc,
+			}
+			// The edits for each file are in order, so we apply them in
+			// reverse to keep the earlier offsets valid.
+			for i := len(edits) - 1; i >= 0; i-- {
+				edit := edits[i]
+				src := got[edit.Range.Filename]
+				got[edit.Range.Filename] = src[:edit.Range.Start.Byte] + edit.NewText + src[edit.Range.End.Byte:]
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
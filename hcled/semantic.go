@@ -0,0 +1,330 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// SemanticTokenType is the category of a SemanticToken, for use by a text
+// editor to decide how to highlight it.
+type SemanticTokenType int
+
+const (
+	SemanticComment SemanticTokenType = iota
+	SemanticString
+	SemanticNumber
+	SemanticKeyword
+	SemanticOperator
+	SemanticBlockType
+	SemanticBlockLabel
+	SemanticAttributeName
+	SemanticObjectKey
+	SemanticVariable
+	SemanticTraversalAttr
+	SemanticFunctionName
+	SemanticTemplateDirective
+	SemanticTemplateInterp
+)
+
+// SemanticToken is a range of a file that belongs to a particular semantic
+// category.
+type SemanticToken struct {
+	Type  SemanticTokenType
+	Range hcl.Range
+}
+
+// SemanticTokens classifies the tokens of the given file into semantic
+// categories, returning them in source order. Tokens that don't belong to
+// any category, such as brackets and commas, are omitted. A token may span
+// multiple lines, as with a multi-line comment.
+//
+// Native syntax files are classified by their syntax alone, and so the schema
+// is ignored. Files in any other syntax are assumed to be JSON, where the
+// given schema decides which properties are attributes and which are blocks.
+// The schema may be nil, in which case all properties are treated as
+// attributes.
+func SemanticTokens(file *hcl.File, schema *Schema) []SemanticToken {
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		return nativeSemanticTokens(file, body)
+	}
+	return jsonSemanticTokens(file, schema)
+}
+
+func nativeSemanticTokens(file *hcl.File, body *hclsyntax.Body) []SemanticToken {
+	tokens, _ := hclsyntax.LexConfig(file.Bytes, file.Body.MissingItemRange().Filename, hcl.InitialPos)
+	c := &semanticClassifier{
+		tokens:  tokens,
+		classes: map[int]SemanticTokenType{},
+	}
+
+	// We first classify the identifiers whose meaning depends on where they
+	// appear in the syntax tree. The walk visits outer nodes before inner
+	// ones and the first classification of a token wins, so that e.g. an
+	// object key is not then classified as a variable.
+	hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		switch node := node.(type) {
+		case *hclsyntax.Attribute:
+			c.classify(node.NameRange, SemanticAttributeName)
+		case *hclsyntax.Block:
+			c.classify(node.TypeRange, SemanticBlockType)
+			for _, rng := range node.LabelRanges {
+				c.classify(rng, SemanticBlockLabel)
+			}
+		case *hclsyntax.ObjectConsKeyExpr:
+			if hcl.ExprAsKeyword(node) != "" {
+				c.classify(node.Range(), SemanticObjectKey)
+			}
+		case *hclsyntax.ScopeTraversalExpr:
+			c.classifyTraversal(node.Traversal)
+		case *hclsyntax.RelativeTraversalExpr:
+			c.classifyTraversal(node.Traversal)
+		case *hclsyntax.FunctionCallExpr:
+			c.classify(node.NameRange, SemanticFunctionName)
+		case *hclsyntax.ForExpr:
+			c.classifyForIntro(node)
+		}
+		return nil
+	})
+
+	// Everything else can be classified by token type, keeping track of the
+	// template sequences we're inside so that we can tell which sort of
+	// sequence each closing brace belongs to.
+	var ret []SemanticToken
+	var seqs []SemanticTokenType
+	for i, tok := range tokens {
+		ty, ok := c.classes[i]
+		switch tok.Type {
+		case hclsyntax.TokenTemplateInterp:
+			ty, ok = SemanticTemplateInterp, true
+			seqs = append(seqs, ty)
+		case hclsyntax.TokenTemplateControl:
+			ty, ok = SemanticTemplateDirective, true
+			seqs = append(seqs, ty)
+		case hclsyntax.TokenTemplateSeqEnd:
+			if len(seqs) != 0 {
+				ty, ok = seqs[len(seqs)-1], true
+				seqs = seqs[:len(seqs)-1]
+			}
+		case hclsyntax.TokenIdent:
+			// The first word of a template directive is its keyword.
+			if i > 0 && tokens[i-1].Type == hclsyntax.TokenTemplateControl {
+				ty, ok = SemanticTemplateDirective, true
+			}
+		}
+		if !ok {
+			ty, ok = tokenSemanticType(tok)
+		}
+		if ok {
+			ret = append(ret, SemanticToken{
+				Type:  ty,
+				Range: tok.Range,
+			})
+		}
+	}
+	return ret
+}
+
+type semanticClassifier struct {
+	tokens  hclsyntax.Tokens
+	classes map[int]SemanticTokenType
+}
+
+// classify assigns the given type to the identifiers and other name-like
+// tokens within the given range that don't already have a type.
+func (c *semanticClassifier) classify(rng hcl.Range, ty SemanticTokenType) {
+	for i := c.tokenIndex(rng.Start.Byte); i < len(c.tokens) && c.tokens[i].Range.Start.Byte < rng.End.Byte; i++ {
+		switch c.tokens[i].Type {
+		case hclsyntax.TokenIdent, hclsyntax.TokenDoubleColon, hclsyntax.TokenOQuote, hclsyntax.TokenQuotedLit, hclsyntax.TokenCQuote:
+			c.set(i, ty)
+		}
+	}
+}
+
+func (c *semanticClassifier) classifyTraversal(traversal hcl.Traversal) {
+	for _, step := range traversal {
+		switch step.(type) {
+		case hcl.TraverseRoot:
+			c.classify(step.SourceRange(), SemanticVariable)
+		case hcl.TraverseAttr:
+			c.classify(step.SourceRange(), SemanticTraversalAttr)
+		}
+	}
+}
+
+// classifyForIntro classifies the keywords and variable names that introduce
+// a for expression or template directive, which are not themselves
+// represented in the syntax tree.
+func (c *semanticClassifier) classifyForIntro(expr *hclsyntax.ForExpr) {
+	keyword := SemanticKeyword
+	start := c.tokenIndex(expr.OpenRange.Start.Byte)
+	if start < len(c.tokens) && c.tokens[start].Type == hclsyntax.TokenTemplateControl {
+		keyword = SemanticTemplateDirective
+	}
+
+	// The identifiers before the collection expression are the "for"
+	// keyword, the variable names, and the "in" keyword.
+	var idents []int
+	end := c.tokenIndex(expr.CollExpr.Range().Start.Byte)
+	for i := start; i < end; i++ {
+		if c.tokens[i].Type == hclsyntax.TokenIdent {
+			idents = append(idents, i)
+		}
+	}
+	for n, i := range idents {
+		switch n {
+		case 0, len(idents) - 1:
+			c.set(i, keyword)
+		default:
+			c.set(i, SemanticVariable)
+		}
+	}
+
+	if expr.CondExpr != nil {
+		i := c.tokenIndex(expr.CondExpr.Range().Start.Byte) - 1
+		if i >= 0 && c.tokens[i].Type == hclsyntax.TokenIdent {
+			c.set(i, keyword)
+		}
+	}
+}
+
+func (c *semanticClassifier) set(i int, ty SemanticTokenType) {
+	if _, exists := c.classes[i]; !exists {
+		c.classes[i] = ty
+	}
+}
+
+// tokenIndex returns the index of the first token that starts at or after
+// the given byte offset.
+func (c *semanticClassifier) tokenIndex(offset int) int {
+	return sort.Search(len(c.tokens), func(i int) bool {
+		return c.tokens[i].Range.Start.Byte >= offset
+	})
+}
+
+// tokenSemanticType returns the type of a token that has no particular
+// meaning in the syntax tree, if any.
+func tokenSemanticType(tok hclsyntax.Token) (SemanticTokenType, bool) {
+	switch tok.Type {
+	case hclsyntax.TokenComment:
+		return SemanticComment, true
+	case hclsyntax.TokenOQuote, hclsyntax.TokenCQuote, hclsyntax.TokenQuotedLit,
+		hclsyntax.TokenOHeredoc, hclsyntax.TokenCHeredoc, hclsyntax.TokenStringLit:
+		return SemanticString, true
+	case hclsyntax.TokenNumberLit:
+		return SemanticNumber, true
+	case hclsyntax.TokenIdent:
+		switch string(tok.Bytes) {
+		case "true", "false", "null":
+			return SemanticKeyword, true
+		}
+	case hclsyntax.TokenEqual, hclsyntax.TokenPlus, hclsyntax.TokenMinus,
+		hclsyntax.TokenStar, hclsyntax.TokenSlash, hclsyntax.TokenPercent,
+		hclsyntax.TokenEqualOp, hclsyntax.TokenNotEqual,
+		hclsyntax.TokenLessThan, hclsyntax.TokenLessThanEq,
+		hclsyntax.TokenGreaterThan, hclsyntax.TokenGreaterThanEq,
+		hclsyntax.TokenAnd, hclsyntax.TokenOr, hclsyntax.TokenBang,
+		hclsyntax.TokenQuestion, hclsyntax.TokenColon,
+		hclsyntax.TokenFatArrow, hclsyntax.TokenEllipsis:
+		return SemanticOperator, true
+	}
+	return 0, false
+}
+
+func jsonSemanticTokens(file *hcl.File, schema *Schema) []SemanticToken {
+	c := &jsonSemanticClassifier{src: file.Bytes}
+	c.classifyBody(file.Body, schema)
+
+	// Blocks written as arrays of objects share a type, so we may have
+	// seen some ranges more than once.
+	sort.SliceStable(c.tokens, func(i, j int) bool {
+		return c.tokens[i].Range.Start.Byte < c.tokens[j].Range.Start.Byte
+	})
+	var ret []SemanticToken
+	for _, tok := range c.tokens {
+		if len(ret) != 0 && ret[len(ret)-1].Range.Start.Byte == tok.Range.Start.Byte {
+			continue
+		}
+		ret = append(ret, tok)
+	}
+	return ret
+}
+
+type jsonSemanticClassifier struct {
+	src    []byte
+	tokens []SemanticToken
+}
+
+func (c *jsonSemanticClassifier) classifyBody(body hcl.Body, schema *Schema) {
+	if schema != nil {
+		bodyS := &hcl.BodySchema{Attributes: schema.Attributes}
+		for _, blockS := range schema.Blocks {
+			bodyS.Blocks = append(bodyS.Blocks, hcl.BlockHeaderSchema{
+				Type:       blockS.Type,
+				LabelNames: blockS.LabelNames,
+			})
+		}
+		content, remain, _ := body.PartialContent(bodyS)
+		for _, attr := range content.Attributes {
+			c.classifyAttribute(attr)
+		}
+		for _, block := range content.Blocks {
+			c.add(block.TypeRange, SemanticBlockType)
+			for _, rng := range block.LabelRanges {
+				c.add(rng, SemanticBlockLabel)
+			}
+			c.classifyBody(block.Body, schema.block([]string{block.Type}).body())
+		}
+		body = remain
+	}
+
+	// Anything not in the schema is treated as an attribute.
+	attrs, _ := body.JustAttributes()
+	for _, attr := range attrs {
+		c.classifyAttribute(attr)
+	}
+}
+
+func (c *jsonSemanticClassifier) classifyAttribute(attr *hcl.Attribute) {
+	c.add(attr.NameRange, SemanticAttributeName)
+	c.classifyExpr(attr.Expr)
+}
+
+func (c *jsonSemanticClassifier) classifyExpr(expr hcl.Expression) {
+	rng := expr.Range()
+	if rng.Start.Byte >= len(c.src) {
+		return
+	}
+
+	// JSON values can be told apart by their first character.
+	switch c.src[rng.Start.Byte] {
+	case '{':
+		pairs, _ := hcl.ExprMap(expr)
+		for _, pair := range pairs {
+			c.add(pair.Key.Range(), SemanticObjectKey)
+			c.classifyExpr(pair.Value)
+		}
+	case '[':
+		elems, _ := hcl.ExprList(expr)
+		for _, elem := range elems {
+			c.classifyExpr(elem)
+		}
+	case '"':
+		c.add(rng, SemanticString)
+	case 't', 'f', 'n':
+		c.add(rng, SemanticKeyword)
+	default:
+		c.add(rng, SemanticNumber)
+	}
+}
+
+func (c *jsonSemanticClassifier) add(rng hcl.Range, ty SemanticTokenType) {
+	c.tokens = append(c.tokens, SemanticToken{
+		Type:  ty,
+		Range: rng,
+	})
+}
@@ -0,0 +1,214 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	schema := &Schema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "name"},
+		},
+		Blocks: []BlockSchema{
+			{
+				Type:       "resource",
+				LabelNames: []string{"type", "name"},
+				Body: &Schema{
+					Attributes: []hcl.AttributeSchema{
+						{Name: "tags"},
+					},
+				},
+			},
+		},
+	}
+
+	type tok struct {
+		Type SemanticTokenType
+		Text string
+	}
+	tests := map[string]struct {
+		Filename string
+		Src      string
+		Schema   *Schema
+		Want     []tok
+	}{
+		"blocks and attributes": {
+			"test.hcl",
+			"# comment\nresource \"a\" b {\n  x = true\n}\n",
+			schema,
+			[]tok{
+				{SemanticComment, "# comment\n"},
+				{SemanticBlockType, "resource"},
+				{SemanticBlockLabel, `"`},
+				{SemanticBlockLabel, "a"},
+				{SemanticBlockLabel, `"`},
+				{SemanticBlockLabel, "b"},
+				{SemanticAttributeName, "x"},
+				{SemanticOperator, "="},
+				{SemanticKeyword, "true"},
+			},
+		},
+		"traversals and function calls": {
+			"test.hcl",
+			"x = upper(var.a[0].b) + provider::p::f(l[*].id)\n",
+			schema,
+			[]tok{
+				{SemanticAttributeName, "x"},
+				{SemanticOperator, "="},
+				{SemanticFunctionName, "upper"},
+				{SemanticVariable, "var"},
+				{SemanticTraversalAttr, "a"},
+				{SemanticNumber, "0"},
+				{SemanticTraversalAttr, "b"},
+				{SemanticOperator, "+"},
+				{SemanticFunctionName, "provider"},
+				{SemanticFunctionName, "::"},
+				{SemanticFunctionName, "p"},
+				{SemanticFunctionName, "::"},
+				{SemanticFunctionName, "f"},
+				{SemanticVariable, "l"},
+				{SemanticOperator, "*"},
+				{SemanticTraversalAttr, "id"},
+			},
+		},
+		"object keys": {
+			"test.hcl",
+			"x = { a = 1, \"b\" = 2, (c) = 3 }\n",
+			schema,
+			[]tok{
+				{SemanticAttributeName, "x"},
+				{SemanticOperator, "="},
+				{SemanticObjectKey, "a"},
+				{SemanticOperator, "="},
+				{SemanticNumber, "1"},
+				{SemanticString, `"`},
+				{SemanticString, "b"},
+				{SemanticString, `"`},
+				{SemanticOperator, "="},
+				{SemanticNumber, "2"},
+				{SemanticVariable, "c"},
+				{SemanticOperator, "="},
+				{SemanticNumber, "3"},
+			},
+		},
+		"for expression": {
+			"test.hcl",
+			"x = {for k, v in m : k => v... if v != null}\n",
+			schema,
+			[]tok{
+				{SemanticAttributeName, "x"},
+				{SemanticOperator, "="},
+				{SemanticKeyword, "for"},
+				{SemanticVariable, "k"},
+				{SemanticVariable, "v"},
+				{SemanticKeyword, "in"},
+				{SemanticVariable, "m"},
+				{SemanticOperator, ":"},
+				{SemanticVariable, "k"},
+				{SemanticOperator, "=>"},
+				{SemanticVariable, "v"},
+				{SemanticOperator, "..."},
+				{SemanticKeyword, "if"},
+				{SemanticVariable, "v"},
+				{SemanticOperator, "!="},
+				{SemanticKeyword, "null"},
+			},
+		},
+		"template directives": {
+			"test.hcl",
+			"x = <<EOT\n%{ for a in b ~}\n${a}\n%{ endfor }\n%{if c}y%{endif}\nEOT\n",
+			schema,
+			[]tok{
+				{SemanticAttributeName, "x"},
+				{SemanticOperator, "="},
+				{SemanticString, "<<EOT\n"},
+				{SemanticTemplateDirective, "%{"},
+				{SemanticTemplateDirective, "for"},
+				{SemanticVariable, "a"},
+				{SemanticTemplateDirective, "in"},
+				{SemanticVariable, "b"},
+				{SemanticTemplateDirective, "~}"},
+				{SemanticString, "\n"},
+				{SemanticTemplateInterp, "${"},
+				{SemanticVariable, "a"},
+				{SemanticTemplateInterp, "}"},
+				{SemanticString, "\n"},
+				{SemanticTemplateDirective, "%{"},
+				{SemanticTemplateDirective, "endfor"},
+				{SemanticTemplateDirective, "}"},
+				{SemanticString, "\n"},
+				{SemanticTemplateDirective, "%{"},
+				{SemanticTemplateDirective, "if"},
+				{SemanticVariable, "c"},
+				{SemanticTemplateDirective, "}"},
+				{SemanticString, "y"},
+				{SemanticTemplateDirective, "%{"},
+				{SemanticTemplateDirective, "endif"},
+				{SemanticTemplateDirective, "}"},
+				{SemanticString, "\n"},
+				{SemanticString, "EOT"},
+			},
+		},
+		"json with schema": {
+			"test.json",
+			`{"name": "x", "resource": {"a": {"b": {"tags": {"env": 1}, "other": [true, null]}}}}`,
+			schema,
+			[]tok{
+				{SemanticAttributeName, `"name"`},
+				{SemanticString, `"x"`},
+				{SemanticBlockType, `"resource"`},
+				{SemanticBlockLabel, `"a"`},
+				{SemanticBlockLabel, `"b"`},
+				{SemanticAttributeName, `"tags"`},
+				{SemanticObjectKey, `"env"`},
+				{SemanticNumber, "1"},
+				{SemanticAttributeName, `"other"`},
+				{SemanticKeyword, "true"},
+				{SemanticKeyword, "null"},
+			},
+		},
+		"json block array": {
+			"test.json",
+			`{"resource": {"a": {"b": [{"tags": "x"}, {"tags": "y"}]}}}`,
+			schema,
+			[]tok{
+				{SemanticBlockType, `"resource"`},
+				{SemanticBlockLabel, `"a"`},
+				{SemanticBlockLabel, `"b"`},
+				{SemanticAttributeName, `"tags"`},
+				{SemanticString, `"x"`},
+				{SemanticAttributeName, `"tags"`},
+				{SemanticString, `"y"`},
+			},
+		},
+		"json without schema": {
+			"test.json",
+			`{"resource": {"a": 1}}`,
+			nil,
+			[]tok{
+				{SemanticAttributeName, `"resource"`},
+				{SemanticObjectKey, `"a"`},
+				{SemanticNumber, "1"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := parseStructureFile(t, test.Filename, test.Src)
+			var got []tok
+			for _, st := range SemanticTokens(file, test.Schema) {
+				got = append(got, tok{st.Type, rangeText(test.Src, st.Range)})
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
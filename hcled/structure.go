@@ -0,0 +1,115 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// SymbolKind is the kind of item that a Symbol represents.
+type SymbolKind int
+
+const (
+	SymbolAttribute SymbolKind = iota
+	SymbolBlock
+)
+
+// Symbol describes an attribute or block in a file, for display in an
+// outline of the file in a text editor.
+type Symbol struct {
+	Kind SymbolKind
+
+	// Name is the name of an attribute or the type of a block.
+	Name string
+
+	// Labels are the labels of a block, or nil for an attribute.
+	Labels []string
+
+	// Range is the range of the whole item, while NameRange is the range
+	// to select when the symbol is chosen: the name of an attribute or the
+	// header of a block.
+	Range     hcl.Range
+	NameRange hcl.Range
+
+	// Children are the items nested inside the body of a block, in source
+	// order.
+	Children []Symbol
+}
+
+type symbolWalker interface {
+	WalkSymbols(cb func(depth int, block bool, name string, labels []string, rng, nameRange hcl.Range))
+}
+
+// Symbols returns the attributes and blocks of the given file as a tree of
+// symbols in source order, or nil if the file's syntax doesn't support
+// navigation.
+//
+// For JSON files, whose structure depends on the schema used to decode them,
+// each property whose value is an object is returned as a block containing
+// the object's properties, and all other properties as attributes.
+func Symbols(file *hcl.File) []Symbol {
+	walker, ok := file.Nav.(symbolWalker)
+	if !ok {
+		return nil
+	}
+
+	// The walk visits the items in source order with the children of each
+	// block immediately after it, so we can build our tree by keeping track
+	// of the most recently-visited item at each depth.
+	var ret []Symbol
+	var stack []*[]Symbol
+	walker.WalkSymbols(func(depth int, block bool, name string, labels []string, rng, nameRange hcl.Range) {
+		sym := Symbol{
+			Kind:      SymbolAttribute,
+			Name:      name,
+			Range:     rng,
+			NameRange: nameRange,
+		}
+		if block {
+			sym.Kind = SymbolBlock
+			sym.Labels = labels
+		}
+
+		stack = stack[:depth]
+		siblings := &ret
+		if depth > 0 {
+			parent := stack[depth-1]
+			siblings = &(*parent)[len(*parent)-1].Children
+		}
+		*siblings = append(*siblings, sym)
+		stack = append(stack, siblings)
+	})
+	return ret
+}
+
+type foldingRanger interface {
+	FoldingRanges() []hcl.Range
+}
+
+// FoldingRanges returns the ranges of the constructs in the given file that
+// a text editor could offer to fold away: blocks, heredoc templates, and
+// object and tuple constructors that span multiple lines. The result is
+// nil if the file's syntax doesn't support navigation.
+func FoldingRanges(file *hcl.File) []hcl.Range {
+	if ranger, ok := file.Nav.(foldingRanger); ok {
+		return ranger.FoldingRanges()
+	}
+	return nil
+}
+
+type selectionRanger interface {
+	SelectionRanges(offset int) []hcl.Range
+}
+
+// SelectionRanges returns the ranges of the nested syntax constructs that
+// contain the given position, ordered from innermost to outermost, for
+// use by a text editor to expand or shrink a selection. The last range is
+// the range of the file's root body or value. The result is nil if the
+// file's syntax doesn't support navigation.
+func SelectionRanges(file *hcl.File, pos hcl.Pos) []hcl.Range {
+	if ranger, ok := file.Nav.(selectionRanger); ok {
+		return ranger.SelectionRanges(pos.Byte)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcled
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/json"
+)
+
+const structureNativeSrc = `name = "example"
+
+resource "aws_instance" "web" {
+  ami = "abc"
+  tags = {
+    env = "prod"
+    list = [
+      1,
+      2,
+    ]
+  }
+
+  lifecycle {}
+
+  user_data = <<EOT
+hello
+EOT
+}
+`
+
+const structureJSONSrc = `{
+  "name": "example",
+  "resource": {
+    "aws_instance": {
+      "web": {"ami": "abc", "zones": [
+        "a"
+      ]}
+    }
+  }
+}
+`
+
+func parseStructureFile(t *testing.T, filename, src string) *hcl.File {
+	t.Helper()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(filename, ".json") {
+		file, diags = json.Parse([]byte(src), filename)
+	} else {
+		file, diags = hclsyntax.ParseConfig([]byte(src), filename, hcl.InitialPos)
+	}
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	return file
+}
+
+// rangeText returns the source text covered by the given range, which makes
+// test expectations easier to read than byte offsets.
+func rangeText(src string, rng hcl.Range) string {
+	return src[rng.Start.Byte:rng.End.Byte]
+}
+
+func TestSymbols(t *testing.T) {
+	type sym struct {
+		Kind     SymbolKind
+		Name     string
+		Labels   []string
+		Text     string
+		NameText string
+		Children []sym
+	}
+	var convert func(src string, syms []Symbol) []sym
+	convert = func(src string, syms []Symbol) []sym {
+		var ret []sym
+		for _, s := range syms {
+			ret = append(ret, sym{
+				Kind:     s.Kind,
+				Name:     s.Name,
+				Labels:   s.Labels,
+				Text:     rangeText(src, s.Range),
+				NameText: rangeText(src, s.NameRange),
+				Children: convert(src, s.Children),
+			})
+		}
+		return ret
+	}
+
+	tests := map[string]struct {
+		Filename string
+		Src      string
+		Want     []sym
+	}{
+		"native": {
+			"test.hcl",
+			structureNativeSrc,
+			[]sym{
+				{SymbolAttribute, "name", nil, `name = "example"`, "name", nil},
+				{SymbolBlock, "resource", []string{"aws_instance", "web"}, structureNativeSrc[18 : len(structureNativeSrc)-1], `resource "aws_instance" "web"`, []sym{
+					{SymbolAttribute, "ami", nil, `ami = "abc"`, "ami", nil},
+					{SymbolAttribute, "tags", nil, "tags = {\n    env = \"prod\"\n    list = [\n      1,\n      2,\n    ]\n  }", "tags", nil},
+					{SymbolBlock, "lifecycle", nil, "lifecycle {}", "lifecycle", nil},
+					{SymbolAttribute, "user_data", nil, "user_data = <<EOT\nhello\nEOT", "user_data", nil},
+				}},
+			},
+		},
+		"json": {
+			"test.json",
+			structureJSONSrc,
+			[]sym{
+				{SymbolAttribute, "name", nil, `"name": "example"`, `"name"`, nil},
+				{SymbolBlock, "resource", nil, structureJSONSrc[25 : len(structureJSONSrc)-3], `"resource"`, []sym{
+					{SymbolBlock, "aws_instance", nil, structureJSONSrc[43 : len(structureJSONSrc)-7], `"aws_instance"`, []sym{
+						{SymbolBlock, "web", nil, "\"web\": {\"ami\": \"abc\", \"zones\": [\n        \"a\"\n      ]}", `"web"`, []sym{
+							{SymbolAttribute, "ami", nil, `"ami": "abc"`, `"ami"`, nil},
+							{SymbolAttribute, "zones", nil, "\"zones\": [\n        \"a\"\n      ]", `"zones"`, nil},
+						}},
+					}},
+				}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := parseStructureFile(t, test.Filename, test.Src)
+			got := convert(test.Src, Symbols(file))
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFoldingRanges(t *testing.T) {
+	tests := map[string]struct {
+		Filename string
+		Src      string
+		Want     []string
+	}{
+		"native": {
+			"test.hcl",
+			structureNativeSrc,
+			[]string{
+				structureNativeSrc[18 : len(structureNativeSrc)-1],
+				"{\n    env = \"prod\"\n    list = [\n      1,\n      2,\n    ]\n  }",
+				"[\n      1,\n      2,\n    ]",
+				"<<EOT\nhello\nEOT",
+			},
+		},
+		"json": {
+			"test.json",
+			structureJSONSrc,
+			[]string{
+				structureJSONSrc[:len(structureJSONSrc)-1],
+				structureJSONSrc[37 : len(structureJSONSrc)-3],
+				structureJSONSrc[59 : len(structureJSONSrc)-7],
+				"{\"ami\": \"abc\", \"zones\": [\n        \"a\"\n      ]}",
+				"[\n        \"a\"\n      ]",
+			},
+		},
+		"single line": {
+			"test.hcl",
+			"a = [1, 2]\nb = {}\nc {}\n",
+			nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := parseStructureFile(t, test.Filename, test.Src)
+			var got []string
+			for _, rng := range FoldingRanges(file) {
+				got = append(got, rangeText(test.Src, rng))
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectionRanges(t *testing.T) {
+	tests := map[string]struct {
+		Filename string
+		Src      string
+		Want     []string
+	}{
+		"native attribute name": {
+			"test.hcl",
+			"a = 1\nb|lock {\n  c = 2\n}\n",
+			[]string{
+				"block",
+				"block {\n  c = 2\n}",
+				"a = 1\nblock {\n  c = 2\n}\n",
+			},
+		},
+		"native nested expression": {
+			"test.hcl",
+			"block {\n  c = [1, foo.b|ar + 2]\n}\n",
+			[]string{
+				"foo.bar",
+				"foo.bar + 2",
+				"[1, foo.bar + 2]",
+				"c = [1, foo.bar + 2]",
+				"{\n  c = [1, foo.bar + 2]\n}",
+				"block {\n  c = [1, foo.bar + 2]\n}",
+				"block {\n  c = [1, foo.bar + 2]\n}\n",
+			},
+		},
+		"native end of word": {
+			"test.hcl",
+			"a = foo|\n",
+			[]string{
+				"foo",
+				"a = foo",
+				"a = foo\n",
+			},
+		},
+		"json value": {
+			"test.json",
+			`{"a": {"b": [1, 2|]}}`,
+			[]string{
+				"2",
+				"[1, 2]",
+				`"b": [1, 2]`,
+				`{"b": [1, 2]}`,
+				`"a": {"b": [1, 2]}`,
+				`{"a": {"b": [1, 2]}}`,
+			},
+		},
+		"json property name": {
+			"test.json",
+			`{"a": {"|b": true}}`,
+			[]string{
+				`"b"`,
+				`"b": true`,
+				`{"b": true}`,
+				`"a": {"b": true}`,
+				`{"a": {"b": true}}`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			offset := strings.Index(test.Src, "|")
+			src := test.Src[:offset] + test.Src[offset+1:]
+			file := parseStructureFile(t, test.Filename, src)
+
+			var got []string
+			for _, rng := range SelectionRanges(file, hcl.Pos{Byte: offset}) {
+				got = append(got, rangeText(src, rng))
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
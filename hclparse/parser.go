@@ -40,6 +40,7 @@ import (
 // multiple times would create a confusing result.
 type Parser struct {
 	files map[string]*hcl.File
+	opts  hclsyntax.ParseOptions
 }
 
 // NewParser creates a new parser, ready to parse configuration files.
@@ -49,6 +50,16 @@ func NewParser() *Parser {
 	}
 }
 
+// NewParserWithOptions is like NewParser, but the returned parser applies the
+// given limits and feature toggles when parsing native syntax files. The
+// options have no effect on JSON files.
+func NewParserWithOptions(opts hclsyntax.ParseOptions) *Parser {
+	return &Parser{
+		files: map[string]*hcl.File{},
+		opts:  opts,
+	}
+}
+
 // ParseHCL parses the given buffer (which is assumed to have been loaded from
 // the given filename) as a native-syntax configuration file and returns the
 // hcl.File object representing it.
@@ -57,7 +68,7 @@ func (p *Parser) ParseHCL(src []byte, filename string) (*hcl.File, hcl.Diagnosti
 		return existing, nil
 	}
 
-	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1})
+	file, diags := hclsyntax.ParseConfigWithOptions(src, filename, hcl.Pos{Byte: 0, Line: 1, Column: 1}, p.opts)
 	p.files[filename] = file
 	return file, diags
 }
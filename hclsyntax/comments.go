@@ -0,0 +1,215 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/apparentlymart/go-textseg/v15/textseg"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Comment is a comment from the source of a body, as recorded by
+// ParseConfigWithComments.
+type Comment struct {
+	// Bytes is the raw source of the comment, including its delimiters but
+	// excluding the newline that terminates a single-line comment.
+	Bytes []byte
+
+	Range hcl.Range
+}
+
+// Text returns the content of the comment with its delimiters and any
+// leading and trailing whitespace removed.
+func (c Comment) Text() string {
+	text := string(c.Bytes)
+	switch {
+	case strings.HasPrefix(text, "#"):
+		text = text[1:]
+	case strings.HasPrefix(text, "//"):
+		text = text[2:]
+	case strings.HasPrefix(text, "/*"):
+		text = strings.TrimSuffix(text[2:], "*/")
+	}
+	return strings.TrimSpace(text)
+}
+
+// Comments are the comments associated with an attribute or block, as
+// recorded by ParseConfigWithComments.
+type Comments struct {
+	// Leading are the comments on the lines immediately before the item,
+	// with no blank lines between them or before the item, along with any
+	// comments on the same line before the item. A documentation comment
+	// for an item is conventionally written as a leading comment.
+	Leading []Comment
+
+	// Inline are the comments within the item itself, excluding those
+	// inside the body of a block, which are instead associated with the
+	// items of that body. For a block, this includes any comments on the
+	// same line after its opening brace.
+	Inline []Comment
+
+	// Trailing are the comments after the end of the item on the same line
+	// as its end.
+	Trailing []Comment
+}
+
+// newComment produces a Comment from a comment token, removing the newline
+// that terminates a single-line comment.
+func newComment(tok Token) Comment {
+	src := bytes.TrimRight(tok.Bytes, "\r\n")
+	rng := tok.Range
+	if len(src) != len(tok.Bytes) {
+		// Single-line comments never contain newlines other than the one
+		// at the end, so the comment now ends on the line it starts on.
+		columns, _ := textseg.TokenCount(src, textseg.ScanGraphemeClusters)
+		rng.End = hcl.Pos{
+			Line:   rng.Start.Line,
+			Column: rng.Start.Column + columns,
+			Byte:   rng.Start.Byte + len(src),
+		}
+	}
+	return Comment{
+		Bytes: src,
+		Range: rng,
+	}
+}
+
+// attachComments associates the given comment tokens, which must be in
+// source order, with the given body and its descendents.
+func attachComments(body *Body, tokens Tokens) {
+	var comments []Comment
+	for _, tok := range tokens {
+		if tok.Type == TokenComment {
+			comments = append(comments, newComment(tok))
+		}
+	}
+	body.commentsAttached = true
+	attachBodyComments(body, comments, nil)
+}
+
+// attachBodyComments associates the given comments, all of which must be
+// within the given body, with the body's items. The owner is the block whose
+// body it is, or nil for the root body.
+func attachBodyComments(body *Body, comments []Comment, owner *Block) {
+	items := sortedBodyItems(body)
+
+	// We'll consider the comments before each item in turn, and then those
+	// after the last item. prevItem is the item before the current gap, if
+	// any.
+	var prevItem Node
+	for _, item := range items {
+		rng := item.Range()
+		var gap []Comment
+		for len(comments) != 0 && comments[0].Range.Start.Byte < rng.Start.Byte {
+			gap = append(gap, comments[0])
+			comments = comments[1:]
+		}
+		var inside []Comment
+		for len(comments) != 0 && comments[0].Range.Start.Byte < rng.End.Byte {
+			inside = append(inside, comments[0])
+			comments = comments[1:]
+		}
+
+		gap = attachTrailingComments(gap, prevItem, owner)
+		gap = attachLeadingComments(gap, item)
+		body.Comments = append(body.Comments, gap...)
+		attachInnerComments(item, inside)
+		prevItem = item
+	}
+	rest := attachTrailingComments(comments, prevItem, owner)
+	body.Comments = append(body.Comments, rest...)
+}
+
+// attachTrailingComments associates any comments at the start of the given
+// gap that are on the same line as the end of the given previous item, or on
+// the same line as the owner's opening brace if there is no previous item.
+// It returns the remaining comments.
+func attachTrailingComments(gap []Comment, prevItem Node, owner *Block) []Comment {
+	var line int
+	switch {
+	case prevItem != nil:
+		line = prevItem.Range().End.Line
+	case owner != nil:
+		line = owner.OpenBraceRange.End.Line
+	default:
+		return gap
+	}
+
+	i := 0
+	for i < len(gap) && gap[i].Range.Start.Line == line {
+		i++
+	}
+	if i == 0 {
+		return gap
+	}
+	if prevItem != nil {
+		comments := itemComments(prevItem)
+		comments.Trailing = append(comments.Trailing, gap[:i]...)
+	} else {
+		comments := itemComments(owner)
+		comments.Inline = append(comments.Inline, gap[:i]...)
+	}
+	return gap[i:]
+}
+
+// attachLeadingComments associates any comments at the end of the given gap
+// that immediately precede the given item, and returns the others.
+func attachLeadingComments(gap []Comment, item Node) []Comment {
+	line := item.Range().Start.Line
+	i := len(gap)
+	for i > 0 {
+		c := gap[i-1].Range
+		if c.End.Line != line && c.End.Line != line-1 {
+			break
+		}
+		line = c.Start.Line
+		i--
+	}
+	if i < len(gap) {
+		comments := itemComments(item)
+		comments.Leading = append(comments.Leading, gap[i:]...)
+	}
+	return gap[:i]
+}
+
+// attachInnerComments associates comments that are within the range of the
+// given item.
+func attachInnerComments(item Node, inside []Comment) {
+	block, isBlock := item.(*Block)
+	var nested []Comment
+	for _, c := range inside {
+		if isBlock && c.Range.Start.Byte >= block.OpenBraceRange.End.Byte && c.Range.Start.Byte < block.CloseBraceRange.Start.Byte {
+			nested = append(nested, c)
+			continue
+		}
+		comments := itemComments(item)
+		comments.Inline = append(comments.Inline, c)
+	}
+	if isBlock {
+		attachBodyComments(block.Body, nested, block)
+	}
+}
+
+// itemComments returns the comments of the given attribute or block,
+// creating them first if necessary.
+func itemComments(item Node) *Comments {
+	switch item := item.(type) {
+	case *Attribute:
+		if item.Comments == nil {
+			item.Comments = &Comments{}
+		}
+		return item.Comments
+	case *Block:
+		if item.Comments == nil {
+			item.Comments = &Comments{}
+		}
+		return item.Comments
+	default:
+		// Should never happen, since bodies contain only attributes and
+		// blocks.
+		panic("comments can only belong to attributes and blocks")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestParseConfigWithComments(t *testing.T) {
+	src := `# File header, separated by a blank line.
+
+# The name of the thing.
+# It's important.
+name = "example" # trailing
+
+/* A block comment */ count = /* inline */ 2
+
+// Documentation for the variable.
+variable "region" /* label */ { # after brace
+  // The default.
+  default = "us-east-1"
+
+  # Dangling at the end of the block.
+} // after block
+
+# Dangling at the end of the file.
+`
+	file, diags := ParseConfigWithComments([]byte(src), "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	body := file.Body.(*Body)
+
+	commentTexts := func(comments []Comment) []string {
+		var ret []string
+		for _, c := range comments {
+			ret = append(ret, c.Text())
+		}
+		return ret
+	}
+	type itemComments struct {
+		Leading, Inline, Trailing []string
+	}
+	summarize := func(comments *Comments) *itemComments {
+		if comments == nil {
+			return nil
+		}
+		return &itemComments{
+			Leading:  commentTexts(comments.Leading),
+			Inline:   commentTexts(comments.Inline),
+			Trailing: commentTexts(comments.Trailing),
+		}
+	}
+
+	block := body.Blocks[0]
+	got := map[string]interface{}{
+		"body":          commentTexts(body.Comments),
+		"name":          summarize(body.Attributes["name"].Comments),
+		"count":         summarize(body.Attributes["count"].Comments),
+		"variable":      summarize(block.Comments),
+		"variable body": commentTexts(block.Body.Comments),
+		"default":       summarize(block.Body.Attributes["default"].Comments),
+	}
+	want := map[string]interface{}{
+		"body": []string{
+			"File header, separated by a blank line.",
+			"Dangling at the end of the file.",
+		},
+		"name": &itemComments{
+			Leading:  []string{"The name of the thing.", "It's important."},
+			Trailing: []string{"trailing"},
+		},
+		"count": &itemComments{
+			Leading: []string{"A block comment"},
+			Inline:  []string{"inline"},
+		},
+		"variable": &itemComments{
+			Leading:  []string{"Documentation for the variable."},
+			Inline:   []string{"label", "after brace"},
+			Trailing: []string{"after block"},
+		},
+		"variable body": []string{"Dangling at the end of the block."},
+		"default": &itemComments{
+			Leading: []string{"The default."},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong comments\n%s", diff)
+	}
+
+	trailing := body.Attributes["name"].Comments.Trailing[0]
+	if got, want := string(trailing.Bytes), "# trailing"; got != want {
+		t.Errorf("wrong bytes %q; want %q", got, want)
+	}
+	wantRange := hcl.Range{
+		Filename: "test.hcl",
+		Start:    hcl.Pos{Line: 5, Column: 18, Byte: 103},
+		End:      hcl.Pos{Line: 5, Column: 28, Byte: 113},
+	}
+	if got := trailing.Range; got != wantRange {
+		t.Errorf("wrong range\ngot:  %#v\nwant: %#v", got, wantRange)
+	}
+}
+
+func TestParseConfigWithoutComments(t *testing.T) {
+	file, diags := ParseConfig([]byte("# doc\na = 1 # trailing\n"), "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	body := file.Body.(*Body)
+	if body.Comments != nil {
+		t.Errorf("unexpected body comments %#v", body.Comments)
+	}
+	if got := body.Attributes["a"].Comments; got != nil {
+		t.Errorf("unexpected attribute comments %#v", got)
+	}
+}
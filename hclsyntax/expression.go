@@ -58,6 +58,16 @@ func (e *ParenthesesExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Expression)
 }
 
+func (e *ParenthesesExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	expr := rewriteExpr(r, e.Expression)
+	if expr == e.Expression {
+		return e
+	}
+	ret := *e
+	ret.Expression = expr
+	return &ret
+}
+
 // LiteralValueExpr is an expression that just always returns a given value.
 type LiteralValueExpr struct {
 	Val      cty.Value
@@ -68,6 +78,10 @@ func (e *LiteralValueExpr) walkChildNodes(w internalWalkFunc) {
 	// Literal values have no child nodes
 }
 
+func (e *LiteralValueExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	return e
+}
+
 func (e *LiteralValueExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	return e.Val, nil
 }
@@ -136,6 +150,10 @@ func (e *ScopeTraversalExpr) walkChildNodes(w internalWalkFunc) {
 	// Scope traversals have no child nodes
 }
 
+func (e *ScopeTraversalExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	return e
+}
+
 func (e *ScopeTraversalExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	val, diags := e.Traversal.TraverseAbs(ctx)
 	setDiagEvalContext(diags, e, ctx)
@@ -167,6 +185,16 @@ func (e *RelativeTraversalExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Source)
 }
 
+func (e *RelativeTraversalExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	source := rewriteExpr(r, e.Source)
+	if source == e.Source {
+		return e
+	}
+	ret := *e
+	ret.Source = source
+	return &ret
+}
+
 func (e *RelativeTraversalExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	src, diags := e.Source.Value(ctx)
 	ret, travDiags := e.Traversal.TraverseRel(src)
@@ -218,24 +246,33 @@ func (e *FunctionCallExpr) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (e *FunctionCallExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	args, changed := rewriteExprs(r, e.Args)
+	if !changed {
+		return e
+	}
+	ret := *e
+	ret.Args = args
+	return &ret
+}
+
 func (e *FunctionCallExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
-	var f function.Function
-	exists := false
-	hasNonNilMap := false
-	thisCtx := ctx
-	for thisCtx != nil {
-		if thisCtx.Functions == nil {
-			thisCtx = thisCtx.Parent()
-			continue
+	f, exists, resolveDiags := ctx.LookupFunction(e.Name, e.NameRange)
+	if exists {
+		setDiagEvalContext(resolveDiags, e, ctx)
+		diags = append(diags, resolveDiags...)
+		if resolveDiags.HasErrors() {
+			return cty.DynamicVal, diags
 		}
-		hasNonNilMap = true
-		f, exists = thisCtx.Functions[e.Name]
-		if exists {
-			break
+	}
+
+	hasNonNilMap := false
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.Parent() {
+		if thisCtx.Functions != nil || thisCtx.Resolver != nil {
+			hasNonNilMap = true
 		}
-		thisCtx = thisCtx.Parent()
 	}
 
 	if !exists {
@@ -525,6 +562,12 @@ func (e *FunctionCallExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnosti
 		return cty.DynamicVal, diags
 	}
 
+	budget := ctx.ActiveBudget()
+	if budgetDiags := budget.CheckDeadline(e.Range()); budgetDiags.HasErrors() {
+		setDiagEvalContext(budgetDiags, e, ctx)
+		return cty.DynamicVal, append(diags, budgetDiags...)
+	}
+
 	resultVal, err := f.Call(argVals)
 	if err != nil {
 		// For errors in the underlying call itself we also return the raw
@@ -629,9 +672,31 @@ func (e *FunctionCallExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnosti
 		return cty.DynamicVal, diags
 	}
 
+	// The function has already built its result by the time we can check
+	// it, as described in the documentation for MaxCollectionLength.
+	if budgetDiags := checkValueLengthBudget(budget, resultVal, e.Range()); budgetDiags.HasErrors() {
+		setDiagEvalContext(budgetDiags, e, ctx)
+		return cty.DynamicVal, append(diags, budgetDiags...)
+	}
+
 	return resultVal, diags
 }
 
+// checkValueLengthBudget checks the number of elements in the given value
+// against the collection length limit of the given budget, if the value is
+// a known collection, tuple or object.
+func checkValueLengthBudget(budget *hcl.EvalBudget, val cty.Value, rng hcl.Range) hcl.Diagnostics {
+	if budget == nil || val.IsNull() || !val.IsKnown() {
+		return nil
+	}
+	ty := val.Type()
+	if !(ty.IsCollectionType() || ty.IsTupleType() || ty.IsObjectType()) {
+		return nil
+	}
+	val, _ = val.Unmark()
+	return budget.CheckCollectionLength(val.LengthInt(), rng)
+}
+
 func (e *FunctionCallExpr) Range() hcl.Range {
 	return hcl.RangeBetween(e.NameRange, e.CloseParenRange)
 }
@@ -723,6 +788,20 @@ func (e *ConditionalExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.FalseResult)
 }
 
+func (e *ConditionalExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	cond := rewriteExpr(r, e.Condition)
+	trueResult := rewriteExpr(r, e.TrueResult)
+	falseResult := rewriteExpr(r, e.FalseResult)
+	if cond == e.Condition && trueResult == e.TrueResult && falseResult == e.FalseResult {
+		return e
+	}
+	ret := *e
+	ret.Condition = cond
+	ret.TrueResult = trueResult
+	ret.FalseResult = falseResult
+	return &ret
+}
+
 func (e *ConditionalExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	trueResult, trueDiags := e.TrueResult.Value(ctx)
 	falseResult, falseDiags := e.FalseResult.Value(ctx)
@@ -1104,6 +1183,18 @@ func (e *IndexExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Key)
 }
 
+func (e *IndexExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	coll := rewriteExpr(r, e.Collection)
+	key := rewriteExpr(r, e.Key)
+	if coll == e.Collection && key == e.Key {
+		return e
+	}
+	ret := *e
+	ret.Collection = coll
+	ret.Key = key
+	return &ret
+}
+
 func (e *IndexExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	coll, collDiags := e.Collection.Value(ctx)
@@ -1138,10 +1229,25 @@ func (e *TupleConsExpr) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (e *TupleConsExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	exprs, changed := rewriteExprs(r, e.Exprs)
+	if !changed {
+		return e
+	}
+	ret := *e
+	ret.Exprs = exprs
+	return &ret
+}
+
 func (e *TupleConsExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	var vals []cty.Value
 	var diags hcl.Diagnostics
 
+	if budgetDiags := ctx.ActiveBudget().CheckCollectionLength(len(e.Exprs), e.SrcRange); budgetDiags.HasErrors() {
+		setDiagEvalContext(budgetDiags, e, ctx)
+		return cty.DynamicVal, budgetDiags
+	}
+
 	vals = make([]cty.Value, len(e.Exprs))
 	for i, expr := range e.Exprs {
 		val, valDiags := expr.Value(ctx)
@@ -1188,6 +1294,30 @@ func (e *ObjectConsExpr) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (e *ObjectConsExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	var items []ObjectConsItem
+	for i, item := range e.Items {
+		key := rewriteExpr(r, item.KeyExpr)
+		value := rewriteExpr(r, item.ValueExpr)
+		if items == nil && (key != item.KeyExpr || value != item.ValueExpr) {
+			items = make([]ObjectConsItem, len(e.Items))
+			copy(items, e.Items[:i])
+		}
+		if items != nil {
+			items[i] = ObjectConsItem{
+				KeyExpr:   key,
+				ValueExpr: value,
+			}
+		}
+	}
+	if items == nil {
+		return e
+	}
+	ret := *e
+	ret.Items = items
+	return &ret
+}
+
 func (e *ObjectConsExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	var vals map[string]cty.Value
 	var diags hcl.Diagnostics
@@ -1261,6 +1391,11 @@ func (e *ObjectConsExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics
 		return cty.DynamicVal, diags
 	}
 
+	if budgetDiags := ctx.ActiveBudget().CheckCollectionLength(len(vals), e.SrcRange); budgetDiags.HasErrors() {
+		setDiagEvalContext(budgetDiags, e, ctx)
+		return cty.DynamicVal, append(diags, budgetDiags...)
+	}
+
 	return cty.ObjectVal(vals).WithMarks(marks...), diags
 }
 
@@ -1309,6 +1444,21 @@ func (e *ObjectConsKeyExpr) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (e *ObjectConsKeyExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	// As with walkChildNodes, a wrapped expression that will be interpreted
+	// as a literal is not a real expression.
+	if e.literalName() != "" {
+		return e
+	}
+	wrapped := rewriteExpr(r, e.Wrapped)
+	if wrapped == e.Wrapped {
+		return e
+	}
+	ret := *e
+	ret.Wrapped = wrapped
+	return &ret
+}
+
 func (e *ObjectConsKeyExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	// Because we accept a naked identifier as a literal key rather than a
 	// reference, it's confusing to accept a traversal containing periods
@@ -1485,6 +1635,8 @@ func (e *ForExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 		return cty.DynamicVal.WithMarks(append(marks, condMarks)...), diags
 	}
 
+	budget := ctx.ActiveBudget()
+
 	if e.KeyExpr != nil {
 		// Producing an object
 		var vals map[string]cty.Value
@@ -1499,6 +1651,11 @@ func (e *ForExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 
 		known := true
 		for it.Next() {
+			if budgetDiags := budget.ConsumeForIteration(e.SrcRange); budgetDiags.HasErrors() {
+				setDiagEvalContext(budgetDiags, e, ctx)
+				return cty.DynamicVal, append(diags, budgetDiags...)
+			}
+
 			k, v := it.Element()
 			childCtx := ctx.NewChild()
 			childCtx.Variables = map[string]cty.Value{}
@@ -1641,9 +1798,17 @@ func (e *ForExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 		if e.Group {
 			vals = map[string]cty.Value{}
 			for k, gvs := range groupVals {
+				if budgetDiags := budget.CheckCollectionLength(len(gvs), e.SrcRange); budgetDiags.HasErrors() {
+					setDiagEvalContext(budgetDiags, e, ctx)
+					return cty.DynamicVal, append(diags, budgetDiags...)
+				}
 				vals[k] = cty.TupleVal(gvs)
 			}
 		}
+		if budgetDiags := budget.CheckCollectionLength(len(vals), e.SrcRange); budgetDiags.HasErrors() {
+			setDiagEvalContext(budgetDiags, e, ctx)
+			return cty.DynamicVal, append(diags, budgetDiags...)
+		}
 
 		return cty.ObjectVal(vals).WithMarks(marks...), diags
 
@@ -1655,6 +1820,11 @@ func (e *ForExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 
 		known := true
 		for it.Next() {
+			if budgetDiags := budget.ConsumeForIteration(e.SrcRange); budgetDiags.HasErrors() {
+				setDiagEvalContext(budgetDiags, e, ctx)
+				return cty.DynamicVal, append(diags, budgetDiags...)
+			}
+
 			k, v := it.Element()
 			childCtx := ctx.NewChild()
 			childCtx.Variables = map[string]cty.Value{}
@@ -1727,6 +1897,10 @@ func (e *ForExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 		if !known {
 			return cty.DynamicVal.WithMarks(marks...), diags
 		}
+		if budgetDiags := budget.CheckCollectionLength(len(vals), e.SrcRange); budgetDiags.HasErrors() {
+			setDiagEvalContext(budgetDiags, e, ctx)
+			return cty.DynamicVal, append(diags, budgetDiags...)
+		}
 
 		return cty.TupleVal(vals).WithMarks(marks...), diags
 	}
@@ -1761,6 +1935,37 @@ func (e *ForExpr) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (e *ForExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	coll := rewriteExpr(r, e.CollExpr)
+
+	scopeNames := map[string]struct{}{}
+	if e.KeyVar != "" {
+		scopeNames[e.KeyVar] = struct{}{}
+	}
+	if e.ValVar != "" {
+		scopeNames[e.ValVar] = struct{}{}
+	}
+
+	var keyExpr, condExpr Expression
+	if e.KeyExpr != nil {
+		keyExpr = rewriteChildScope(r, scopeNames, e.KeyExpr)
+	}
+	valExpr := rewriteChildScope(r, scopeNames, e.ValExpr)
+	if e.CondExpr != nil {
+		condExpr = rewriteChildScope(r, scopeNames, e.CondExpr)
+	}
+
+	if coll == e.CollExpr && keyExpr == e.KeyExpr && valExpr == e.ValExpr && condExpr == e.CondExpr {
+		return e
+	}
+	ret := *e
+	ret.CollExpr = coll
+	ret.KeyExpr = keyExpr
+	ret.ValExpr = valExpr
+	ret.CondExpr = condExpr
+	return &ret
+}
+
 func (e *ForExpr) Range() hcl.Range {
 	return e.SrcRange
 }
@@ -1901,6 +2106,10 @@ func (e *SplatExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	// Unmark the collection, and save the marks to apply to the returned
 	// collection result
 	sourceVal, marks := sourceVal.Unmark()
+	if budgetDiags := ctx.ActiveBudget().CheckCollectionLength(sourceVal.LengthInt(), e.Range()); budgetDiags.HasErrors() {
+		setDiagEvalContext(budgetDiags, e, ctx)
+		return cty.DynamicVal, append(diags, budgetDiags...)
+	}
 	vals := make([]cty.Value, 0, sourceVal.LengthInt())
 	it := sourceVal.ElementIterator()
 	if ctx == nil {
@@ -1966,6 +2175,18 @@ func (e *SplatExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Each)
 }
 
+func (e *SplatExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	source := rewriteExpr(r, e.Source)
+	each := rewriteExpr(r, e.Each)
+	if source == e.Source && each == e.Each {
+		return e
+	}
+	ret := *e
+	ret.Source = source
+	ret.Each = each
+	return &ret
+}
+
 func (e *SplatExpr) Range() hcl.Range {
 	return e.SrcRange
 }
@@ -2044,6 +2265,10 @@ func (e *AnonSymbolExpr) walkChildNodes(w internalWalkFunc) {
 	// AnonSymbolExpr is a leaf node in the tree
 }
 
+func (e *AnonSymbolExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	return e
+}
+
 func (e *AnonSymbolExpr) Range() hcl.Range {
 	return e.SrcRange
 }
@@ -2068,6 +2293,10 @@ func (e *ExprSyntaxError) walkChildNodes(w internalWalkFunc) {
 	// ExprSyntaxError is a leaf node in the tree
 }
 
+func (e *ExprSyntaxError) rewriteChildNodes(r internalRewriteFunc) Node {
+	return e
+}
+
 func (e *ExprSyntaxError) Range() hcl.Range {
 	return e.SrcRange
 }
@@ -206,6 +206,18 @@ func (e *BinaryOpExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.RHS)
 }
 
+func (e *BinaryOpExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	lhs := rewriteExpr(r, e.LHS)
+	rhs := rewriteExpr(r, e.RHS)
+	if lhs == e.LHS && rhs == e.RHS {
+		return e
+	}
+	ret := *e
+	ret.LHS = lhs
+	ret.RHS = rhs
+	return &ret
+}
+
 func (e *BinaryOpExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	impl := e.Op.Impl // assumed to be a function taking exactly two arguments
 	params := impl.Params()
@@ -312,6 +324,16 @@ func (e *UnaryOpExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Val)
 }
 
+func (e *UnaryOpExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	val := rewriteExpr(r, e.Val)
+	if val == e.Val {
+		return e
+	}
+	ret := *e
+	ret.Val = val
+	return &ret
+}
+
 func (e *UnaryOpExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	impl := e.Op.Impl // assumed to be a function taking exactly one argument
 	params := impl.Params()
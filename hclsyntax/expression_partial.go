@@ -0,0 +1,409 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// PartialEvaluate evaluates as much of the given expression as possible
+// using the given context, and returns a residual expression that produces
+// the same result as the original when evaluated in a context that also
+// defines the remaining variables.
+//
+// Any subexpression whose result is wholly known is replaced by a
+// LiteralValueExpr. Variables and functions that are not defined in the
+// context at all are left in the residual expression, as are variables whose
+// values are not wholly known, so callers can use either approach to mark
+// the parts of the scope that are not yet available.
+//
+// In addition to folding subexpressions whose operands are all known,
+// PartialEvaluate simplifies:
+//
+//   - Conditional expressions whose condition is known, which are replaced by
+//     the selected result expression.
+//   - Logical operators where one known operand determines the result.
+//   - Templates, where adjacent known parts are combined into a single
+//     literal string.
+//
+// When a conditional expression is replaced by one of its results, the
+// residual expression no longer converts that result to a type it has in
+// common with the other result.
+//
+// The bodies of "for" expressions and splat expressions are evaluated only
+// if everything they refer to is defined in the context, and are otherwise
+// left as-is.
+//
+// The given expression is not modified, but the result may share unchanged
+// subtrees with it. Diagnostics are returned only for errors in the parts of
+// the expression that could be evaluated.
+func PartialEvaluate(expr Expression, ctx *hcl.EvalContext) (Expression, hcl.Diagnostics) {
+	switch e := expr.(type) {
+	case *LiteralValueExpr:
+		return e, nil
+
+	case *ScopeTraversalExpr:
+		root := e.Traversal.RootName()
+		if !partialVariableDefined(root, e.SrcRange, ctx) {
+			return e, nil
+		}
+		return partialFold(e, e, ctx)
+
+	case *RelativeTraversalExpr:
+		source, diags := PartialEvaluate(e.Source, ctx)
+		residual := &RelativeTraversalExpr{
+			Source:    source,
+			Traversal: e.Traversal,
+			SrcRange:  e.SrcRange,
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, source)
+
+	case *ParenthesesExpr:
+		inner, diags := PartialEvaluate(e.Expression, ctx)
+		if lit, ok := inner.(*LiteralValueExpr); ok {
+			return &LiteralValueExpr{Val: lit.Val, SrcRange: e.SrcRange}, diags
+		}
+		return &ParenthesesExpr{Expression: inner, SrcRange: e.SrcRange}, diags
+
+	case *FunctionCallExpr:
+		var diags hcl.Diagnostics
+		args := make([]Expression, len(e.Args))
+		for i, arg := range e.Args {
+			var argDiags hcl.Diagnostics
+			args[i], argDiags = PartialEvaluate(arg, ctx)
+			diags = append(diags, argDiags...)
+		}
+		residual := &FunctionCallExpr{
+			Name:            e.Name,
+			Args:            args,
+			ExpandFinal:     e.ExpandFinal,
+			NameRange:       e.NameRange,
+			OpenParenRange:  e.OpenParenRange,
+			CloseParenRange: e.CloseParenRange,
+		}
+		if !partialFunctionDefined(e.Name, e.NameRange, ctx) {
+			return residual, diags
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, args...)
+
+	case *ConditionalExpr:
+		cond, diags := PartialEvaluate(e.Condition, ctx)
+		trueResult, trueDiags := PartialEvaluate(e.TrueResult, ctx)
+		falseResult, falseDiags := PartialEvaluate(e.FalseResult, ctx)
+		residual := &ConditionalExpr{
+			Condition:   cond,
+			TrueResult:  trueResult,
+			FalseResult: falseResult,
+			SrcRange:    e.SrcRange,
+		}
+		if condVal, known := partialKnownBool(cond); known && !diags.HasErrors() {
+			selected, other, selectedDiags := falseResult, trueResult, falseDiags
+			if condVal {
+				selected, other, selectedDiags = trueResult, falseResult, trueDiags
+			}
+			diags = append(diags, selectedDiags...)
+			_, selectedKnown := selected.(*LiteralValueExpr)
+			_, otherKnown := other.(*LiteralValueExpr)
+			if !selectedKnown || !otherKnown {
+				return selected, diags
+			}
+			// If both results are known then we fold the whole expression
+			// so that the result is converted in the same way as
+			// ConditionalExpr.Value would.
+			return partialFoldIfLiteral(residual, diags, ctx)
+		}
+		diags = append(diags, trueDiags...)
+		diags = append(diags, falseDiags...)
+		return partialFoldIfLiteral(residual, diags, ctx, cond, trueResult, falseResult)
+
+	case *BinaryOpExpr:
+		lhs, diags := PartialEvaluate(e.LHS, ctx)
+		rhs, rhsDiags := PartialEvaluate(e.RHS, ctx)
+		diags = append(diags, rhsDiags...)
+		residual := &BinaryOpExpr{
+			LHS:      lhs,
+			Op:       e.Op,
+			RHS:      rhs,
+			SrcRange: e.SrcRange,
+		}
+		if e.Op == OpLogicalOr || e.Op == OpLogicalAnd {
+			// One known operand may be enough to determine the result of a
+			// logical operator, in which case the other is never used.
+			controlling := e.Op == OpLogicalOr
+			for _, operand := range []Expression{lhs, rhs} {
+				if val, known := partialKnownBool(operand); known && val == controlling {
+					return &LiteralValueExpr{Val: cty.BoolVal(val), SrcRange: e.SrcRange}, diags
+				}
+			}
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, lhs, rhs)
+
+	case *UnaryOpExpr:
+		val, diags := PartialEvaluate(e.Val, ctx)
+		residual := &UnaryOpExpr{
+			Op:          e.Op,
+			Val:         val,
+			SrcRange:    e.SrcRange,
+			SymbolRange: e.SymbolRange,
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, val)
+
+	case *IndexExpr:
+		coll, diags := PartialEvaluate(e.Collection, ctx)
+		key, keyDiags := PartialEvaluate(e.Key, ctx)
+		diags = append(diags, keyDiags...)
+		residual := &IndexExpr{
+			Collection:   coll,
+			Key:          key,
+			SrcRange:     e.SrcRange,
+			OpenRange:    e.OpenRange,
+			BracketRange: e.BracketRange,
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, coll, key)
+
+	case *TupleConsExpr:
+		var diags hcl.Diagnostics
+		exprs := make([]Expression, len(e.Exprs))
+		for i, elem := range e.Exprs {
+			var elemDiags hcl.Diagnostics
+			exprs[i], elemDiags = PartialEvaluate(elem, ctx)
+			diags = append(diags, elemDiags...)
+		}
+		residual := &TupleConsExpr{
+			Exprs:     exprs,
+			SrcRange:  e.SrcRange,
+			OpenRange: e.OpenRange,
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, exprs...)
+
+	case *ObjectConsExpr:
+		var diags hcl.Diagnostics
+		items := make([]ObjectConsItem, len(e.Items))
+		var parts []Expression
+		for i, item := range e.Items {
+			key, keyDiags := PartialEvaluate(item.KeyExpr, ctx)
+			diags = append(diags, keyDiags...)
+			val, valDiags := PartialEvaluate(item.ValueExpr, ctx)
+			diags = append(diags, valDiags...)
+			items[i] = ObjectConsItem{KeyExpr: key, ValueExpr: val}
+			if keyExpr, ok := key.(*ObjectConsKeyExpr); !ok || keyExpr.ForceNonLiteral || keyExpr.literalName() == "" {
+				parts = append(parts, key)
+			}
+			parts = append(parts, val)
+		}
+		residual := &ObjectConsExpr{
+			Items:     items,
+			SrcRange:  e.SrcRange,
+			OpenRange: e.OpenRange,
+		}
+		return partialFoldIfLiteral(residual, diags, ctx, parts...)
+
+	case *ObjectConsKeyExpr:
+		if !e.ForceNonLiteral {
+			// A naked identifier is a literal key already, and must be left
+			// as-is so that it isn't interpreted as a variable. A naked
+			// traversal with more than one step is an error that
+			// ObjectConsKeyExpr.Value will report.
+			if _, isTraversal := e.Wrapped.(*ScopeTraversalExpr); isTraversal || e.literalName() != "" {
+				return e, nil
+			}
+		}
+		wrapped, diags := PartialEvaluate(e.Wrapped, ctx)
+		if lit, ok := wrapped.(*LiteralValueExpr); ok {
+			return lit, diags
+		}
+		return &ObjectConsKeyExpr{
+			Wrapped:         wrapped,
+			ForceNonLiteral: e.ForceNonLiteral,
+		}, diags
+
+	case *TemplateExpr:
+		return partialTemplate(e, ctx)
+
+	case *TemplateWrapExpr:
+		wrapped, diags := PartialEvaluate(e.Wrapped, ctx)
+		if lit, ok := wrapped.(*LiteralValueExpr); ok {
+			return &LiteralValueExpr{Val: lit.Val, SrcRange: e.SrcRange}, diags
+		}
+		return &TemplateWrapExpr{Wrapped: wrapped, SrcRange: e.SrcRange}, diags
+
+	case *TemplateJoinExpr:
+		tuple, diags := PartialEvaluate(e.Tuple, ctx)
+		residual := &TemplateJoinExpr{Tuple: tuple}
+		return partialFoldIfLiteral(residual, diags, ctx, tuple)
+
+	case *ForExpr:
+		coll, diags := PartialEvaluate(e.CollExpr, ctx)
+		residual := &ForExpr{
+			KeyVar:     e.KeyVar,
+			ValVar:     e.ValVar,
+			CollExpr:   coll,
+			KeyExpr:    e.KeyExpr,
+			ValExpr:    e.ValExpr,
+			CondExpr:   e.CondExpr,
+			Group:      e.Group,
+			SrcRange:   e.SrcRange,
+			OpenRange:  e.OpenRange,
+			CloseRange: e.CloseRange,
+		}
+		if diags.HasErrors() || !partialIsClosed(residual, ctx) {
+			return residual, diags
+		}
+		return partialFold(residual, residual, ctx)
+
+	case *SplatExpr:
+		source, diags := PartialEvaluate(e.Source, ctx)
+		residual := &SplatExpr{
+			Source:      source,
+			Each:        e.Each,
+			Item:        e.Item,
+			SrcRange:    e.SrcRange,
+			MarkerRange: e.MarkerRange,
+		}
+		if diags.HasErrors() || !partialIsClosed(residual, ctx) {
+			return residual, diags
+		}
+		return partialFold(residual, residual, ctx)
+
+	default:
+		// AnonSymbolExpr is meaningful only inside a splat expression, and
+		// ExprSyntaxError can't be evaluated any further.
+		return expr, nil
+	}
+}
+
+// partialTemplate implements PartialEvaluate for TemplateExpr, combining
+// adjacent known parts into single literal strings.
+func partialTemplate(e *TemplateExpr, ctx *hcl.EvalContext) (Expression, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var parts []Expression
+	var pending *LiteralValueExpr // adjacent known parts combined so far
+
+	flush := func() {
+		if pending != nil && pending.Val.AsString() != "" {
+			parts = append(parts, pending)
+		}
+		pending = nil
+	}
+
+	for _, part := range e.Parts {
+		part, partDiags := PartialEvaluate(part, ctx)
+		diags = append(diags, partDiags...)
+
+		lit, ok := part.(*LiteralValueExpr)
+		if !ok {
+			flush()
+			parts = append(parts, part)
+			continue
+		}
+
+		// Marked values and values that can't be converted to strings are
+		// left for TemplateExpr.Value to deal with.
+		strVal, err := convert.Convert(lit.Val, cty.String)
+		if err != nil || strVal.IsNull() || strVal.IsMarked() {
+			flush()
+			parts = append(parts, part)
+			continue
+		}
+
+		if pending == nil {
+			pending = &LiteralValueExpr{Val: strVal, SrcRange: lit.SrcRange}
+			continue
+		}
+		pending = &LiteralValueExpr{
+			Val:      cty.StringVal(pending.Val.AsString() + strVal.AsString()),
+			SrcRange: hcl.RangeBetween(pending.SrcRange, lit.SrcRange),
+		}
+	}
+
+	if len(parts) == 0 {
+		// All of the parts were known, so the template is known too.
+		val := cty.StringVal("")
+		if pending != nil {
+			val = pending.Val
+		}
+		if diags.HasErrors() {
+			return e, diags
+		}
+		return &LiteralValueExpr{Val: val, SrcRange: e.SrcRange}, diags
+	}
+	flush()
+
+	residual := &TemplateExpr{
+		Parts:    parts,
+		SrcRange: e.SrcRange,
+	}
+	return partialFoldIfLiteral(residual, diags, ctx, parts...)
+}
+
+// partialKnownBool returns the value of the given expression if it is a
+// literal that converts to a known, non-null and unmarked bool.
+func partialKnownBool(expr Expression) (bool, bool) {
+	lit, ok := expr.(*LiteralValueExpr)
+	if !ok {
+		return false, false
+	}
+	val, err := convert.Convert(lit.Val, cty.Bool)
+	if err != nil || !val.IsKnown() || val.IsNull() || val.IsMarked() {
+		return false, false
+	}
+	return val.True(), true
+}
+
+// partialFoldIfLiteral folds the given residual expression into a literal
+// if all of the given operands are literals, or otherwise returns it as-is.
+func partialFoldIfLiteral(residual Expression, diags hcl.Diagnostics, ctx *hcl.EvalContext, operands ...Expression) (Expression, hcl.Diagnostics) {
+	if diags.HasErrors() {
+		return residual, diags
+	}
+	for _, operand := range operands {
+		if _, ok := operand.(*LiteralValueExpr); !ok {
+			return residual, diags
+		}
+	}
+	folded, foldDiags := partialFold(residual, residual, ctx)
+	return folded, append(diags, foldDiags...)
+}
+
+// partialFold evaluates the given expression and returns a literal of its
+// result if it is wholly known, or the given residual expression otherwise.
+func partialFold(expr Expression, residual Expression, ctx *hcl.EvalContext) (Expression, hcl.Diagnostics) {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		return residual, diags
+	}
+	return &LiteralValueExpr{Val: val, SrcRange: expr.Range()}, diags
+}
+
+// partialIsClosed returns true if all of the variables and functions that
+// the given expression refers to are defined in the given context.
+func partialIsClosed(expr Expression, ctx *hcl.EvalContext) bool {
+	for _, traversal := range Variables(expr) {
+		if !partialVariableDefined(traversal.RootName(), traversal.SourceRange(), ctx) {
+			return false
+		}
+	}
+	closed := true
+	VisitAll(expr, func(n Node) hcl.Diagnostics {
+		if call, ok := n.(*FunctionCallExpr); ok {
+			if !partialFunctionDefined(call.Name, call.NameRange, ctx) {
+				closed = false
+			}
+		}
+		return nil
+	})
+	return closed
+}
+
+func partialVariableDefined(name string, rng hcl.Range, ctx *hcl.EvalContext) bool {
+	_, found, _ := ctx.LookupVariable(name, rng)
+	return found
+}
+
+func partialFunctionDefined(name string, rng hcl.Range, ctx *hcl.EvalContext) bool {
+	_, found, _ := ctx.LookupFunction(name, rng)
+	return found
+}
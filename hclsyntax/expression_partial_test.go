@@ -0,0 +1,269 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestPartialEvaluate(t *testing.T) {
+	functions := map[string]function.Function{
+		"upper": stdlib.UpperFunc,
+	}
+	// partialCtx defines only some of the variables that fullCtx does, and
+	// has an unknown value for one of them.
+	partialCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"env":    cty.StringVal("prod"),
+				"region": cty.UnknownVal(cty.String),
+				"count":  cty.NumberIntVal(2),
+				"debug":  cty.False,
+				"names":  cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			}),
+		},
+		Functions: functions,
+	}
+	fullCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{
+				"env":    cty.StringVal("prod"),
+				"region": cty.StringVal("us-east-1"),
+				"count":  cty.NumberIntVal(2),
+				"debug":  cty.False,
+				"names":  cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			}),
+			"local": cty.ObjectVal(map[string]cty.Value{
+				"suffix": cty.StringVal("x"),
+				"flag":   cty.True,
+			}),
+		},
+		Functions: functions,
+	}
+
+	tests := []struct {
+		input string
+		// want is the value of the residual expression if it is expected to
+		// be a literal, or cty.NilVal otherwise.
+		want cty.Value
+		// wantVars are the root names of the variables that the residual
+		// expression is expected to refer to.
+		wantVars []string
+	}{
+		{
+			`var.count * 2 + 1`,
+			cty.NumberIntVal(5),
+			nil,
+		},
+		{
+			`upper(var.env)`,
+			cty.StringVal("PROD"),
+			nil,
+		},
+		{
+			`"${var.region}-bucket"`,
+			cty.NilVal,
+			[]string{"var"},
+		},
+		{
+			`"${var.env}-${local.suffix}"`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`var.debug ? local.suffix : var.env`,
+			cty.StringVal("prod"),
+			nil,
+		},
+		{
+			`!var.debug ? local.suffix : "y"`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`local.flag ? var.env : "y"`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`var.debug && local.flag`,
+			cty.False,
+			nil,
+		},
+		{
+			`local.flag || !var.debug`,
+			cty.True,
+			nil,
+		},
+		{
+			`local.flag && !var.debug`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`[var.env, local.suffix, var.count + 1]`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`{env = var.env, count = var.count}`,
+			cty.ObjectVal(map[string]cty.Value{
+				"env":   cty.StringVal("prod"),
+				"count": cty.NumberIntVal(2),
+			}),
+			nil,
+		},
+		{
+			`{(var.env) = local.suffix}`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`[for n in var.names : upper(n)]`,
+			cty.TupleVal([]cty.Value{cty.StringVal("A"), cty.StringVal("B")}),
+			nil,
+		},
+		{
+			`[for n in var.names : "${n}${local.suffix}"]`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`var.names[*]`,
+			cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			nil,
+		},
+		{
+			`upper(local.suffix)`,
+			cty.NilVal,
+			[]string{"local"},
+		},
+		{
+			`var.names[var.count - 1]`,
+			cty.StringVal("b"),
+			nil,
+		},
+		{
+			`"%{ for n in var.names }${n}%{ endfor }"`,
+			cty.StringVal("ab"),
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			expr, parseDiags := ParseExpression([]byte(test.input), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+			}
+
+			got, diags := PartialEvaluate(expr, partialCtx)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Error())
+			}
+
+			if lit, ok := got.(*LiteralValueExpr); ok {
+				if test.want == cty.NilVal {
+					t.Fatalf("unexpected literal result %#v", lit.Val)
+				}
+				if !lit.Val.RawEquals(test.want) {
+					t.Errorf("wrong result\ngot:  %#v\nwant: %#v", lit.Val, test.want)
+				}
+			} else if test.want != cty.NilVal {
+				t.Fatalf("result is %T, not a literal", got)
+			}
+
+			var gotVars []string
+			for _, traversal := range Variables(got) {
+				gotVars = append(gotVars, traversal.RootName())
+			}
+			if diff := cmp.Diff(test.wantVars, gotVars); diff != "" {
+				t.Errorf("wrong variables in residual expression\n%s", diff)
+			}
+
+			// The residual expression must produce the same result as the
+			// original once all of the variables are available.
+			wantVal, diags := expr.Value(fullCtx)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors evaluating original: %s", diags.Error())
+			}
+			gotVal, diags := got.Value(fullCtx)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors evaluating residual: %s", diags.Error())
+			}
+			if !gotVal.RawEquals(wantVal) {
+				t.Errorf("wrong residual result\ngot:  %#v\nwant: %#v", gotVal, wantVal)
+			}
+		})
+	}
+}
+
+func TestPartialEvaluateTemplate(t *testing.T) {
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"env":  cty.StringVal("prod"),
+			"name": cty.StringVal("app"),
+		},
+	}
+	expr, parseDiags := ParseTemplate([]byte(`${env}-${name}-${region}-bucket`), "", hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+	}
+
+	got, diags := PartialEvaluate(expr, ctx)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+
+	tmpl, ok := got.(*TemplateExpr)
+	if !ok {
+		t.Fatalf("result is %T, not *TemplateExpr", got)
+	}
+	if got, want := len(tmpl.Parts), 3; got != want {
+		t.Fatalf("wrong number of parts %d; want %d", got, want)
+	}
+	if lit, ok := tmpl.Parts[0].(*LiteralValueExpr); !ok || !lit.Val.RawEquals(cty.StringVal("prod-app-")) {
+		t.Errorf("wrong first part %#v", tmpl.Parts[0])
+	}
+	if _, ok := tmpl.Parts[1].(*ScopeTraversalExpr); !ok {
+		t.Errorf("wrong second part %#v", tmpl.Parts[1])
+	}
+	if lit, ok := tmpl.Parts[2].(*LiteralValueExpr); !ok || !lit.Val.RawEquals(cty.StringVal("-bucket")) {
+		t.Errorf("wrong third part %#v", tmpl.Parts[2])
+	}
+
+	wantRange := hcl.Range{
+		Start: hcl.Pos{Line: 1, Column: 3, Byte: 2},
+		End:   hcl.Pos{Line: 1, Column: 16, Byte: 15},
+	}
+	if got := tmpl.Parts[0].Range(); got != wantRange {
+		t.Errorf("wrong range for first part\ngot:  %#v\nwant: %#v", got, wantRange)
+	}
+}
+
+func TestPartialEvaluateErrors(t *testing.T) {
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"a": cty.StringVal("x"),
+		},
+	}
+	expr, parseDiags := ParseExpression([]byte(`[a + 1, b]`), "", hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+	}
+
+	_, diags := PartialEvaluate(expr, ctx)
+	if got, want := len(diags), 1; got != want {
+		t.Fatalf("wrong number of diagnostics %d; want %d", got, want)
+	}
+	if got, want := diags[0].Summary, "Invalid operand"; got != want {
+		t.Errorf("wrong summary %q; want %q", got, want)
+	}
+}
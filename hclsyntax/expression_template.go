@@ -24,10 +24,21 @@ func (e *TemplateExpr) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (e *TemplateExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	parts, changed := rewriteExprs(r, e.Parts)
+	if !changed {
+		return e
+	}
+	ret := *e
+	ret.Parts = parts
+	return &ret
+}
+
 func (e *TemplateExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	buf := &bytes.Buffer{}
 	var diags hcl.Diagnostics
 	isKnown := true
+	budget := ctx.ActiveBudget()
 
 	// Maintain a set of marks for values used in the template
 	marks := make(cty.ValueMarks)
@@ -85,7 +96,12 @@ func (e *TemplateExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics)
 		// then we'll skip appending so that "buf" will contain only the
 		// known prefix of the result.
 		if isKnown && !diags.HasErrors() {
-			buf.WriteString(strVal.AsString())
+			str := strVal.AsString()
+			if budgetDiags := budget.CheckTemplateLength(buf.Len()+len(str), e.SrcRange); budgetDiags.HasErrors() {
+				setDiagEvalContext(budgetDiags, e, ctx)
+				return cty.DynamicVal, append(diags, budgetDiags...)
+			}
+			buf.WriteString(str)
 		}
 	}
 
@@ -157,6 +173,16 @@ func (e *TemplateJoinExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Tuple)
 }
 
+func (e *TemplateJoinExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	tuple := rewriteExpr(r, e.Tuple)
+	if tuple == e.Tuple {
+		return e
+	}
+	return &TemplateJoinExpr{
+		Tuple: tuple,
+	}
+}
+
 func (e *TemplateJoinExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	tuple, diags := e.Tuple.Value(ctx)
 
@@ -247,6 +273,16 @@ func (e *TemplateWrapExpr) walkChildNodes(w internalWalkFunc) {
 	w(e.Wrapped)
 }
 
+func (e *TemplateWrapExpr) rewriteChildNodes(r internalRewriteFunc) Node {
+	wrapped := rewriteExpr(r, e.Wrapped)
+	if wrapped == e.Wrapped {
+		return e
+	}
+	ret := *e
+	ret.Wrapped = wrapped
+	return &ret
+}
+
 func (e *TemplateWrapExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	return e.Wrapped.Value(ctx)
 }
@@ -4,8 +4,10 @@
 package hclsyntax
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/hashicorp/hcl/v2"
@@ -2882,6 +2884,296 @@ func TestFunctionCallExprValue(t *testing.T) {
 	}
 }
 
+func TestExpressionEvalContextResolver(t *testing.T) {
+	resolver := &testEvalContextResolver{
+		vars: map[string]cty.Value{
+			"lazy": cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("lazy value"),
+			}),
+			"pending":  cty.UnknownVal(cty.String),
+			"shadowed": cty.StringVal("from resolver"),
+		},
+		funcs: map[string]function.Function{
+			"upper": stdlib.UpperFunc,
+		},
+	}
+	parent := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"shadowed": cty.StringVal("from map"),
+		},
+		Resolver: resolver,
+	}
+	child := parent.NewChild()
+	child.Variables = map[string]cty.Value{
+		"eager": cty.StringVal("eager value"),
+	}
+
+	tests := []struct {
+		input     string
+		want      cty.Value
+		diagCount int
+	}{
+		{
+			`lazy.name`,
+			cty.StringVal("lazy value"),
+			0,
+		},
+		{
+			`"${eager}/${lazy.name}"`,
+			cty.StringVal("eager value/lazy value"),
+			0,
+		},
+		{
+			`shadowed`,
+			cty.StringVal("from map"),
+			0,
+		},
+		{
+			`pending`,
+			cty.UnknownVal(cty.String),
+			0,
+		},
+		{
+			`upper(lazy.name)`,
+			cty.StringVal("LAZY VALUE"),
+			0,
+		},
+		{
+			`[for v in ["a"] : upper(v)]`,
+			cty.TupleVal([]cty.Value{cty.StringVal("A")}),
+			0,
+		},
+		{
+			`broken`,
+			cty.DynamicVal,
+			1, // the resolver's own error
+		},
+		{
+			`missing`,
+			cty.DynamicVal,
+			1, // Unknown variable
+		},
+		{
+			`missing()`,
+			cty.DynamicVal,
+			1, // Call to unknown function
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			expr, parseDiags := ParseExpression([]byte(test.input), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+			}
+
+			got, diags := expr.Value(child)
+			if len(diags) != test.diagCount {
+				t.Errorf("wrong number of diagnostics %d; want %d", len(diags), test.diagCount)
+				for _, diag := range diags {
+					t.Logf(" - %s", diag.Error())
+				}
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+
+	if _, called := resolver.varCalls["shadowed"]; called {
+		t.Errorf("resolver was consulted for a variable defined in the Variables map")
+	}
+	if _, called := resolver.varCalls["eager"]; called {
+		t.Errorf("resolver was consulted for a variable defined in a child context")
+	}
+}
+
+type testEvalContextResolver struct {
+	vars     map[string]cty.Value
+	funcs    map[string]function.Function
+	varCalls map[string]int
+}
+
+func (r *testEvalContextResolver) ResolveVariable(name string, rng hcl.Range) (cty.Value, bool, hcl.Diagnostics) {
+	if r.varCalls == nil {
+		r.varCalls = make(map[string]int)
+	}
+	r.varCalls[name]++
+
+	if name == "broken" {
+		return cty.DynamicVal, true, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Broken variable",
+				Detail:   "This variable can never be resolved.",
+				Subject:  rng.Ptr(),
+			},
+		}
+	}
+	val, ok := r.vars[name]
+	return val, ok, nil
+}
+
+func (r *testEvalContextResolver) ResolveFunction(name string, rng hcl.Range) (function.Function, bool, hcl.Diagnostics) {
+	f, ok := r.funcs[name]
+	return f, ok, nil
+}
+
+func TestExpressionEvalBudget(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := map[string]struct {
+		input     string
+		budget    *hcl.EvalBudget
+		want      cty.Value
+		wantLimit string
+	}{
+		"for within iteration limit": {
+			`[for x in [1, 2, 3] : [for y in [1, 2] : x * y]]`,
+			&hcl.EvalBudget{MaxForIterations: 9},
+			cty.TupleVal([]cty.Value{
+				cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+				cty.TupleVal([]cty.Value{cty.NumberIntVal(2), cty.NumberIntVal(4)}),
+				cty.TupleVal([]cty.Value{cty.NumberIntVal(3), cty.NumberIntVal(6)}),
+			}),
+			"",
+		},
+		"nested for exceeding iteration limit": {
+			`[for x in [1, 2, 3] : [for y in [1, 2] : x * y]]`,
+			&hcl.EvalBudget{MaxForIterations: 8},
+			// The outer expression completes its final iteration even
+			// though the inner one failed, as for any other error in
+			// the value expression.
+			cty.TupleVal([]cty.Value{
+				cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+				cty.TupleVal([]cty.Value{cty.NumberIntVal(2), cty.NumberIntVal(4)}),
+				cty.DynamicVal,
+			}),
+			hcl.EvalBudgetLimitForIterations,
+		},
+		"template within length limit": {
+			`"${a}-${a}"`,
+			&hcl.EvalBudget{MaxTemplateLength: 7},
+			cty.StringVal("foo-foo"),
+			"",
+		},
+		"template exceeding length limit": {
+			`"${a}-${a}"`,
+			&hcl.EvalBudget{MaxTemplateLength: 6},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitTemplateLength,
+		},
+		"tuple within collection limit": {
+			`[1, 2]`,
+			&hcl.EvalBudget{MaxCollectionLength: 2},
+			cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+			"",
+		},
+		"tuple exceeding collection limit": {
+			`[1, 2, 3]`,
+			&hcl.EvalBudget{MaxCollectionLength: 2},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitCollectionLength,
+		},
+		"object exceeding collection limit": {
+			`{a = 1, b = 2, c = 3}`,
+			&hcl.EvalBudget{MaxCollectionLength: 2},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitCollectionLength,
+		},
+		"for result exceeding collection limit": {
+			`{for k, v in obj : k => v}`,
+			&hcl.EvalBudget{MaxCollectionLength: 2},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitCollectionLength,
+		},
+		"function result exceeding collection limit": {
+			`concat([1, 2], [3])`,
+			&hcl.EvalBudget{MaxCollectionLength: 2},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitCollectionLength,
+		},
+		"splat exceeding collection limit": {
+			`objs[*].a`,
+			&hcl.EvalBudget{MaxCollectionLength: 2},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitCollectionLength,
+		},
+		"deadline passed": {
+			`[for x in [1] : x]`,
+			&hcl.EvalBudget{Deadline: time.Now().Add(-time.Second)},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitDeadline,
+		},
+		"context cancelled": {
+			`concat([1], [2])`,
+			&hcl.EvalBudget{Context: cancelled},
+			cty.DynamicVal,
+			hcl.EvalBudgetLimitDeadline,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, parseDiags := ParseExpression([]byte(test.input), "", hcl.InitialPos)
+			if parseDiags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+			}
+
+			// The budget is set on a parent context to make sure that it
+			// applies to evaluation in descendant contexts too.
+			ctx := &hcl.EvalContext{
+				Budget: test.budget,
+			}
+			ctx = ctx.NewChild()
+			// Collections that would exceed the tests' collection limits
+			// come from variables, since constructing them in the input
+			// would exceed the limit before the expression under test.
+			ctx.Variables = map[string]cty.Value{
+				"a": cty.StringVal("foo"),
+				"obj": cty.ObjectVal(map[string]cty.Value{
+					"a": cty.NumberIntVal(1),
+					"b": cty.NumberIntVal(2),
+					"c": cty.NumberIntVal(3),
+				}),
+				"objs": cty.TupleVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{"a": cty.NumberIntVal(1)}),
+					cty.ObjectVal(map[string]cty.Value{"a": cty.NumberIntVal(2)}),
+					cty.ObjectVal(map[string]cty.Value{"a": cty.NumberIntVal(3)}),
+				}),
+			}
+			ctx.Functions = map[string]function.Function{
+				"concat": stdlib.ConcatFunc,
+			}
+
+			got, diags := expr.Value(ctx)
+			if test.wantLimit == "" {
+				if len(diags) != 0 {
+					t.Fatalf("unexpected diagnostics: %s", diags.Error())
+				}
+			} else {
+				if len(diags) != 1 {
+					t.Fatalf("wrong number of diagnostics %d; want 1\n%s", len(diags), diags.Error())
+				}
+				extra, ok := hcl.DiagnosticExtra[hcl.EvalBudgetDiagExtra](diags[0])
+				if !ok {
+					t.Fatalf("diagnostic has no EvalBudgetDiagExtra: %s", diags[0].Error())
+				}
+				if got, want := extra.EvalBudgetLimit(), test.wantLimit; got != want {
+					t.Errorf("wrong limit %q; want %q", got, want)
+				}
+				if diags[0].Expression == nil || diags[0].EvalContext == nil {
+					t.Errorf("diagnostic is missing its expression and context")
+				}
+			}
+			if !got.RawEquals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
+
 func TestExpressionAsTraversal(t *testing.T) {
 	expr, _ := ParseExpression([]byte("a.b[0][\"c\"]"), "", hcl.Pos{})
 	traversal, diags := hcl.AbsTraversalForExpr(expr)
@@ -0,0 +1,884 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// A TypeContext provides the types of the variables and the functions that
+// should be used to infer the type of an expression with InferType.
+//
+// It is the static analog of hcl.EvalContext: where an EvalContext has a
+// value for each variable, a TypeContext has only its type.
+type TypeContext struct {
+	Variables map[string]cty.Type
+	Functions map[string]function.Function
+	parent    *TypeContext
+}
+
+// NewChild returns a new TypeContext that is a child of the receiver.
+func (ctx *TypeContext) NewChild() *TypeContext {
+	return &TypeContext{parent: ctx}
+}
+
+// Parent returns the parent of the receiver, or nil if the receiver has
+// no parent.
+func (ctx *TypeContext) Parent() *TypeContext {
+	return ctx.parent
+}
+
+// InferType returns the type of the value that the given expression would
+// produce if evaluated with variables of the types given in the context,
+// without needing any of the variables' values.
+//
+// Unlike evaluating the expression with unknown values, InferType checks
+// all of the nested expressions regardless of whether they would be
+// evaluated for particular values, so for example it reports type errors in
+// both result expressions of a conditional expression.
+//
+// Some results depend on values rather than types, and in those cases the
+// result is cty.DynamicPseudoType. This includes object constructors whose
+// keys are not constant, "for" expressions other than those that iterate
+// over a tuple without an "if" clause to produce another tuple, and calls to
+// functions whose return type depends on their argument values.
+//
+// Functions are called only to determine their return types. Their
+// implementations are never called.
+func InferType(expr Expression, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	tc := &typeChecker{
+		anonTypes: make(map[*AnonSymbolExpr]cty.Type),
+	}
+	return tc.exprType(expr, ctx)
+}
+
+type typeChecker struct {
+	// anonTypes tracks the types of the AnonSymbolExpr nodes of any splat
+	// expressions that are currently being checked.
+	anonTypes map[*AnonSymbolExpr]cty.Type
+}
+
+func (tc *typeChecker) exprType(expr Expression, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	switch e := expr.(type) {
+	case *LiteralValueExpr:
+		return e.Val.Type(), nil
+	case *ParenthesesExpr:
+		return tc.exprType(e.Expression, ctx)
+	case *ScopeTraversalExpr:
+		return tc.scopeTraversalType(e, ctx)
+	case *RelativeTraversalExpr:
+		srcTy, diags := tc.exprType(e.Source, ctx)
+		ty, travDiags := traversalType(srcTy, e.Traversal)
+		setDiagExpression(travDiags, e)
+		return ty, append(diags, travDiags...)
+	case *FunctionCallExpr:
+		return tc.functionCallType(e, ctx)
+	case *ConditionalExpr:
+		return tc.conditionalType(e, ctx)
+	case *IndexExpr:
+		return tc.indexType(e, ctx)
+	case *TupleConsExpr:
+		var diags hcl.Diagnostics
+		etys := make([]cty.Type, len(e.Exprs))
+		for i, elemExpr := range e.Exprs {
+			var elemDiags hcl.Diagnostics
+			etys[i], elemDiags = tc.exprType(elemExpr, ctx)
+			diags = append(diags, elemDiags...)
+		}
+		return cty.Tuple(etys), diags
+	case *ObjectConsExpr:
+		return tc.objectConsType(e, ctx)
+	case *ObjectConsKeyExpr:
+		if !e.ForceNonLiteral {
+			if travExpr, isTraversal := e.Wrapped.(*ScopeTraversalExpr); isTraversal && len(travExpr.Traversal) > 1 {
+				// Value will return an error for this case, so we'll just
+				// make the same check here.
+				_, diags := e.Value(nil)
+				return cty.DynamicPseudoType, diags
+			}
+			if e.literalName() != "" {
+				return cty.String, nil
+			}
+		}
+		return tc.exprType(e.Wrapped, ctx)
+	case *ForExpr:
+		return tc.forType(e, ctx)
+	case *SplatExpr:
+		return tc.splatType(e, ctx)
+	case *AnonSymbolExpr:
+		if ty, ok := tc.anonTypes[e]; ok {
+			return ty, nil
+		}
+		return cty.DynamicPseudoType, nil
+	case *BinaryOpExpr:
+		return tc.binaryOpType(e, ctx)
+	case *UnaryOpExpr:
+		return tc.unaryOpType(e, ctx)
+	case *TemplateExpr:
+		return tc.templateType(e, ctx)
+	case *TemplateWrapExpr:
+		return tc.exprType(e.Wrapped, ctx)
+	case *TemplateJoinExpr:
+		return tc.templateJoinType(e, ctx)
+	case *ExprSyntaxError:
+		return e.Placeholder.Type(), e.ParseDiags
+	default:
+		// Should never happen, because the above should cover all of
+		// the expression types in this package.
+		panic(fmt.Sprintf("InferType doesn't support %T", expr))
+	}
+}
+
+func (tc *typeChecker) scopeTraversalType(e *ScopeTraversalExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	split := e.Traversal.SimpleSplit()
+	root := split.Abs[0].(hcl.TraverseRoot)
+	name := root.Name
+
+	hasNonNil := false
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		if thisCtx.Variables == nil {
+			continue
+		}
+		hasNonNil = true
+		if ty, exists := thisCtx.Variables[name]; exists {
+			ty, diags := traversalType(ty, split.Rel)
+			setDiagExpression(diags, e)
+			return ty, diags
+		}
+	}
+
+	if !hasNonNil {
+		return cty.DynamicPseudoType, hcl.Diagnostics{
+			{
+				Severity:   hcl.DiagError,
+				Summary:    "Variables not allowed",
+				Detail:     "Variables may not be used here.",
+				Subject:    &root.SrcRange,
+				Expression: e,
+			},
+		}
+	}
+
+	var suggestions []string
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		for k := range thisCtx.Variables {
+			suggestions = append(suggestions, k)
+		}
+	}
+	sort.Strings(suggestions)
+	suggestion := nameSuggestion(name, suggestions)
+	if suggestion != "" {
+		suggestion = fmt.Sprintf(" Did you mean %q?", suggestion)
+	}
+
+	return cty.DynamicPseudoType, hcl.Diagnostics{
+		{
+			Severity:   hcl.DiagError,
+			Summary:    "Unknown variable",
+			Detail:     fmt.Sprintf("There is no variable named %q.%s", name, suggestion),
+			Subject:    &root.SrcRange,
+			Expression: e,
+		},
+	}
+}
+
+func (tc *typeChecker) functionCallType(e *FunctionCallExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	var f function.Function
+	exists := false
+	hasNonNilMap := false
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		if thisCtx.Functions == nil {
+			continue
+		}
+		hasNonNilMap = true
+		if f, exists = thisCtx.Functions[e.Name]; exists {
+			break
+		}
+	}
+
+	// We check the argument types even if we can't find the function,
+	// because they may contain errors of their own.
+	argTys := make([]cty.Type, len(e.Args))
+	for i, arg := range e.Args {
+		var argDiags hcl.Diagnostics
+		argTys[i], argDiags = tc.exprType(arg, ctx)
+		diags = append(diags, argDiags...)
+	}
+
+	if !exists {
+		if !hasNonNilMap {
+			return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+				Severity:   hcl.DiagError,
+				Summary:    "Function calls not allowed",
+				Detail:     "Functions may not be called here.",
+				Subject:    e.Range().Ptr(),
+				Expression: e,
+			})
+		}
+
+		var avail []string
+		for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+			for name := range thisCtx.Functions {
+				avail = append(avail, name)
+			}
+		}
+		sort.Strings(avail)
+		suggestion := nameSuggestion(e.Name, avail)
+		if suggestion != "" {
+			suggestion = fmt.Sprintf(" Did you mean %q?", suggestion)
+		}
+		return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+			Severity:   hcl.DiagError,
+			Summary:    "Call to unknown function",
+			Detail:     fmt.Sprintf("There is no function named %q.%s", e.Name, suggestion),
+			Subject:    &e.NameRange,
+			Context:    e.Range().Ptr(),
+			Expression: e,
+		})
+	}
+
+	params := f.Params()
+	varParam := f.VarParam()
+	args := e.Args
+
+	// If the final argument is expanded and we can't tell how many elements
+	// it has then we can check the element type against the remaining
+	// parameters but we can't determine the return type.
+	expandedUnknownLength := false
+	if e.ExpandFinal {
+		if len(args) < 1 {
+			// should never happen if the parser is behaving
+			panic("ExpandFinal set on function call with no arguments")
+		}
+		lastArg := args[len(args)-1]
+		lastTy := argTys[len(argTys)-1]
+		args = args[:len(args)-1]
+		argTys = argTys[:len(argTys)-1]
+
+		switch {
+		case lastTy == cty.DynamicPseudoType:
+			expandedUnknownLength = true
+		case lastTy.IsTupleType():
+			for _, ety := range lastTy.TupleElementTypes() {
+				args = append(args, lastArg)
+				argTys = append(argTys, ety)
+			}
+		case lastTy.IsListType() || lastTy.IsSetType():
+			expandedUnknownLength = true
+			ety := lastTy.ElementType()
+			var param *function.Parameter
+			if len(args) < len(params) {
+				param = &params[len(args)]
+			} else {
+				param = varParam
+			}
+			if param != nil {
+				if err := typeConversionError(ety, param.Type); err != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Invalid function argument",
+						Detail: fmt.Sprintf(
+							"Invalid value for %q parameter: %s.",
+							param.Name, err,
+						),
+						Subject:    lastArg.StartRange().Ptr(),
+						Context:    e.Range().Ptr(),
+						Expression: lastArg,
+					})
+				}
+			}
+		default:
+			return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+				Severity:   hcl.DiagError,
+				Summary:    "Invalid expanding argument value",
+				Detail:     "The expanding argument (indicated by ...) must be of a tuple, list, or set type.",
+				Subject:    lastArg.Range().Ptr(),
+				Context:    e.Range().Ptr(),
+				Expression: lastArg,
+			})
+		}
+	}
+
+	if !expandedUnknownLength && len(args) < len(params) {
+		missing := params[len(args)]
+		qual := ""
+		if varParam != nil {
+			qual = " at least"
+		}
+		return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Not enough function arguments",
+			Detail: fmt.Sprintf(
+				"Function %q expects%s %d argument(s). Missing value for %q.",
+				e.Name, qual, len(params), missing.Name,
+			),
+			Subject:    &e.CloseParenRange,
+			Context:    e.Range().Ptr(),
+			Expression: e,
+		})
+	}
+
+	if varParam == nil && len(args) > len(params) {
+		return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Too many function arguments",
+			Detail: fmt.Sprintf(
+				"Function %q expects only %d argument(s).",
+				e.Name, len(params),
+			),
+			Subject:    args[len(params)].StartRange().Ptr(),
+			Context:    e.Range().Ptr(),
+			Expression: e,
+		})
+	}
+
+	for i, argExpr := range args {
+		var param *function.Parameter
+		if i < len(params) {
+			param = &params[i]
+		} else {
+			param = varParam
+		}
+		// Function implementations expect arguments that have already
+		// been converted to their parameter types, as in
+		// FunctionCallExpr.Value, so we'll ask for the return type using
+		// the converted types.
+		converted, err := convert.Convert(cty.UnknownVal(argTys[i]), param.Type)
+		if err == nil {
+			argTys[i] = converted.Type()
+		} else {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid function argument",
+				Detail: fmt.Sprintf(
+					"Invalid value for %q parameter: %s.",
+					param.Name, err,
+				),
+				Subject:    argExpr.StartRange().Ptr(),
+				Context:    e.Range().Ptr(),
+				Expression: argExpr,
+			})
+		}
+	}
+
+	if diags.HasErrors() || expandedUnknownLength {
+		return cty.DynamicPseudoType, diags
+	}
+
+	retTy, err := f.ReturnType(argTys)
+	if err != nil {
+		if argErr, ok := err.(function.ArgError); ok && argErr.Index < len(args) {
+			paramName := "argument"
+			if argErr.Index < len(params) {
+				paramName = params[argErr.Index].Name
+			} else if varParam != nil {
+				paramName = varParam.Name
+			}
+			argExpr := args[argErr.Index]
+			return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid function argument",
+				Detail: fmt.Sprintf(
+					"Invalid value for %q parameter: %s.",
+					paramName, err,
+				),
+				Subject:    argExpr.StartRange().Ptr(),
+				Context:    e.Range().Ptr(),
+				Expression: argExpr,
+			})
+		}
+		return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Error in function call",
+			Detail: fmt.Sprintf(
+				"Call to function %q failed: %s.",
+				e.Name, err,
+			),
+			Subject:    e.StartRange().Ptr(),
+			Context:    e.Range().Ptr(),
+			Expression: e,
+		})
+	}
+	return retTy, diags
+}
+
+func (tc *typeChecker) conditionalType(e *ConditionalExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	condTy, condDiags := tc.exprType(e.Condition, ctx)
+	diags = append(diags, condDiags...)
+	if err := typeConversionError(condTy, cty.Bool); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity:   hcl.DiagError,
+			Summary:    "Incorrect condition type",
+			Detail:     fmt.Sprintf("The condition expression must be of type bool: %s.", err),
+			Subject:    e.Condition.Range().Ptr(),
+			Context:    &e.SrcRange,
+			Expression: e.Condition,
+		})
+	}
+
+	trueTy, trueDiags := tc.exprType(e.TrueResult, ctx)
+	diags = append(diags, trueDiags...)
+	falseTy, falseDiags := tc.exprType(e.FalseResult, ctx)
+	diags = append(diags, falseDiags...)
+
+	var resultTy cty.Type
+	switch {
+	// As in ConditionalExpr.Value, a literal null in either result
+	// can convert to whatever type the other result has.
+	case isLiteralNull(e.TrueResult):
+		resultTy = falseTy
+	case isLiteralNull(e.FalseResult):
+		resultTy = trueTy
+	case trueTy == cty.DynamicPseudoType, falseTy == cty.DynamicPseudoType:
+		resultTy = cty.DynamicPseudoType
+	default:
+		resultTy, _ = convert.UnifyUnsafe([]cty.Type{trueTy, falseTy})
+	}
+
+	if resultTy == cty.NilType {
+		return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Inconsistent conditional result types",
+			Detail: fmt.Sprintf(
+				"The true and false result expressions must have consistent types. %s.",
+				describeConditionalTypeMismatch(trueTy, falseTy),
+			),
+			Subject:    hcl.RangeBetween(e.TrueResult.Range(), e.FalseResult.Range()).Ptr(),
+			Context:    &e.SrcRange,
+			Expression: e,
+		})
+	}
+	return resultTy, diags
+}
+
+func (tc *typeChecker) indexType(e *IndexExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	collTy, collDiags := tc.exprType(e.Collection, ctx)
+	diags = append(diags, collDiags...)
+	keyTy, keyDiags := tc.exprType(e.Key, ctx)
+	diags = append(diags, keyDiags...)
+
+	key, ok := staticExprValue(e.Key)
+	if !ok {
+		key = cty.UnknownVal(keyTy)
+	}
+	val, indexDiags := hcl.Index(typePlaceholderVal(collTy), key, &e.BracketRange)
+	setDiagExpression(indexDiags, e)
+	return val.Type(), append(diags, indexDiags...)
+}
+
+func (tc *typeChecker) objectConsType(e *ObjectConsExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	known := true
+	atys := make(map[string]cty.Type, len(e.Items))
+
+	for _, item := range e.Items {
+		keyTy, keyDiags := tc.exprType(item.KeyExpr, ctx)
+		diags = append(diags, keyDiags...)
+		valTy, valDiags := tc.exprType(item.ValueExpr, ctx)
+		diags = append(diags, valDiags...)
+
+		if keyDiags.HasErrors() {
+			known = false
+			continue
+		}
+		if err := typeConversionError(keyTy, cty.String); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity:   hcl.DiagError,
+				Summary:    "Incorrect key type",
+				Detail:     fmt.Sprintf("Can't use this value as a key: %s.", err),
+				Subject:    item.KeyExpr.Range().Ptr(),
+				Expression: item.KeyExpr,
+			})
+			known = false
+			continue
+		}
+
+		key, ok := staticObjectKey(item.KeyExpr)
+		if !ok {
+			known = false
+			continue
+		}
+		atys[key] = valTy
+	}
+
+	if !known {
+		return cty.DynamicPseudoType, diags
+	}
+	return cty.Object(atys), diags
+}
+
+func (tc *typeChecker) forType(e *ForExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	collTy, collDiags := tc.exprType(e.CollExpr, ctx)
+	diags = append(diags, collDiags...)
+
+	var keyTy cty.Type
+	var valTys []cty.Type // one per element if the length is known
+	var valTy cty.Type    // the type of any element
+	switch {
+	case collTy == cty.DynamicPseudoType:
+		keyTy, valTy = cty.DynamicPseudoType, cty.DynamicPseudoType
+	case collTy.IsListType():
+		keyTy, valTy = cty.Number, collTy.ElementType()
+	case collTy.IsSetType():
+		keyTy, valTy = collTy.ElementType(), collTy.ElementType()
+	case collTy.IsMapType():
+		keyTy, valTy = cty.String, collTy.ElementType()
+	case collTy.IsTupleType():
+		keyTy = cty.Number
+		valTys = collTy.TupleElementTypes()
+		valTy = unifyOrDynamic(valTys)
+	case collTy.IsObjectType():
+		keyTy = cty.String
+		atys := collTy.AttributeTypes()
+		names := make([]string, 0, len(atys))
+		for name := range atys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		attrTys := make([]cty.Type, len(names))
+		for i, name := range names {
+			attrTys[i] = atys[name]
+		}
+		valTy = unifyOrDynamic(attrTys)
+	default:
+		return cty.DynamicPseudoType, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Iteration over non-iterable value",
+			Detail: fmt.Sprintf(
+				"A value of type %s cannot be used as the collection in a 'for' expression.",
+				collTy.FriendlyName(),
+			),
+			Subject:    e.CollExpr.Range().Ptr(),
+			Context:    &e.SrcRange,
+			Expression: e.CollExpr,
+		})
+	}
+
+	childCtx := func(valTy cty.Type) *TypeContext {
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Type{}
+		if e.KeyVar != "" {
+			child.Variables[e.KeyVar] = keyTy
+		}
+		child.Variables[e.ValVar] = valTy
+		return child
+	}
+	anyCtx := childCtx(valTy)
+
+	if e.CondExpr != nil {
+		condTy, condDiags := tc.exprType(e.CondExpr, anyCtx)
+		diags = append(diags, condDiags...)
+		if err := typeConversionError(condTy, cty.Bool); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity:   hcl.DiagError,
+				Summary:    "Invalid 'for' condition",
+				Detail:     fmt.Sprintf("The 'if' clause value is invalid: %s.", err),
+				Subject:    e.CondExpr.Range().Ptr(),
+				Context:    &e.SrcRange,
+				Expression: e.CondExpr,
+			})
+		}
+	}
+
+	if e.KeyExpr != nil {
+		keyExprTy, keyDiags := tc.exprType(e.KeyExpr, anyCtx)
+		diags = append(diags, keyDiags...)
+		if err := typeConversionError(keyExprTy, cty.String); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity:   hcl.DiagError,
+				Summary:    "Invalid object key",
+				Detail:     fmt.Sprintf("The key expression produced an invalid result: %s.", err),
+				Subject:    e.KeyExpr.Range().Ptr(),
+				Context:    &e.SrcRange,
+				Expression: e.KeyExpr,
+			})
+		}
+		_, valDiags := tc.exprType(e.ValExpr, anyCtx)
+		diags = append(diags, valDiags...)
+
+		// The attribute names of the resulting object depend on the
+		// values of the keys, so we can't know the result type.
+		return cty.DynamicPseudoType, diags
+	}
+
+	if valTys != nil && e.CondExpr == nil {
+		// When iterating over a tuple without a condition we know exactly
+		// how many elements the result will have, and so we can check
+		// each element separately to produce an exact tuple type.
+		resultTys := make([]cty.Type, len(valTys))
+		for i, ty := range valTys {
+			var valDiags hcl.Diagnostics
+			resultTys[i], valDiags = tc.exprType(e.ValExpr, childCtx(ty))
+			diags = append(diags, valDiags...)
+		}
+		return cty.Tuple(resultTys), diags
+	}
+
+	_, valDiags := tc.exprType(e.ValExpr, anyCtx)
+	diags = append(diags, valDiags...)
+	return cty.DynamicPseudoType, diags
+}
+
+func (tc *typeChecker) splatType(e *SplatExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	sourceTy, diags := tc.exprType(e.Source, ctx)
+
+	eachType := func(ty cty.Type) (cty.Type, hcl.Diagnostics) {
+		tc.anonTypes[e.Item] = ty
+		defer delete(tc.anonTypes, e.Item)
+		return tc.exprType(e.Each, ctx)
+	}
+
+	switch {
+	case sourceTy == cty.DynamicPseudoType:
+		_, eachDiags := eachType(cty.DynamicPseudoType)
+		return cty.DynamicPseudoType, append(diags, eachDiags...)
+	case sourceTy.IsListType() || sourceTy.IsSetType():
+		ety, eachDiags := eachType(sourceTy.ElementType())
+		return cty.List(ety), append(diags, eachDiags...)
+	case sourceTy.IsTupleType():
+		etys := sourceTy.TupleElementTypes()
+		resultTys := make([]cty.Type, len(etys))
+		for i, ety := range etys {
+			var eachDiags hcl.Diagnostics
+			resultTys[i], eachDiags = eachType(ety)
+			diags = append(diags, eachDiags...)
+		}
+		return cty.Tuple(resultTys), diags
+	default:
+		// Any other value is treated as a single-element tuple. (A null
+		// value would produce an empty tuple instead, but we can't know
+		// that from the type alone.)
+		ety, eachDiags := eachType(sourceTy)
+		return cty.Tuple([]cty.Type{ety}), append(diags, eachDiags...)
+	}
+}
+
+func (tc *typeChecker) binaryOpType(e *BinaryOpExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	params := e.Op.Impl.Params()
+
+	lhsTy, lhsDiags := tc.exprType(e.LHS, ctx)
+	diags = append(diags, lhsDiags...)
+	if err := typeConversionError(lhsTy, params[0].Type); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity:   hcl.DiagError,
+			Summary:    "Invalid operand",
+			Detail:     fmt.Sprintf("Unsuitable value for left operand: %s.", err),
+			Subject:    e.LHS.Range().Ptr(),
+			Context:    &e.SrcRange,
+			Expression: e.LHS,
+		})
+	}
+
+	rhsTy, rhsDiags := tc.exprType(e.RHS, ctx)
+	diags = append(diags, rhsDiags...)
+	if err := typeConversionError(rhsTy, params[1].Type); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity:   hcl.DiagError,
+			Summary:    "Invalid operand",
+			Detail:     fmt.Sprintf("Unsuitable value for right operand: %s.", err),
+			Subject:    e.RHS.Range().Ptr(),
+			Context:    &e.SrcRange,
+			Expression: e.RHS,
+		})
+	}
+
+	return e.Op.Type, diags
+}
+
+func (tc *typeChecker) unaryOpType(e *UnaryOpExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	param := e.Op.Impl.Params()[0]
+
+	ty, diags := tc.exprType(e.Val, ctx)
+	if err := typeConversionError(ty, param.Type); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity:   hcl.DiagError,
+			Summary:    "Invalid operand",
+			Detail:     fmt.Sprintf("Unsuitable value for unary operand: %s.", err),
+			Subject:    e.Val.Range().Ptr(),
+			Context:    &e.SrcRange,
+			Expression: e.Val,
+		})
+	}
+
+	return e.Op.Type, diags
+}
+
+func (tc *typeChecker) templateType(e *TemplateExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	for _, part := range e.Parts {
+		partTy, partDiags := tc.exprType(part, ctx)
+		diags = append(diags, partDiags...)
+		if err := typeConversionError(partTy, cty.String); err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid template interpolation value",
+				Detail: fmt.Sprintf(
+					"Cannot include the given value in a string template: %s.",
+					err,
+				),
+				Subject:    part.Range().Ptr(),
+				Context:    &e.SrcRange,
+				Expression: part,
+			})
+		}
+	}
+	return cty.String, diags
+}
+
+func (tc *typeChecker) templateJoinType(e *TemplateJoinExpr, ctx *TypeContext) (cty.Type, hcl.Diagnostics) {
+	tupleTy, diags := tc.exprType(e.Tuple, ctx)
+	if tupleTy.IsTupleType() {
+		for _, ety := range tupleTy.TupleElementTypes() {
+			if err := typeConversionError(ety, cty.String); err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid template interpolation value",
+					Detail: fmt.Sprintf(
+						"Cannot include one of the interpolation results into the string template: %s.",
+						err,
+					),
+					Subject:    e.Range().Ptr(),
+					Expression: e,
+				})
+				break
+			}
+		}
+	}
+	return cty.String, diags
+}
+
+// traversalType returns the type of the result of applying the given
+// relative traversal to a value of the given type.
+func traversalType(ty cty.Type, traversal hcl.Traversal) (cty.Type, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	for _, step := range traversal {
+		val, stepDiags := step.TraversalStep(typePlaceholderVal(ty))
+		diags = append(diags, stepDiags...)
+		if stepDiags.HasErrors() {
+			return cty.DynamicPseudoType, diags
+		}
+		ty = val.Type()
+	}
+	return ty, diags
+}
+
+// typePlaceholderVal returns a non-null value of the given type which is
+// known at its top level if the type is a structural type, so that
+// operations on it can take into account the number of elements of a tuple
+// and the attribute names of an object.
+func typePlaceholderVal(ty cty.Type) cty.Value {
+	switch {
+	case ty.IsTupleType():
+		etys := ty.TupleElementTypes()
+		if len(etys) == 0 {
+			return cty.EmptyTupleVal
+		}
+		vals := make([]cty.Value, len(etys))
+		for i, ety := range etys {
+			vals[i] = cty.UnknownVal(ety)
+		}
+		return cty.TupleVal(vals)
+	case ty.IsObjectType():
+		atys := ty.AttributeTypes()
+		if len(atys) == 0 {
+			return cty.EmptyObjectVal
+		}
+		vals := make(map[string]cty.Value, len(atys))
+		for name, aty := range atys {
+			vals[name] = cty.UnknownVal(aty)
+		}
+		return cty.ObjectVal(vals)
+	default:
+		return cty.UnknownVal(ty).RefineNotNull()
+	}
+}
+
+// typeConversionError returns an error describing why a value of the first
+// type cannot be converted to the second, or nil if conversion is possible
+// for at least some values.
+func typeConversionError(from, to cty.Type) error {
+	_, err := convert.Convert(cty.UnknownVal(from), to)
+	return err
+}
+
+// unifyOrDynamic returns a type that all of the given types can convert to,
+// or cty.DynamicPseudoType if there is no such type.
+func unifyOrDynamic(tys []cty.Type) cty.Type {
+	if len(tys) == 0 {
+		return cty.DynamicPseudoType
+	}
+	ty, _ := convert.UnifyUnsafe(tys)
+	if ty == cty.NilType {
+		return cty.DynamicPseudoType
+	}
+	return ty
+}
+
+// staticExprValue returns the value of the given expression if it is a
+// literal value or a template consisting only of literal strings.
+func staticExprValue(expr Expression) (cty.Value, bool) {
+	switch e := expr.(type) {
+	case *LiteralValueExpr:
+		return e.Val, true
+	case *TemplateExpr:
+		if e.IsStringLiteral() {
+			val, diags := e.Value(nil)
+			return val, !diags.HasErrors()
+		}
+	case *ParenthesesExpr:
+		return staticExprValue(e.Expression)
+	}
+	return cty.NilVal, false
+}
+
+// staticObjectKey returns the string that the given object constructor key
+// expression will produce, if that can be determined without evaluating it.
+func staticObjectKey(expr Expression) (string, bool) {
+	if keyExpr, ok := expr.(*ObjectConsKeyExpr); ok {
+		if !keyExpr.ForceNonLiteral {
+			if name := keyExpr.literalName(); name != "" {
+				return name, true
+			}
+		}
+		expr = keyExpr.Wrapped
+	}
+	val, ok := staticExprValue(expr)
+	if !ok || val.IsNull() {
+		return "", false
+	}
+	val, err := convert.Convert(val, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return val.AsString(), true
+}
+
+func isLiteralNull(expr Expression) bool {
+	lit, ok := expr.(*LiteralValueExpr)
+	return ok && lit.Val.RawEquals(cty.NullVal(cty.DynamicPseudoType))
+}
+
+// setDiagExpression sets the Expression field of any of the given
+// diagnostics that don't already have one.
+func setDiagExpression(diags hcl.Diagnostics, expr hcl.Expression) {
+	for _, diag := range diags {
+		if diag.Expression == nil {
+			diag.Expression = expr
+		}
+	}
+}
@@ -0,0 +1,477 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestInferType(t *testing.T) {
+	ctx := &TypeContext{
+		Variables: map[string]cty.Type{
+			"str":  cty.String,
+			"num":  cty.Number,
+			"bool": cty.Bool,
+			"list": cty.List(cty.String),
+			"set":  cty.Set(cty.Number),
+			"map":  cty.Map(cty.Bool),
+			"tup":  cty.Tuple([]cty.Type{cty.String, cty.Number}),
+			"obj": cty.Object(map[string]cty.Type{
+				"name": cty.String,
+				"tags": cty.Map(cty.String),
+			}),
+			"objs": cty.List(cty.Object(map[string]cty.Type{
+				"id": cty.Number,
+			})),
+			"dyn": cty.DynamicPseudoType,
+		},
+		Functions: map[string]function.Function{
+			"upper":  stdlib.UpperFunc,
+			"concat": stdlib.ConcatFunc,
+			"max":    stdlib.MaxFunc,
+		},
+	}
+
+	tests := []struct {
+		input     string
+		ctx       *TypeContext
+		want      cty.Type
+		diagCount int
+	}{
+		{
+			`1`,
+			nil,
+			cty.Number,
+			0,
+		},
+		{
+			`"hello"`,
+			nil,
+			cty.String,
+			0,
+		},
+		{
+			`null`,
+			nil,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`str`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`str`,
+			nil,
+			cty.DynamicPseudoType,
+			1, // Variables not allowed
+		},
+		{
+			`strr`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Unknown variable
+		},
+		{
+			`obj.name`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`obj.tags["foo"]`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`obj.nope`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Unsupported attribute
+		},
+		{
+			`tup[1]`,
+			ctx,
+			cty.Number,
+			0,
+		},
+		{
+			`tup[2]`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Invalid index
+		},
+		{
+			`tup[num]`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`list[num]`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`map.foo`,
+			ctx,
+			cty.Bool,
+			0,
+		},
+		{
+			`dyn.foo.bar`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`num.foo`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Unsupported attribute
+		},
+		{
+			`(obj).name`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`upper(str)`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`upper(list)`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Invalid function argument
+		},
+		{
+			`upper()`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Not enough function arguments
+		},
+		{
+			`upper(str, str)`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Too many function arguments
+		},
+		{
+			`lower(str)`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Call to unknown function
+		},
+		{
+			`upper(str)`,
+			&TypeContext{Variables: ctx.Variables},
+			cty.DynamicPseudoType,
+			1, // Function calls not allowed
+		},
+		{
+			`concat(list, list)`,
+			ctx,
+			cty.List(cty.String),
+			0,
+		},
+		{
+			`max(tup[1], num)`,
+			ctx,
+			cty.Number,
+			0,
+		},
+		{
+			`max([1, 2]...)`,
+			ctx,
+			cty.Number,
+			0,
+		},
+		{
+			`max(set...)`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`max(objs...)`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Invalid function argument
+		},
+		{
+			`num + 1`,
+			ctx,
+			cty.Number,
+			0,
+		},
+		{
+			`str == num`,
+			ctx,
+			cty.Bool,
+			0,
+		},
+		{
+			`list + 1`,
+			ctx,
+			cty.Number,
+			1, // Invalid operand
+		},
+		{
+			`!bool`,
+			ctx,
+			cty.Bool,
+			0,
+		},
+		{
+			`-str`,
+			ctx,
+			cty.Number,
+			0, // a string might contain a number
+		},
+		{
+			`-obj`,
+			ctx,
+			cty.Number,
+			1, // Invalid operand
+		},
+		{
+			`bool ? str : num`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`bool ? null : list`,
+			ctx,
+			cty.List(cty.String),
+			0,
+		},
+		{
+			`bool ? dyn : str`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`bool ? list : num`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Inconsistent conditional result types
+		},
+		{
+			`list ? 1 : 2`,
+			ctx,
+			cty.Number,
+			1, // Incorrect condition type
+		},
+		{
+			`true ? 1 : upper(list)`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Invalid function argument, even though never evaluated
+		},
+		{
+			`"${str}-${num}"`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`"${list}"`,
+			ctx,
+			cty.List(cty.String),
+			0, // wrapped interpolation passes through its type
+		},
+		{
+			`"a ${list}"`,
+			ctx,
+			cty.String,
+			1, // Invalid template interpolation value
+		},
+		{
+			`"%{ for v in list }${v}%{ endfor }"`,
+			ctx,
+			cty.String,
+			0,
+		},
+		{
+			`[str, num]`,
+			ctx,
+			cty.Tuple([]cty.Type{cty.String, cty.Number}),
+			0,
+		},
+		{
+			`{a = str, "b" = num}`,
+			ctx,
+			cty.Object(map[string]cty.Type{
+				"a": cty.String,
+				"b": cty.Number,
+			}),
+			0,
+		},
+		{
+			`{(str) = num}`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`{(list) = num}`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Incorrect key type
+		},
+		{
+			`{a.b = num}`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Ambiguous attribute key
+		},
+		{
+			`[for v in tup : [v]]`,
+			ctx,
+			cty.Tuple([]cty.Type{
+				cty.Tuple([]cty.Type{cty.String}),
+				cty.Tuple([]cty.Type{cty.Number}),
+			}),
+			0,
+		},
+		{
+			`[for i, v in list : upper(v) if i > 0]`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`{for k, v in map : k => !v}`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`[for v in set : upper(v)]`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+		{
+			`[for v in list : v if v]`,
+			ctx,
+			cty.DynamicPseudoType,
+			0, // a string might be "true" or "false"
+		},
+		{
+			`[for v in objs : v.name]`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Unsupported attribute
+		},
+		{
+			`[for v in num : v]`,
+			ctx,
+			cty.DynamicPseudoType,
+			1, // Iteration over non-iterable value
+		},
+		{
+			`objs[*].id`,
+			ctx,
+			cty.List(cty.Number),
+			0,
+		},
+		{
+			`objs.*.id`,
+			ctx,
+			cty.List(cty.Number),
+			0,
+		},
+		{
+			`objs[*].name`,
+			ctx,
+			cty.List(cty.DynamicPseudoType),
+			1, // Unsupported attribute
+		},
+		{
+			`tup[*]`,
+			ctx,
+			cty.Tuple([]cty.Type{cty.String, cty.Number}),
+			0,
+		},
+		{
+			`obj[*].name`,
+			ctx,
+			cty.Tuple([]cty.Type{cty.String}),
+			0,
+		},
+		{
+			`dyn[*].foo`,
+			ctx,
+			cty.DynamicPseudoType,
+			0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			expr, parseDiags := ParseExpression([]byte(test.input), "", hcl.Pos{Line: 1, Column: 1, Byte: 0})
+			if parseDiags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+			}
+
+			got, diags := InferType(expr, test.ctx)
+
+			if len(diags) != test.diagCount {
+				t.Errorf("wrong number of diagnostics %d; want %d", len(diags), test.diagCount)
+				for _, diag := range diags {
+					t.Logf(" - %s", diag.Error())
+				}
+			}
+
+			if !got.Equals(test.want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInferTypeNestedContext(t *testing.T) {
+	parent := &TypeContext{
+		Variables: map[string]cty.Type{
+			"a": cty.String,
+		},
+	}
+	child := parent.NewChild()
+	child.Variables = map[string]cty.Type{
+		"b": cty.Number,
+	}
+
+	expr, parseDiags := ParseExpression([]byte(`[a, b]`), "", hcl.InitialPos)
+	if parseDiags.HasErrors() {
+		t.Fatalf("unexpected parse errors: %s", parseDiags.Error())
+	}
+
+	got, diags := InferType(expr, child)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	want := cty.Tuple([]cty.Type{cty.String, cty.Number})
+	if !got.Equals(want) {
+		t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if child.Parent() != parent {
+		t.Errorf("wrong parent")
+	}
+}
@@ -6,6 +6,7 @@ package hclsyntax
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/hcl/v2"
 )
@@ -60,3 +61,91 @@ func (n navigation) ContextDefRange(offset int) hcl.Range {
 
 	return block.DefRange()
 }
+
+// Implementation of hcled.Symbols
+func (n navigation) WalkSymbols(cb func(depth int, block bool, name string, labels []string, rng, nameRange hcl.Range)) {
+	walkBodySymbols(n.root, 0, cb)
+}
+
+func walkBodySymbols(body *Body, depth int, cb func(depth int, block bool, name string, labels []string, rng, nameRange hcl.Range)) {
+	for _, item := range sortedBodyItems(body) {
+		switch item := item.(type) {
+		case *Attribute:
+			cb(depth, false, item.Name, nil, item.SrcRange, item.NameRange)
+		case *Block:
+			cb(depth, true, item.Type, item.Labels, item.Range(), item.DefRange())
+			walkBodySymbols(item.Body, depth+1, cb)
+		}
+	}
+}
+
+// Implementation of hcled.FoldingRanges
+func (n navigation) FoldingRanges() []hcl.Range {
+	var ret []hcl.Range
+	VisitAll(n.root, func(node Node) hcl.Diagnostics {
+		switch node.(type) {
+		case *Block, *ObjectConsExpr, *TupleConsExpr, *TemplateExpr, *TemplateWrapExpr:
+			// Templates can span multiple lines only if they are heredocs
+			// or if they have multi-line interpolation sequences.
+			rng := node.Range()
+			if rng.End.Line > rng.Start.Line {
+				ret = append(ret, rng)
+			}
+		}
+		return nil
+	})
+
+	// Body attributes are visited in no particular order, so we'll put our
+	// results into source order.
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Start.Byte < ret[j].Start.Byte
+	})
+	return ret
+}
+
+// Implementation of hcled.SelectionRanges
+func (n navigation) SelectionRanges(offset int) []hcl.Range {
+	// Our walk visits parents before their children, so the nodes that
+	// contain the offset appear in order from outermost to innermost. The
+	// offset may be at the boundary between two adjacent nodes, in which
+	// case we take the first one.
+	var ret []hcl.Range
+	VisitAll(n.root, func(node Node) hcl.Diagnostics {
+		switch node.(type) {
+		case Attributes, Blocks:
+			// These are just grouping constructs without meaningful ranges.
+			return nil
+		}
+		rng := node.Range()
+		if offset < rng.Start.Byte || offset > rng.End.Byte {
+			return nil
+		}
+		if len(ret) != 0 {
+			last := ret[len(ret)-1]
+			if rng.Start.Byte < last.Start.Byte || rng.End.Byte > last.End.Byte {
+				return nil
+			}
+			if rng == last {
+				return nil
+			}
+		}
+		ret = append(ret, rng)
+
+		switch node := node.(type) {
+		case *Attribute:
+			if offset <= node.NameRange.End.Byte {
+				ret = append(ret, node.NameRange)
+			}
+		case *Block:
+			if offset < node.OpenBraceRange.Start.Byte {
+				ret = append(ret, node.DefRange())
+			}
+		}
+		return nil
+	})
+
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret
+}
@@ -19,6 +19,13 @@ type Node interface {
 	// walks.
 	walkChildNodes(w internalWalkFunc)
 
+	// This is the mechanism by which the public-facing rewrite functions
+	// are implemented. Implementations should call the given function for
+	// each child node, in the same order as walkChildNodes, and return a
+	// copy of the node with each child replaced by the function's result,
+	// or the node itself if none of the children changed.
+	rewriteChildNodes(r internalRewriteFunc) Node
+
 	Range() hcl.Range
 }
 
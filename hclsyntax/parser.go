@@ -22,6 +22,13 @@ type parser struct {
 	// in recovery mode, assuming that the recovery heuristics have failed
 	// in this case and left the peeker in a wrong place.
 	recovery bool
+
+	// opts are the limits and feature toggles for this parse, and depth
+	// and depthExceeded track the nesting depth to enforce
+	// opts.MaxNestingDepth.
+	opts          ParseOptions
+	depth         int
+	depthExceeded bool
 }
 
 func (p *parser) ParseBody(end TokenType) (*Body, hcl.Diagnostics) {
@@ -32,6 +39,24 @@ func (p *parser) ParseBody(end TokenType) (*Body, hcl.Diagnostics) {
 	startRange := p.PrevRange()
 	var endRange hcl.Range
 
+	nestDiags, ok := p.enterNesting(startRange)
+	if !ok {
+		// We'll skip over the whole body, leaving it empty.
+		endRange = p.recover(end).Range
+		return &Body{
+			Attributes: attrs,
+			Blocks:     blocks,
+
+			SrcRange: hcl.RangeBetween(startRange, endRange),
+			EndRange: hcl.Range{
+				Filename: endRange.Filename,
+				Start:    endRange.End,
+				End:      endRange.End,
+			},
+		}, nestDiags
+	}
+	defer p.exitNesting()
+
 Token:
 	for {
 		next := p.Peek()
@@ -476,7 +501,7 @@ Token:
 }
 
 func (p *parser) ParseExpression() (Expression, hcl.Diagnostics) {
-	return p.parseTernaryConditional()
+	return p.parseNested(p.parseTernaryConditional)
 }
 
 func (p *parser) parseTernaryConditional() (Expression, hcl.Diagnostics) {
@@ -702,6 +727,9 @@ Traversal:
 				// to do attribute traversals into each of its elements,
 				// whereas foo[*] can support _any_ traversal.
 				marker := p.Read() // eat star
+				if p.opts.DisableSplats {
+					diags = append(diags, disabledSplat(hcl.RangeBetween(dot.Range, marker.Range)))
+				}
 				trav := make(hcl.Traversal, 0, 1)
 				var firstRange, lastRange hcl.Range
 				firstRange = p.NextRange()
@@ -835,7 +863,10 @@ Traversal:
 				// This is a full splat expression, like foo[*], which consumes
 				// the rest of the traversal steps after it using a recursive
 				// call to this function.
-				p.Read() // consume star
+				star := p.Read() // consume star
+				if p.opts.DisableSplats {
+					diags = append(diags, disabledSplat(hcl.RangeBetween(open.Range, star.Range)))
+				}
 				close := p.Read()
 				if close.Type != TokenCBrack && !p.recovery {
 					diags = append(diags, &hcl.Diagnostic{
@@ -854,7 +885,9 @@ Traversal:
 				}
 				// Now we'll recursively call this same function to eat any
 				// remaining traversal steps against the anonymous symbol.
-				travExpr, nestedDiags := p.parseExpressionTraversals(itemExpr)
+				travExpr, nestedDiags := p.parseNested(func() (Expression, hcl.Diagnostics) {
+					return p.parseExpressionTraversals(itemExpr)
+				})
 				diags = append(diags, nestedDiags...)
 
 				ret = &SplatExpr{
@@ -1043,6 +1076,13 @@ func (p *parser) parseExpressionTerm() (Expression, hcl.Diagnostics) {
 		open := p.Read() // eat opening marker
 		closer := p.oppositeBracket(open.Type)
 		exprs, passthru, _, diags := p.parseTemplateInner(closer, tokenOpensFlushHeredoc(open))
+		if open.Type == TokenOHeredoc && p.opts.DisableHeredocs {
+			diags = append(diags, disabledFeature(
+				"Heredoc templates not allowed",
+				"Heredoc templates are not allowed here. Use a quoted string instead, with \\n escape sequences for any newlines.",
+				open.Range,
+			))
+		}
 
 		closeRange := p.PrevRange()
 
@@ -1068,7 +1108,7 @@ func (p *parser) parseExpressionTerm() (Expression, hcl.Diagnostics) {
 		// here, otherwise we can capture a following binary expression into
 		// our negation.
 		// e.g. -46+5 should parse as (-46)+5, not -(46+5)
-		operand, diags := p.parseExpressionWithTraversals()
+		operand, diags := p.parseNested(p.parseExpressionWithTraversals)
 		return &UnaryOpExpr{
 			Op:  OpNegate,
 			Val: operand,
@@ -1083,7 +1123,7 @@ func (p *parser) parseExpressionTerm() (Expression, hcl.Diagnostics) {
 		// Important to use parseExpressionWithTraversals rather than parseExpression
 		// here, otherwise we can capture a following binary expression into
 		// our negation.
-		operand, diags := p.parseExpressionWithTraversals()
+		operand, diags := p.parseNested(p.parseExpressionWithTraversals)
 		return &UnaryOpExpr{
 			Op:  OpLogicalNot,
 			Val: operand,
@@ -1202,6 +1242,13 @@ func (p *parser) finishParsingFunctionCall(name Token) (Expression, hcl.Diagnost
 		Start:    name.Range.Start,
 		End:      nameEndPos,
 	}
+	if p.opts.DisableNamespacedFunctions && nameEndPos != name.Range.End {
+		diags = append(diags, disabledFeature(
+			"Namespaced functions not allowed",
+			fmt.Sprintf("Function names containing the :: namespace separator are not allowed here, so %q cannot be called.", nameStr),
+			nameRange,
+		))
+	}
 
 	if openTok.Type != TokenOParen {
 		diag := hcl.Diagnostic{
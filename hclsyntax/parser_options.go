@@ -0,0 +1,136 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ParseOptions are limits and feature toggles for ParseConfigWithOptions,
+// ParseConfigWithCommentsAndOptions and ParseExpressionWithOptions.
+//
+// The zero value of ParseOptions imposes no limits and enables all features,
+// which is the behavior of ParseConfig and ParseExpression.
+type ParseOptions struct {
+	// MaxNestingDepth is the maximum number of levels that blocks and
+	// expressions may be nested inside one another, or zero for no limit.
+	// Each body, including the root body of a file, counts as one level, as
+	// does each expression within another construct, such as the value of an
+	// attribute, an element of a collection, a function argument or a
+	// template interpolation. Each unary operator, each splat operator and
+	// each if or for template directive also counts as one level.
+	//
+	// Applications that parse untrusted input should set a limit, since
+	// the parser is recursive and so pathologically-nested input could
+	// otherwise exhaust the stack.
+	MaxNestingDepth int
+
+	// MaxSourceSize is the maximum length in bytes of the source buffer, or
+	// zero for no limit. A longer buffer is rejected without being parsed.
+	MaxSourceSize int
+
+	// DisableTemplateDirectives rejects the %{ if } and %{ for } template
+	// directives.
+	DisableTemplateDirectives bool
+
+	// DisableHeredocs rejects heredoc templates.
+	DisableHeredocs bool
+
+	// DisableSplats rejects the splat operators [*] and .*.
+	DisableSplats bool
+
+	// DisableNamespacedFunctions rejects calls to functions whose names
+	// contain the :: namespace separator.
+	DisableNamespacedFunctions bool
+}
+
+// checkSourceSize returns an error diagnostic if the given source buffer is
+// longer than the options allow.
+func (o ParseOptions) checkSourceSize(src []byte, filename string, start hcl.Pos) hcl.Diagnostics {
+	if o.MaxSourceSize <= 0 || len(src) <= o.MaxSourceSize {
+		return nil
+	}
+	return hcl.Diagnostics{
+		{
+			Severity: hcl.DiagError,
+			Summary:  "Source too large",
+			Detail:   fmt.Sprintf("The source is %d bytes long, which exceeds the maximum size of %d bytes.", len(src), o.MaxSourceSize),
+			Subject: &hcl.Range{
+				Filename: filename,
+				Start:    start,
+				End:      start,
+			},
+		},
+	}
+}
+
+// enterNesting records that the parser is about to parse a construct that is
+// nested one level deeper than the current one, returning false along with
+// an error diagnostic if this would exceed the maximum nesting depth. If it
+// returns true, the caller must call exitNesting once it has parsed the
+// nested construct.
+func (p *parser) enterNesting(rng hcl.Range) (hcl.Diagnostics, bool) {
+	max := p.opts.MaxNestingDepth
+	if max > 0 && p.depth >= max {
+		var diags hcl.Diagnostics
+		if !p.depthExceeded {
+			// We report only the first violation, since it's likely that
+			// anything that follows is part of the same deep structure.
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Nesting too deep",
+				Detail:   fmt.Sprintf("This construct is nested more than %d levels deep, which exceeds the maximum nesting depth.", max),
+				Subject:  &rng,
+			})
+			p.depthExceeded = true
+		}
+		p.setRecovery()
+		return diags, false
+	}
+	p.depth++
+	return nil, true
+}
+
+func (p *parser) exitNesting() {
+	p.depth--
+}
+
+// parseNested calls the given function to parse an expression that is nested
+// one level deeper than the current one, unless that would exceed the maximum
+// nesting depth.
+func (p *parser) parseNested(parse func() (Expression, hcl.Diagnostics)) (Expression, hcl.Diagnostics) {
+	rng := p.NextRange()
+	diags, ok := p.enterNesting(rng)
+	if !ok {
+		return &ExprSyntaxError{
+			Placeholder: cty.DynamicVal,
+			ParseDiags:  diags,
+			SrcRange:    rng,
+		}, diags
+	}
+	defer p.exitNesting()
+	return parse()
+}
+
+// disabledFeature returns an error diagnostic reporting that a construct that
+// is disabled by the parser options appears at the given range.
+func disabledFeature(summary, detail string, rng hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  &rng,
+	}
+}
+
+func disabledSplat(rng hcl.Range) *hcl.Diagnostic {
+	return disabledFeature(
+		"Splat expressions not allowed",
+		"Splat expressions are not allowed here. Use a for expression instead, such as [for v in list : v.attr].",
+		rng,
+	)
+}
@@ -0,0 +1,243 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestParseConfigWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		Src  string
+		Opts ParseOptions
+		Want []string // diagnostic summaries
+	}{
+		"no options": {
+			"a = [for v in x : v[*].b]\nb = <<EOT\n%{if c}d%{endif}\nEOT\nc = p::f()\n",
+			ParseOptions{},
+			nil,
+		},
+		"within nesting limit": {
+			"a {\n  b = [[1]]\n}\n",
+			ParseOptions{MaxNestingDepth: 5},
+			nil,
+		},
+		"nested expressions": {
+			"a = [[[1]]]\n",
+			ParseOptions{MaxNestingDepth: 3},
+			[]string{"Nesting too deep"},
+		},
+		"nested blocks": {
+			"a {\n  b {\n    c {\n    }\n  }\n}\nd = 1\n",
+			ParseOptions{MaxNestingDepth: 2},
+			[]string{"Nesting too deep"},
+		},
+		"pathological brackets": {
+			"a = " + strings.Repeat("[", 100000) + strings.Repeat("]", 100000) + "\n",
+			ParseOptions{MaxNestingDepth: 100},
+			[]string{"Nesting too deep"},
+		},
+		"pathological unary operators": {
+			"a = " + strings.Repeat("!", 100000) + "true\n",
+			ParseOptions{MaxNestingDepth: 100},
+			[]string{"Nesting too deep"},
+		},
+		"pathological conditionals": {
+			"a = " + strings.Repeat("true ? 1 : ", 100000) + "2\n",
+			ParseOptions{MaxNestingDepth: 100},
+			[]string{"Nesting too deep"},
+		},
+		"pathological splats": {
+			"a = b" + strings.Repeat("[*]", 100000) + "\n",
+			ParseOptions{MaxNestingDepth: 100},
+			[]string{"Nesting too deep"},
+		},
+		"pathological templates": {
+			"a = " + strings.Repeat(`"${`, 10000) + "1" + strings.Repeat(`}"`, 10000) + "\n",
+			ParseOptions{MaxNestingDepth: 100},
+			[]string{"Nesting too deep"},
+		},
+		"nested template directives": {
+			"a = \"%{if b}%{for v in c}%{if v}d%{endif}%{endfor}%{endif}\"\ne = 1\n",
+			ParseOptions{MaxNestingDepth: 4},
+			[]string{"Nesting too deep"},
+		},
+		"template directives within nesting limit": {
+			"a = \"%{if b}%{for v in c}%{if v}d%{endif}%{endfor}%{endif}\"\n",
+			ParseOptions{MaxNestingDepth: 5},
+			nil,
+		},
+		"pathological template directives": {
+			"a = \"" + strings.Repeat("%{if true}", 500) + "x" + strings.Repeat("%{endif}", 500) + "\"\n",
+			ParseOptions{MaxNestingDepth: 10},
+			[]string{"Nesting too deep"},
+		},
+		"within size limit": {
+			"a = 1\n",
+			ParseOptions{MaxSourceSize: 6},
+			nil,
+		},
+		"too large": {
+			"a = 1\n",
+			ParseOptions{MaxSourceSize: 5},
+			[]string{"Source too large"},
+		},
+		"template directives": {
+			"a = \"%{if b}c%{else}d%{endif}%{for v in e}${v}%{endfor}\"\n",
+			ParseOptions{DisableTemplateDirectives: true},
+			[]string{"Template directives not allowed", "Template directives not allowed"},
+		},
+		"template interpolations with directives disabled": {
+			"a = \"${b}\"\n",
+			ParseOptions{DisableTemplateDirectives: true},
+			nil,
+		},
+		"heredocs": {
+			"a = <<EOT\nb\nEOT\n",
+			ParseOptions{DisableHeredocs: true},
+			[]string{"Heredoc templates not allowed"},
+		},
+		"quoted templates with heredocs disabled": {
+			"a = \"b\\n\"\n",
+			ParseOptions{DisableHeredocs: true},
+			nil,
+		},
+		"full splats": {
+			"a = b[*].c\n",
+			ParseOptions{DisableSplats: true},
+			[]string{"Splat expressions not allowed"},
+		},
+		"attribute-only splats": {
+			"a = b.*.c\n",
+			ParseOptions{DisableSplats: true},
+			[]string{"Splat expressions not allowed"},
+		},
+		"namespaced functions": {
+			"a = p::q::f(1)\n",
+			ParseOptions{DisableNamespacedFunctions: true},
+			[]string{"Namespaced functions not allowed"},
+		},
+		"plain functions with namespaces disabled": {
+			"a = f(1)\n",
+			ParseOptions{DisableNamespacedFunctions: true},
+			nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			file, diags := ParseConfigWithOptions([]byte(test.Src), "test.hcl", hcl.InitialPos, test.Opts)
+			if file == nil || file.Body == nil {
+				t.Fatalf("no body returned")
+			}
+			var got []string
+			for _, diag := range diags {
+				got = append(got, diag.Summary)
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong diagnostics\n%s\n%s", diff, diags.Error())
+			}
+		})
+	}
+}
+
+func TestParseConfigWithOptionsNestingRecovery(t *testing.T) {
+	// Content after a construct that is nested too deeply should still be
+	// parsed normally.
+	src := "a {\n  b {\n    c = 1\n  }\n}\nd = [[1]]\ne = 2\n"
+	file, diags := ParseConfigWithOptions([]byte(src), "test.hcl", hcl.InitialPos, ParseOptions{MaxNestingDepth: 2})
+	if len(diags) != 1 || diags[0].Summary != "Nesting too deep" {
+		t.Fatalf("wrong diagnostics: %s", diags.Error())
+	}
+	wantRange := hcl.Range{
+		Filename: "test.hcl",
+		Start:    hcl.Pos{Line: 2, Column: 5, Byte: 8},
+		End:      hcl.Pos{Line: 2, Column: 6, Byte: 9},
+	}
+	if got := *diags[0].Subject; got != wantRange {
+		t.Errorf("wrong subject %s; want %s", got, wantRange)
+	}
+
+	body := file.Body.(*Body)
+	if len(body.Blocks) != 1 || len(body.Blocks[0].Body.Blocks) != 1 {
+		t.Fatalf("wrong blocks %#v", body.Blocks)
+	}
+	if inner := body.Blocks[0].Body.Blocks[0].Body; len(inner.Attributes) != 0 {
+		t.Errorf("too-deep body has attributes %#v", inner.Attributes)
+	}
+	for _, name := range []string{"d", "e"} {
+		if _, exists := body.Attributes[name]; !exists {
+			t.Errorf("missing attribute %q", name)
+		}
+	}
+}
+
+func TestParseConfigWithCommentsAndOptions(t *testing.T) {
+	src := "# doc\na = [[1]]\n"
+	file, diags := ParseConfigWithCommentsAndOptions([]byte(src), "test.hcl", hcl.InitialPos, ParseOptions{MaxNestingDepth: 2})
+	if len(diags) != 1 || diags[0].Summary != "Nesting too deep" {
+		t.Fatalf("wrong diagnostics: %s", diags.Error())
+	}
+	attr := file.Body.(*Body).Attributes["a"]
+	if attr == nil {
+		t.Fatal("missing attribute a")
+	}
+	if attr.Comments == nil || len(attr.Comments.Leading) != 1 {
+		t.Errorf("wrong comments %#v", attr.Comments)
+	}
+}
+
+func TestParseExpressionWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		Src  string
+		Opts ParseOptions
+		Want []string // diagnostic summaries
+	}{
+		"valid": {
+			`[for v in x : v if v != ""]`,
+			ParseOptions{MaxNestingDepth: 3, MaxSourceSize: 100},
+			nil,
+		},
+		"nested": {
+			`f(g(h(1)))`,
+			ParseOptions{MaxNestingDepth: 3},
+			[]string{"Nesting too deep"},
+		},
+		"too large": {
+			`1 + 2`,
+			ParseOptions{MaxSourceSize: 4},
+			[]string{"Source too large"},
+		},
+		"splat": {
+			`a[*]`,
+			ParseOptions{DisableSplats: true},
+			[]string{"Splat expressions not allowed"},
+		},
+		"template directive": {
+			`"%{for v in a}${v}%{endfor}"`,
+			ParseOptions{DisableTemplateDirectives: true},
+			[]string{"Template directives not allowed"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, diags := ParseExpressionWithOptions([]byte(test.Src), "", hcl.InitialPos, test.Opts)
+			if expr == nil {
+				t.Fatalf("no expression returned")
+			}
+			var got []string
+			for _, diag := range diags {
+				got = append(got, diag.Summary)
+			}
+			if diff := cmp.Diff(test.Want, got); diff != "" {
+				t.Errorf("wrong diagnostics\n%s\n%s", diff, diags.Error())
+			}
+		})
+	}
+}
@@ -45,6 +45,8 @@ func (p *parser) parseTemplateInner(end TokenType, flushHeredoc bool) ([]Express
 	tp := templateParser{
 		Tokens:   parts.Tokens,
 		SrcRange: parts.SrcRange,
+
+		parser: p,
 	}
 	exprs, exprsDiags := tp.parseRoot()
 	diags = append(diags, exprsDiags...)
@@ -63,6 +65,10 @@ type templateParser struct {
 	Tokens   []templateToken
 	SrcRange hcl.Range
 
+	// parser is the parser that produced the tokens, which tracks the
+	// nesting depth of the directives.
+	parser *parser
+
 	pos int
 }
 
@@ -100,9 +106,19 @@ func (p *templateParser) parseExpr() (Expression, hcl.Diagnostics) {
 		return tok.Expr, nil
 
 	case *templateIfToken:
+		diags, ok := p.parser.enterNesting(tok.SrcRange)
+		if !ok {
+			return p.skipDirective(tok.SrcRange), diags
+		}
+		defer p.parser.exitNesting()
 		return p.parseIf()
 
 	case *templateForToken:
+		diags, ok := p.parser.enterNesting(tok.SrcRange)
+		if !ok {
+			return p.skipDirective(tok.SrcRange), diags
+		}
+		defer p.parser.exitNesting()
 		return p.parseFor()
 
 	case *templateEndToken:
@@ -345,6 +361,30 @@ Token:
 	}, diags
 }
 
+// skipDirective skips over the if or for directive at the peeker, along with
+// everything up to and including its corresponding end directive, returning
+// a placeholder expression in its place. It is used when the directive would
+// exceed the maximum nesting depth, and so must not recurse.
+func (p *templateParser) skipDirective(rng hcl.Range) Expression {
+	depth := 0
+	for {
+		switch tok := p.Peek().(type) {
+		case *templateEndToken:
+			return errPlaceholderExpr(rng)
+		case *templateIfToken, *templateForToken:
+			depth++
+		case *templateEndCtrlToken:
+			if tok.Type != templateElse {
+				depth--
+			}
+		}
+		p.Read()
+		if depth == 0 {
+			return errPlaceholderExpr(rng)
+		}
+	}
+}
+
 func (p *templateParser) Peek() templateToken {
 	return p.Tokens[p.pos]
 }
@@ -502,6 +542,16 @@ Token:
 			}
 			p.Read() // eat keyword token
 
+			if p.opts.DisableTemplateDirectives && (ifKeyword.TokenMatches(kw) || forKeyword.TokenMatches(kw)) {
+				// We report only the directives that open a construct, since
+				// the rest of the construct is redundant.
+				diags = append(diags, disabledFeature(
+					"Template directives not allowed",
+					"Template directives are not allowed here. Use a conditional or for expression inside an interpolation sequence instead.",
+					hcl.RangeBetween(next.Range, kw.Range),
+				))
+			}
+
 			switch {
 
 			case ifKeyword.TokenMatches(kw):
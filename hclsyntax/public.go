@@ -5,6 +5,7 @@ package hclsyntax
 
 import (
 	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ParseConfig parses the given buffer as a whole HCL config file, returning
@@ -18,9 +19,62 @@ import (
 // should be served using the hcl.Body interface to ensure compatibility with
 // other configurationg syntaxes, such as JSON.
 func ParseConfig(src []byte, filename string, start hcl.Pos) (*hcl.File, hcl.Diagnostics) {
+	return ParseConfigWithOptions(src, filename, start, ParseOptions{})
+}
+
+// ParseConfigWithOptions is like ParseConfig, but enforces the limits and
+// feature toggles in the given options. Any disabled constructs in the given
+// buffer produce error diagnostics.
+//
+// If the buffer exceeds the maximum source size then it is not parsed at all,
+// and the returned file has an empty body.
+func ParseConfigWithOptions(src []byte, filename string, start hcl.Pos, opts ParseOptions) (*hcl.File, hcl.Diagnostics) {
+	return parseConfig(src, filename, start, opts, false)
+}
+
+// ParseConfigWithComments is like ParseConfig, but also records the comments
+// in the given buffer and associates them with the nearby attributes and
+// blocks, using the Comments fields of Body, Attribute and Block.
+//
+// This is intended for applications such as documentation generators and
+// text editors that wish to present comments alongside the configuration
+// items they describe, without parsing the file a second time.
+func ParseConfigWithComments(src []byte, filename string, start hcl.Pos) (*hcl.File, hcl.Diagnostics) {
+	return ParseConfigWithCommentsAndOptions(src, filename, start, ParseOptions{})
+}
+
+// ParseConfigWithCommentsAndOptions combines ParseConfigWithComments and
+// ParseConfigWithOptions, associating comments with the parsed items while
+// also enforcing the limits and feature toggles in the given options.
+func ParseConfigWithCommentsAndOptions(src []byte, filename string, start hcl.Pos, opts ParseOptions) (*hcl.File, hcl.Diagnostics) {
+	return parseConfig(src, filename, start, opts, true)
+}
+
+// parseConfig is the common implementation of the ParseConfig family of
+// functions, which associates comments with the parsed body only if the
+// comments flag is set.
+func parseConfig(src []byte, filename string, start hcl.Pos, opts ParseOptions, comments bool) (*hcl.File, hcl.Diagnostics) {
+	if diags := opts.checkSourceSize(src, filename, start); diags.HasErrors() {
+		rng := hcl.Range{Filename: filename, Start: start, End: start}
+		body := &Body{
+			Attributes: Attributes{},
+			Blocks:     Blocks{},
+			SrcRange:   rng,
+			EndRange:   rng,
+		}
+		return &hcl.File{
+			Body:  body,
+			Bytes: src,
+
+			Nav: navigation{
+				root: body,
+			},
+		}, diags
+	}
+
 	tokens, diags := LexConfig(src, filename, start)
 	peeker := newPeeker(tokens, false)
-	parser := &parser{peeker: peeker}
+	parser := &parser{peeker: peeker, opts: opts}
 	body, parseDiags := parser.ParseBody(TokenEOF)
 	diags = append(diags, parseDiags...)
 
@@ -29,6 +83,10 @@ func ParseConfig(src []byte, filename string, start hcl.Pos) (*hcl.File, hcl.Dia
 	// errors.
 	peeker.AssertEmptyIncludeNewlinesStack()
 
+	if comments {
+		attachComments(body, tokens)
+	}
+
 	return &hcl.File{
 		Body:  body,
 		Bytes: src,
@@ -42,9 +100,27 @@ func ParseConfig(src []byte, filename string, start hcl.Pos) (*hcl.File, hcl.Dia
 // ParseExpression parses the given buffer as a standalone HCL expression,
 // returning it as an instance of Expression.
 func ParseExpression(src []byte, filename string, start hcl.Pos) (Expression, hcl.Diagnostics) {
+	return ParseExpressionWithOptions(src, filename, start, ParseOptions{})
+}
+
+// ParseExpressionWithOptions is like ParseExpression, but enforces the limits
+// and feature toggles in the given options. Any disabled constructs in the
+// given buffer produce error diagnostics.
+//
+// If the buffer exceeds the maximum source size then it is not parsed at all,
+// and the returned expression is a placeholder for an unknown value.
+func ParseExpressionWithOptions(src []byte, filename string, start hcl.Pos, opts ParseOptions) (Expression, hcl.Diagnostics) {
+	if diags := opts.checkSourceSize(src, filename, start); diags.HasErrors() {
+		return &ExprSyntaxError{
+			Placeholder: cty.DynamicVal,
+			ParseDiags:  diags,
+			SrcRange:    hcl.Range{Filename: filename, Start: start, End: start},
+		}, diags
+	}
+
 	tokens, diags := LexExpression(src, filename, start)
 	peeker := newPeeker(tokens, false)
-	parser := &parser{peeker: peeker}
+	parser := &parser{peeker: peeker, opts: opts}
 
 	// Bare expressions are always parsed in  "ignore newlines" mode, as if
 	// they were wrapped in parentheses.
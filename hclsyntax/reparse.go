@@ -0,0 +1,531 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// TextEdit describes a change to a source buffer, replacing the bytes between
+// the byte offsets Start (inclusive) and End (exclusive) with NewText.
+//
+// An insertion has Start equal to End, and a deletion has an empty NewText.
+type TextEdit struct {
+	Start, End int
+	NewText    []byte
+}
+
+// Apply returns a new buffer containing the result of applying the edit to
+// the given source buffer. The given buffer is not modified.
+//
+// Apply panics if the edit's offsets are not within the given buffer.
+func (e TextEdit) Apply(src []byte) []byte {
+	ret := make([]byte, 0, len(src)-(e.End-e.Start)+len(e.NewText))
+	ret = append(ret, src[:e.Start]...)
+	ret = append(ret, e.NewText...)
+	ret = append(ret, src[e.End:]...)
+	return ret
+}
+
+// ReparseConfig is an incremental alternative to ParseConfig, for use in
+// applications such as text editors where a file is parsed again after each
+// small change to its source.
+//
+// The prev and prevSrc arguments are the body from an earlier call to
+// ParseConfig or ReparseConfig and the source buffer it was parsed from,
+// the edit describes a change to that source buffer, and the filename and
+// start position must be the same ones used for the earlier call. The result
+// is the file that ParseConfig would return for the edited source.
+//
+// Only the top-level items affected by the edit are lexed and parsed again,
+// and the ranges of the items after them are adjusted for the change in
+// length. If the edit has effects that cannot be confined to those items,
+// such as opening a multi-line comment, ReparseConfig parses the whole of
+// the edited source instead.
+//
+// The previous body must have been parsed without any error diagnostics,
+// since otherwise it may not faithfully represent the previous source. A
+// caller with a body that had errors should call ParseConfig instead. The
+// previous body is not modified, but the result shares the nodes of any
+// items before the edit with it, so neither should be modified afterwards.
+//
+// If the previous body was produced by ParseConfigWithComments then so is
+// the result, but in that case the whole of the edited source is always
+// parsed, since an edit can change which nearby items comments belong to.
+func ReparseConfig(prev *Body, prevSrc []byte, edit TextEdit, filename string, start hcl.Pos) (*hcl.File, hcl.Diagnostics) {
+	if edit.Start < 0 || edit.End < edit.Start || edit.End > len(prevSrc) {
+		return nil, hcl.Diagnostics{
+			{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid source edit",
+				Detail:   fmt.Sprintf("Cannot replace bytes %d to %d of a %d-byte source buffer.", edit.Start, edit.End, len(prevSrc)),
+			},
+		}
+	}
+
+	src := edit.Apply(prevSrc)
+	if prev.commentsAttached {
+		return ParseConfigWithComments(src, filename, start)
+	}
+	if body, ok := reparseBody(prev, src, edit, filename, start); ok {
+		return &hcl.File{
+			Body:  body,
+			Bytes: src,
+
+			Nav: navigation{
+				root: body,
+			},
+		}, nil
+	}
+	return ParseConfig(src, filename, start)
+}
+
+// reparseBody attempts to produce the body for the given edited source by
+// parsing only the part of it that was affected by the given edit. It returns
+// false if it cannot guarantee the same result as parsing the entire source.
+func reparseBody(prev *Body, src []byte, edit TextEdit, filename string, start hcl.Pos) (*Body, bool) {
+	// We'll sort the previous items into those entirely before the edit,
+	// those entirely after it, and those it affected. An item that the edit
+	// only touches is still affected, since e.g. text inserted immediately
+	// after an attribute's expression becomes part of that expression.
+	var before, after []Node
+	segStart, segStartPos := 0, start
+	segEnd, segEndPos := len(src)-len(edit.NewText)+(edit.End-edit.Start), prev.EndRange.Start
+	for _, item := range sortedBodyItems(prev) {
+		rng := item.Range()
+		switch {
+		case rng.End.Byte < edit.Start:
+			before = append(before, item)
+			if rng.End.Byte > segStart {
+				segStart, segStartPos = rng.End.Byte, rng.End
+			}
+		case rng.Start.Byte > edit.End:
+			after = append(after, item)
+			if rng.Start.Byte < segEnd {
+				segEnd, segEndPos = rng.Start.Byte, rng.Start
+			}
+		}
+	}
+	if len(before) == 0 && len(after) == 0 {
+		// Everything was affected, so there's nothing to gain.
+		return nil, false
+	}
+
+	delta := len(edit.NewText) - (edit.End - edit.Start)
+	seg := src[segStart : segEnd+delta]
+	tokens, diags := LexConfig(seg, filename, segStartPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	// The segment must be separated from the unaffected items by newlines,
+	// as the full source would be, or else the edit has joined the segment
+	// with one of them.
+	if len(before) != 0 && !reparseStartsWithNewline(tokens) {
+		return nil, false
+	}
+	if len(after) != 0 && !reparseEndsWithNewline(tokens) {
+		return nil, false
+	}
+
+	peeker := newPeeker(tokens, false)
+	parser := &parser{peeker: peeker}
+	segBody, diags := parser.ParseBody(TokenEOF)
+	if len(diags) != 0 {
+		return nil, false
+	}
+	peeker.AssertEmptyIncludeNewlinesStack()
+
+	shifter := newRangeShifter(segEndPos, segBody.EndRange.Start)
+	ret := &Body{
+		Attributes: make(Attributes, len(prev.Attributes)),
+		Blocks:     make(Blocks, 0, len(prev.Blocks)),
+	}
+
+	addAttr := func(attr *Attribute) bool {
+		if _, exists := ret.Attributes[attr.Name]; exists {
+			// A full parse would report that this attribute is redefined.
+			return false
+		}
+		ret.Attributes[attr.Name] = attr
+		return true
+	}
+	for _, item := range before {
+		switch item := item.(type) {
+		case *Attribute:
+			ret.Attributes[item.Name] = item
+		case *Block:
+			ret.Blocks = append(ret.Blocks, item)
+		}
+	}
+	for _, item := range sortedBodyItems(segBody) {
+		switch item := item.(type) {
+		case *Attribute:
+			if !addAttr(item) {
+				return nil, false
+			}
+		case *Block:
+			ret.Blocks = append(ret.Blocks, item)
+		}
+	}
+	for _, item := range after {
+		switch item := item.(type) {
+		case *Attribute:
+			if !addAttr(shifter.Attribute(item)) {
+				return nil, false
+			}
+		case *Block:
+			ret.Blocks = append(ret.Blocks, shifter.Block(item))
+		}
+	}
+
+	// The body spans from the first token to the end of file, so we take
+	// each end from the segment if it includes that end of the file.
+	startRange, endRange := prev.SrcRange, shifter.Range(prev.EndRange)
+	if len(before) == 0 {
+		startRange = segBody.SrcRange
+	}
+	if len(after) == 0 {
+		endRange = segBody.EndRange
+	}
+	ret.SrcRange = hcl.RangeBetween(startRange, endRange)
+	ret.EndRange = endRange
+	return ret, true
+}
+
+// reparseStartsWithNewline returns true if the first significant token in
+// the given sequence is a newline, skipping any inline comments before it.
+func reparseStartsWithNewline(tokens Tokens) bool {
+	for _, tok := range tokens {
+		if reparseInlineComment(tok) {
+			continue
+		}
+		return reparseNewline(tok)
+	}
+	return false
+}
+
+// reparseEndsWithNewline returns true if the last significant token in the
+// given sequence, other than the end of file, is a newline, skipping any
+// inline comments after it.
+func reparseEndsWithNewline(tokens Tokens) bool {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		tok := tokens[i]
+		if tok.Type == TokenEOF || reparseInlineComment(tok) {
+			continue
+		}
+		return reparseNewline(tok)
+	}
+	return false
+}
+
+// reparseNewline returns true if the given token is a newline or a
+// single-line comment that absorbed the newline that terminates it. A
+// single-line comment without a newline was terminated by the end of the
+// segment, and so would continue further in the full source.
+func reparseNewline(tok Token) bool {
+	switch tok.Type {
+	case TokenNewline:
+		return true
+	case TokenComment:
+		return len(tok.Bytes) > 0 && tok.Bytes[len(tok.Bytes)-1] == '\n'
+	default:
+		return false
+	}
+}
+
+// reparseInlineComment returns true if the given token is a complete
+// comment of the /* ... */ form.
+func reparseInlineComment(tok Token) bool {
+	return tok.Type == TokenComment && bytes.HasPrefix(tok.Bytes, []byte("/*"))
+}
+
+// rangeShifter produces copies of nodes with their source ranges moved to
+// account for an edit earlier in the source, where the position that was
+// at "from" before the edit is at "to" afterwards.
+//
+// Only positions at or after "from" may be shifted. Since "from" is the start
+// of a top-level item, positions on the same line as it can only be within
+// that item, and so only those positions have their columns adjusted.
+type rangeShifter struct {
+	from, to hcl.Pos
+
+	// anonSymbols maps the symbols of the splat expressions we've copied
+	// to their replacements, so that we can preserve the relationship
+	// between a splat expression and the references to its symbol.
+	anonSymbols map[*AnonSymbolExpr]*AnonSymbolExpr
+}
+
+func newRangeShifter(from, to hcl.Pos) *rangeShifter {
+	return &rangeShifter{
+		from:        from,
+		to:          to,
+		anonSymbols: make(map[*AnonSymbolExpr]*AnonSymbolExpr),
+	}
+}
+
+func (s *rangeShifter) Pos(pos hcl.Pos) hcl.Pos {
+	ret := hcl.Pos{
+		Line:   pos.Line + s.to.Line - s.from.Line,
+		Column: pos.Column,
+		Byte:   pos.Byte + s.to.Byte - s.from.Byte,
+	}
+	if pos.Line == s.from.Line {
+		ret.Column += s.to.Column - s.from.Column
+	}
+	return ret
+}
+
+func (s *rangeShifter) Range(rng hcl.Range) hcl.Range {
+	return hcl.Range{
+		Filename: rng.Filename,
+		Start:    s.Pos(rng.Start),
+		End:      s.Pos(rng.End),
+	}
+}
+
+func (s *rangeShifter) Ranges(rngs []hcl.Range) []hcl.Range {
+	if rngs == nil {
+		return nil
+	}
+	ret := make([]hcl.Range, len(rngs))
+	for i, rng := range rngs {
+		ret[i] = s.Range(rng)
+	}
+	return ret
+}
+
+func (s *rangeShifter) Body(body *Body) *Body {
+	ret := &Body{
+		SrcRange: s.Range(body.SrcRange),
+		EndRange: s.Range(body.EndRange),
+	}
+	if body.Attributes != nil {
+		ret.Attributes = make(Attributes, len(body.Attributes))
+		for name, attr := range body.Attributes {
+			ret.Attributes[name] = s.Attribute(attr)
+		}
+	}
+	if body.Blocks != nil {
+		ret.Blocks = make(Blocks, len(body.Blocks))
+		for i, block := range body.Blocks {
+			ret.Blocks[i] = s.Block(block)
+		}
+	}
+	return ret
+}
+
+func (s *rangeShifter) Attribute(attr *Attribute) *Attribute {
+	return &Attribute{
+		Name: attr.Name,
+		Expr: s.Expr(attr.Expr),
+
+		SrcRange:    s.Range(attr.SrcRange),
+		NameRange:   s.Range(attr.NameRange),
+		EqualsRange: s.Range(attr.EqualsRange),
+	}
+}
+
+func (s *rangeShifter) Block(block *Block) *Block {
+	return &Block{
+		Type:   block.Type,
+		Labels: block.Labels,
+		Body:   s.Body(block.Body),
+
+		TypeRange:       s.Range(block.TypeRange),
+		LabelRanges:     s.Ranges(block.LabelRanges),
+		OpenBraceRange:  s.Range(block.OpenBraceRange),
+		CloseBraceRange: s.Range(block.CloseBraceRange),
+	}
+}
+
+func (s *rangeShifter) Exprs(exprs []Expression) []Expression {
+	if exprs == nil {
+		return nil
+	}
+	ret := make([]Expression, len(exprs))
+	for i, expr := range exprs {
+		ret[i] = s.Expr(expr)
+	}
+	return ret
+}
+
+func (s *rangeShifter) Expr(expr Expression) Expression {
+	switch e := expr.(type) {
+	case nil:
+		return nil
+	case *LiteralValueExpr:
+		return &LiteralValueExpr{
+			Val:      e.Val,
+			SrcRange: s.Range(e.SrcRange),
+		}
+	case *ScopeTraversalExpr:
+		return &ScopeTraversalExpr{
+			Traversal: s.Traversal(e.Traversal),
+			SrcRange:  s.Range(e.SrcRange),
+		}
+	case *RelativeTraversalExpr:
+		return &RelativeTraversalExpr{
+			Source:    s.Expr(e.Source),
+			Traversal: s.Traversal(e.Traversal),
+			SrcRange:  s.Range(e.SrcRange),
+		}
+	case *ParenthesesExpr:
+		return &ParenthesesExpr{
+			Expression: s.Expr(e.Expression),
+			SrcRange:   s.Range(e.SrcRange),
+		}
+	case *FunctionCallExpr:
+		return &FunctionCallExpr{
+			Name:            e.Name,
+			Args:            s.Exprs(e.Args),
+			ExpandFinal:     e.ExpandFinal,
+			NameRange:       s.Range(e.NameRange),
+			OpenParenRange:  s.Range(e.OpenParenRange),
+			CloseParenRange: s.Range(e.CloseParenRange),
+		}
+	case *ConditionalExpr:
+		return &ConditionalExpr{
+			Condition:   s.Expr(e.Condition),
+			TrueResult:  s.Expr(e.TrueResult),
+			FalseResult: s.Expr(e.FalseResult),
+			SrcRange:    s.Range(e.SrcRange),
+		}
+	case *BinaryOpExpr:
+		return &BinaryOpExpr{
+			LHS:      s.Expr(e.LHS),
+			Op:       e.Op,
+			RHS:      s.Expr(e.RHS),
+			SrcRange: s.Range(e.SrcRange),
+		}
+	case *UnaryOpExpr:
+		return &UnaryOpExpr{
+			Op:          e.Op,
+			Val:         s.Expr(e.Val),
+			SrcRange:    s.Range(e.SrcRange),
+			SymbolRange: s.Range(e.SymbolRange),
+		}
+	case *IndexExpr:
+		return &IndexExpr{
+			Collection:   s.Expr(e.Collection),
+			Key:          s.Expr(e.Key),
+			SrcRange:     s.Range(e.SrcRange),
+			OpenRange:    s.Range(e.OpenRange),
+			BracketRange: s.Range(e.BracketRange),
+		}
+	case *TupleConsExpr:
+		return &TupleConsExpr{
+			Exprs:     s.Exprs(e.Exprs),
+			SrcRange:  s.Range(e.SrcRange),
+			OpenRange: s.Range(e.OpenRange),
+		}
+	case *ObjectConsExpr:
+		var items []ObjectConsItem
+		if e.Items != nil {
+			items = make([]ObjectConsItem, len(e.Items))
+			for i, item := range e.Items {
+				items[i] = ObjectConsItem{
+					KeyExpr:   s.Expr(item.KeyExpr),
+					ValueExpr: s.Expr(item.ValueExpr),
+				}
+			}
+		}
+		return &ObjectConsExpr{
+			Items:     items,
+			SrcRange:  s.Range(e.SrcRange),
+			OpenRange: s.Range(e.OpenRange),
+		}
+	case *ObjectConsKeyExpr:
+		return &ObjectConsKeyExpr{
+			Wrapped:         s.Expr(e.Wrapped),
+			ForceNonLiteral: e.ForceNonLiteral,
+		}
+	case *ForExpr:
+		return &ForExpr{
+			KeyVar:     e.KeyVar,
+			ValVar:     e.ValVar,
+			CollExpr:   s.Expr(e.CollExpr),
+			KeyExpr:    s.Expr(e.KeyExpr),
+			ValExpr:    s.Expr(e.ValExpr),
+			CondExpr:   s.Expr(e.CondExpr),
+			Group:      e.Group,
+			SrcRange:   s.Range(e.SrcRange),
+			OpenRange:  s.Range(e.OpenRange),
+			CloseRange: s.Range(e.CloseRange),
+		}
+	case *SplatExpr:
+		// The symbol must be copied before the expression that refers to it.
+		item := s.Expr(e.Item).(*AnonSymbolExpr)
+		return &SplatExpr{
+			Source:      s.Expr(e.Source),
+			Each:        s.Expr(e.Each),
+			Item:        item,
+			SrcRange:    s.Range(e.SrcRange),
+			MarkerRange: s.Range(e.MarkerRange),
+		}
+	case *AnonSymbolExpr:
+		if ret, exists := s.anonSymbols[e]; exists {
+			return ret
+		}
+		ret := &AnonSymbolExpr{
+			SrcRange: s.Range(e.SrcRange),
+		}
+		s.anonSymbols[e] = ret
+		return ret
+	case *TemplateExpr:
+		return &TemplateExpr{
+			Parts:    s.Exprs(e.Parts),
+			SrcRange: s.Range(e.SrcRange),
+		}
+	case *TemplateJoinExpr:
+		return &TemplateJoinExpr{
+			Tuple: s.Expr(e.Tuple),
+		}
+	case *TemplateWrapExpr:
+		return &TemplateWrapExpr{
+			Wrapped:  s.Expr(e.Wrapped),
+			SrcRange: s.Range(e.SrcRange),
+		}
+	case *ExprSyntaxError:
+		return &ExprSyntaxError{
+			Placeholder: e.Placeholder,
+			ParseDiags:  e.ParseDiags,
+			SrcRange:    s.Range(e.SrcRange),
+		}
+	default:
+		// Should never happen, since the above covers all of the expression
+		// types the parser can produce.
+		panic(fmt.Sprintf("unsupported expression type %T", expr))
+	}
+}
+
+func (s *rangeShifter) Traversal(traversal hcl.Traversal) hcl.Traversal {
+	if traversal == nil {
+		return nil
+	}
+	ret := make(hcl.Traversal, len(traversal))
+	for i, step := range traversal {
+		switch ts := step.(type) {
+		case hcl.TraverseRoot:
+			ts.SrcRange = s.Range(ts.SrcRange)
+			ret[i] = ts
+		case hcl.TraverseAttr:
+			ts.SrcRange = s.Range(ts.SrcRange)
+			ret[i] = ts
+		case hcl.TraverseIndex:
+			ts.SrcRange = s.Range(ts.SrcRange)
+			ret[i] = ts
+		case hcl.TraverseSplat:
+			ts.Each = s.Traversal(ts.Each)
+			ts.SrcRange = s.Range(ts.SrcRange)
+			ret[i] = ts
+		default:
+			ret[i] = step
+		}
+	}
+	return ret
+}
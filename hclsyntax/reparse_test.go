@@ -0,0 +1,138 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/hcl/v2"
+)
+
+const reparseTestSrc = `# Leading comment
+name = "example" # trailing comment
+count = 2
+
+/* A block comment
+   over several lines */
+service "web" {
+  image = "web:${count}"
+  ports = [for p in [80, 443] : p + 1]
+
+  nested {
+    ids = var.items[*].id
+    doc = <<EOT
+Hello, ${name}!
+EOT
+  }
+}
+banner = <<-EOT
+  Welcome
+  EOT
+héllo = { a = 1, "b" = !true ? -1 : 2 }
+  indented = upper(name)
+empty {}
+`
+
+// TestReparseConfig checks that ReparseConfig gives the same result as a
+// full parse for a variety of edits at every position in a source file.
+func TestReparseConfig(t *testing.T) {
+	src := []byte(reparseTestSrc)
+	prev, diags := ParseConfig(src, "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	prevBody := prev.Body.(*Body)
+
+	var edits []TextEdit
+	for i := 0; i <= len(src); i++ {
+		for _, text := range []string{"\n", "x", " ", "#", "\"", "}", "{", "a = 1\n", "\nb {}\n"} {
+			edits = append(edits, TextEdit{Start: i, End: i, NewText: []byte(text)})
+		}
+		if i < len(src) {
+			edits = append(edits, TextEdit{Start: i, End: i + 1})
+		}
+		if i+5 <= len(src) {
+			edits = append(edits, TextEdit{Start: i, End: i + 5, NewText: []byte("z")})
+		}
+	}
+
+	for _, edit := range edits {
+		newSrc := edit.Apply(src)
+		want, wantDiags := ParseConfig(newSrc, "test.hcl", hcl.InitialPos)
+		got, gotDiags := ReparseConfig(prevBody, src, edit, "test.hcl", hcl.InitialPos)
+
+		if len(gotDiags) != len(wantDiags) {
+			t.Errorf("wrong diagnostics for %#v\ngot:  %s\nwant: %s", edit, gotDiags.Error(), wantDiags.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got.Body, want.Body) {
+			t.Errorf("wrong result for %#v\ngot:  %s\nwant: %s", edit, spew.Sdump(got.Body), spew.Sdump(want.Body))
+		}
+		if string(got.Bytes) != string(newSrc) {
+			t.Errorf("wrong source for %#v\ngot:  %q\nwant: %q", edit, got.Bytes, newSrc)
+		}
+	}
+}
+
+func TestReparseConfigIncremental(t *testing.T) {
+	src := []byte(reparseTestSrc)
+	prev, diags := ParseConfig(src, "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	prevBody := prev.Body.(*Body)
+
+	tests := []struct {
+		old, new string
+		want     bool
+	}{
+		// Changes within a single item can be handled incrementally.
+		{`count = 2`, `count = 3`, true},
+		{`p + 1`, `p + 2`, true},
+		{`Hello`, `Goodbye`, true},
+		{`empty {}`, "empty {}\nextra = true", true},
+
+		// Changes that affect the items around them cannot.
+		{`# Leading comment`, `/* Leading comment`, false},
+		{`count = 2`, `name = 2`, false},
+		{"2 }\n", `2 }`, false},
+		{`EOT`, `EOF`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s to %s", test.old, test.new), func(t *testing.T) {
+			start := bytes.Index(src, []byte(test.old))
+			if start < 0 {
+				t.Fatalf("%q not found in source", test.old)
+			}
+			edit := TextEdit{Start: start, End: start + len(test.old), NewText: []byte(test.new)}
+			_, got := reparseBody(prevBody, edit.Apply(src), edit, "test.hcl", hcl.InitialPos)
+			if got != test.want {
+				t.Errorf("wrong result %t; want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReparseConfigWithComments(t *testing.T) {
+	src := []byte("# doc\na = 1\n")
+	prev, diags := ParseConfigWithComments(src, "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	edit := TextEdit{Start: 10, End: 11, NewText: []byte("2 # trailing")}
+	got, diags := ReparseConfig(prev.Body.(*Body), src, edit, "test.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	want, _ := ParseConfigWithComments(edit.Apply(src), "test.hcl", hcl.InitialPos)
+	if !reflect.DeepEqual(got.Body, want.Body) {
+		t.Errorf("wrong result\ngot:  %s\nwant: %s", spew.Sdump(got.Body), spew.Sdump(want.Body))
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// RewriteFunc is the callback signature for RewriteAll.
+type RewriteFunc func(node Node) (Node, hcl.Diagnostics)
+
+// RewriteAll is a basic way to rewrite the AST beginning with a particular
+// node. The given function will be called once for each AST node in
+// depth-first order, after its child nodes have been rewritten, and its
+// result replaces the node it was given.
+//
+// The AST is never modified. Instead, each node with a child that was
+// replaced is copied with that child updated, and so RewriteAll returns a
+// new tree that shares any unchanged subtrees with the original. A function
+// that doesn't wish to change a node should return it unchanged.
+//
+// A replacement for an expression must also be an expression, and similarly
+// for bodies, attributes and blocks. The function may return nil for an
+// attribute or block to remove it from its body, but must not return nil for
+// any other node. The function will also be called with the synthetic
+// Attributes, Blocks and ChildScope nodes that appear in walks, which it
+// should generally return unchanged. A replacement that breaks these rules
+// produces an error diagnostic and is ignored, keeping the original node, as
+// is a renamed attribute whose new name is already used in its body.
+//
+// The RewriteFunc may return diagnostics, in which case they will be
+// accumulated and returned as a single set.
+func RewriteAll(node Node, f RewriteFunc) (Node, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var rewrite internalRewriteFunc
+	rewrite = func(node Node) Node {
+		node, moreDiags := rewriteChildNodes(node, rewrite)
+		diags = append(diags, moreDiags...)
+		newNode, moreDiags := f(node)
+		diags = append(diags, moreDiags...)
+		return checkReplacement(node, newNode, &diags)
+	}
+	return rewrite(node), diags
+}
+
+// Rewriter is an interface used with Rewrite.
+type Rewriter interface {
+	// Enter is called with each node before its child nodes are rewritten.
+	Enter(node Node) hcl.Diagnostics
+
+	// Exit is called with each node after its child nodes have been
+	// rewritten, and returns the node that should replace it, following
+	// the same rules as the function given to RewriteAll.
+	Exit(node Node) (Node, hcl.Diagnostics)
+}
+
+// Rewrite is a more complex way to rewrite the AST starting with a particular
+// node, which provides information about the tree structure via separate
+// Enter and Exit functions. For example, a rewriter can track the ChildScope
+// nodes it enters in order to avoid changing references to local symbols.
+//
+// The result is as for RewriteAll.
+func Rewrite(node Node, r Rewriter) (Node, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var rewrite internalRewriteFunc
+	rewrite = func(node Node) Node {
+		diags = append(diags, r.Enter(node)...)
+		node, moreDiags := rewriteChildNodes(node, rewrite)
+		diags = append(diags, moreDiags...)
+		newNode, moreDiags := r.Exit(node)
+		diags = append(diags, moreDiags...)
+		return checkReplacement(node, newNode, &diags)
+	}
+	return rewrite(node), diags
+}
+
+type internalRewriteFunc func(Node) Node
+
+// rewriteChildNodes rewrites the children of the given node, returning
+// diagnostics for any conflicts between the rewritten children.
+func rewriteChildNodes(node Node, r internalRewriteFunc) (Node, hcl.Diagnostics) {
+	if attrs, ok := node.(Attributes); ok {
+		return attrs.rewriteAttributes(r)
+	}
+	return node.rewriteChildNodes(r), nil
+}
+
+// checkReplacement returns the given replacement for the given node if it
+// follows the rules described for RewriteAll. Otherwise, it appends an error
+// diagnostic to diags and returns the original node.
+func checkReplacement(node, newNode Node, diags *hcl.Diagnostics) Node {
+	var ok bool
+	switch node.(type) {
+	case *Attribute:
+		_, ok = newNode.(*Attribute)
+		ok = ok || newNode == nil
+	case *Block:
+		_, ok = newNode.(*Block)
+		ok = ok || newNode == nil
+	case *Body:
+		body, isBody := newNode.(*Body)
+		ok = isBody && body != nil
+	case Attributes:
+		_, ok = newNode.(Attributes)
+	case Blocks:
+		_, ok = newNode.(Blocks)
+	case ChildScope:
+		scope, isScope := newNode.(ChildScope)
+		ok = isScope && scope.Expr != nil
+	default:
+		_, ok = newNode.(Expression)
+	}
+	if ok {
+		return newNode
+	}
+
+	*diags = append(*diags, &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid replacement node",
+		Detail:   fmt.Sprintf("A rewrite replaced a %T node with %T, which is not allowed in its place.", node, newNode),
+		Subject:  node.Range().Ptr(),
+	})
+	return node
+}
+
+func rewriteExpr(r internalRewriteFunc, expr Expression) Expression {
+	node := r(expr)
+	ret, ok := node.(Expression)
+	if !ok || ret == nil {
+		panic(fmt.Sprintf("cannot replace %T with %T", expr, node))
+	}
+	return ret
+}
+
+// rewriteExprs rewrites each of the given expressions, returning a new slice
+// and true if any of them changed, or the given slice and false otherwise.
+func rewriteExprs(r internalRewriteFunc, exprs []Expression) ([]Expression, bool) {
+	var ret []Expression
+	for i, expr := range exprs {
+		newExpr := rewriteExpr(r, expr)
+		if ret == nil && newExpr != expr {
+			ret = make([]Expression, len(exprs))
+			copy(ret, exprs[:i])
+		}
+		if ret != nil {
+			ret[i] = newExpr
+		}
+	}
+	if ret == nil {
+		return exprs, false
+	}
+	return ret, true
+}
+
+// rewriteChildScope rewrites an expression that is evaluated in a child
+// scope with the given local names, via a synthetic ChildScope node as in
+// walkChildNodes.
+func rewriteChildScope(r internalRewriteFunc, names map[string]struct{}, expr Expression) Expression {
+	node := r(ChildScope{
+		LocalNames: names,
+		Expr:       expr,
+	})
+	scope, ok := node.(ChildScope)
+	if !ok || scope.Expr == nil {
+		panic(fmt.Sprintf("cannot replace ChildScope with %T", node))
+	}
+	return scope.Expr
+}
+
+func rewriteAttributes(r internalRewriteFunc, attrs Attributes) Attributes {
+	node := r(attrs)
+	ret, ok := node.(Attributes)
+	if !ok {
+		panic(fmt.Sprintf("cannot replace Attributes with %T", node))
+	}
+	return ret
+}
+
+func rewriteBlocks(r internalRewriteFunc, blocks Blocks) Blocks {
+	node := r(blocks)
+	ret, ok := node.(Blocks)
+	if !ok {
+		panic(fmt.Sprintf("cannot replace Blocks with %T", node))
+	}
+	return ret
+}
+
+func sameAttributes(a, b Attributes) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, attr := range a {
+		if b[name] != attr {
+			return false
+		}
+	}
+	return true
+}
+
+func sameBlocks(a, b Blocks) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclsyntax
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestRewriteAll(t *testing.T) {
+	src := `
+a = x + 1
+b = [x, y, "${x}"]
+c = y
+
+block "label" {
+  d = { key = x }
+}
+`
+	file, diags := ParseConfig([]byte(src), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	body := file.Body.(*Body)
+
+	// Replace every reference to x with the number 2, and remove c.
+	newNode, diags := RewriteAll(body, func(node Node) (Node, hcl.Diagnostics) {
+		switch node := node.(type) {
+		case *ScopeTraversalExpr:
+			if node.Traversal.RootName() == "x" {
+				return &LiteralValueExpr{
+					Val:      cty.NumberIntVal(2),
+					SrcRange: node.SrcRange,
+				}, nil
+			}
+		case *Attribute:
+			if node.Name == "c" {
+				return nil, nil
+			}
+		}
+		return node, nil
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	newBody := newNode.(*Body)
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"x": cty.NumberIntVal(1),
+			"y": cty.StringVal("y"),
+		},
+	}
+	tests := []struct {
+		expr Expression
+		want cty.Value
+	}{
+		{newBody.Attributes["a"].Expr, cty.NumberIntVal(3)},
+		{newBody.Attributes["b"].Expr, cty.TupleVal([]cty.Value{cty.NumberIntVal(2), cty.StringVal("y"), cty.NumberIntVal(2)})},
+		{newBody.Blocks[0].Body.Attributes["d"].Expr, cty.ObjectVal(map[string]cty.Value{"key": cty.NumberIntVal(2)})},
+
+		// The original tree must be unchanged.
+		{body.Attributes["a"].Expr, cty.NumberIntVal(2)},
+		{body.Blocks[0].Body.Attributes["d"].Expr, cty.ObjectVal(map[string]cty.Value{"key": cty.NumberIntVal(1)})},
+	}
+	for _, test := range tests {
+		got, diags := test.expr.Value(ctx)
+		if diags.HasErrors() {
+			t.Errorf("unexpected problems: %s", diags.Error())
+			continue
+		}
+		if !got.RawEquals(test.want) {
+			t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+		}
+	}
+
+	if _, exists := newBody.Attributes["c"]; exists {
+		t.Errorf("attribute c was not removed")
+	}
+	if _, exists := body.Attributes["c"]; !exists {
+		t.Errorf("attribute c was removed from the original body")
+	}
+	if got, want := newBody.Blocks[0].Labels[0], "label"; got != want {
+		t.Errorf("wrong block label %q; want %q", got, want)
+	}
+}
+
+func TestRewriteAllUnchanged(t *testing.T) {
+	file, diags := ParseConfig([]byte("a = [1, 2]\nb {\n  c = d\n}\n"), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	body := file.Body.(*Body)
+
+	got, diags := RewriteAll(body, func(node Node) (Node, hcl.Diagnostics) {
+		return node, nil
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	if got != Node(body) {
+		t.Errorf("body was copied even though nothing changed")
+	}
+}
+
+func TestRewriteAllInvalid(t *testing.T) {
+	file, diags := ParseConfig([]byte("a = 1\nb = 2\nc {\n  d = 3\n}\n"), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	body := file.Body.(*Body)
+
+	tests := map[string]struct {
+		f         RewriteFunc
+		want      []string // diagnostic summaries
+		wantAttrs []string
+	}{
+		"rename onto existing attribute": {
+			func(node Node) (Node, hcl.Diagnostics) {
+				if attr, ok := node.(*Attribute); ok && attr.Name == "b" {
+					ret := *attr
+					ret.Name = "a"
+					return &ret, nil
+				}
+				return node, nil
+			},
+			[]string{"Attribute redefined"},
+			[]string{"a"},
+		},
+		"expression replaced with attribute": {
+			func(node Node) (Node, hcl.Diagnostics) {
+				if _, ok := node.(*LiteralValueExpr); ok {
+					return &Attribute{Name: "x"}, nil
+				}
+				return node, nil
+			},
+			[]string{"Invalid replacement node", "Invalid replacement node", "Invalid replacement node"},
+			[]string{"a", "b"},
+		},
+		"attribute replaced with block": {
+			func(node Node) (Node, hcl.Diagnostics) {
+				if attr, ok := node.(*Attribute); ok && attr.Name == "d" {
+					return &Block{Type: "x"}, nil
+				}
+				return node, nil
+			},
+			[]string{"Invalid replacement node"},
+			[]string{"a", "b"},
+		},
+		"body removed": {
+			func(node Node) (Node, hcl.Diagnostics) {
+				if _, ok := node.(*Body); ok {
+					return nil, nil
+				}
+				return node, nil
+			},
+			[]string{"Invalid replacement node", "Invalid replacement node"},
+			[]string{"a", "b"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, diags := RewriteAll(body, test.f)
+			var summaries []string
+			for _, diag := range diags {
+				summaries = append(summaries, diag.Summary)
+			}
+			if !reflect.DeepEqual(summaries, test.want) {
+				t.Fatalf("wrong diagnostics %#v; want %#v\n%s", summaries, test.want, diags.Error())
+			}
+
+			// The invalid replacements are ignored, keeping the original
+			// nodes, and a renamed attribute that collides with another is
+			// dropped in favor of the one that appears first.
+			newBody := got.(*Body)
+			var attrs []string
+			for name := range newBody.Attributes {
+				attrs = append(attrs, name)
+			}
+			sort.Strings(attrs)
+			if !reflect.DeepEqual(attrs, test.wantAttrs) {
+				t.Errorf("wrong attributes %#v; want %#v", attrs, test.wantAttrs)
+			}
+			if val, _ := newBody.Attributes["a"].Expr.Value(nil); !val.RawEquals(cty.NumberIntVal(1)) {
+				t.Errorf("wrong value for a: %#v", val)
+			}
+			if _, exists := newBody.Blocks[0].Body.Attributes["d"]; !exists {
+				t.Errorf("attribute d was removed")
+			}
+		})
+	}
+}
+
+// testRenameRewriter renames references to a variable, except where the
+// name refers to a symbol of a for expression.
+type testRenameRewriter struct {
+	from, to string
+	scopes   []map[string]struct{}
+}
+
+func (r *testRenameRewriter) Enter(node Node) hcl.Diagnostics {
+	if scope, ok := node.(ChildScope); ok {
+		r.scopes = append(r.scopes, scope.LocalNames)
+	}
+	return nil
+}
+
+func (r *testRenameRewriter) Exit(node Node) (Node, hcl.Diagnostics) {
+	switch node := node.(type) {
+	case ChildScope:
+		r.scopes = r.scopes[:len(r.scopes)-1]
+	case *ScopeTraversalExpr:
+		if node.Traversal.RootName() != r.from {
+			break
+		}
+		for _, scope := range r.scopes {
+			if _, local := scope[r.from]; local {
+				return node, nil
+			}
+		}
+		traversal := make(hcl.Traversal, len(node.Traversal))
+		copy(traversal, node.Traversal)
+		root := traversal[0].(hcl.TraverseRoot)
+		root.Name = r.to
+		traversal[0] = root
+		return &ScopeTraversalExpr{
+			Traversal: traversal,
+			SrcRange:  node.SrcRange,
+		}, nil
+	}
+	return node, nil
+}
+
+func TestRewrite(t *testing.T) {
+	expr, diags := ParseExpression([]byte(`[for v in v : v if v != old.v] == old.list`), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	// Only the references outside of the for expression's scope should be
+	// renamed.
+	got, diags := Rewrite(expr, &testRenameRewriter{from: "v", to: "renamed"})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	var gotNames []string
+	for _, traversal := range Variables(got.(Expression)) {
+		gotNames = append(gotNames, traversal.RootName())
+	}
+	want := []string{"renamed", "old", "old"}
+	if len(gotNames) != len(want) {
+		t.Fatalf("wrong variables %#v; want %#v", gotNames, want)
+	}
+	for i := range want {
+		if gotNames[i] != want[i] {
+			t.Errorf("wrong variables %#v; want %#v", gotNames, want)
+			break
+		}
+	}
+}
@@ -5,6 +5,7 @@ package hclsyntax
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -37,6 +38,15 @@ type Body struct {
 	hiddenAttrs  map[string]struct{}
 	hiddenBlocks map[string]struct{}
 
+	// Comments are the comments within the body that are not associated
+	// with any of its items. Comments are recorded only by
+	// ParseConfigWithComments, and are nil otherwise.
+	Comments []Comment
+
+	// commentsAttached is set on the root body of a file produced by
+	// ParseConfigWithComments.
+	commentsAttached bool
+
 	SrcRange hcl.Range
 	EndRange hcl.Range // Final token of the body (zero-length range)
 }
@@ -49,10 +59,38 @@ func (b *Body) walkChildNodes(w internalWalkFunc) {
 	w(b.Blocks)
 }
 
+func (b *Body) rewriteChildNodes(r internalRewriteFunc) Node {
+	attrs := rewriteAttributes(r, b.Attributes)
+	blocks := rewriteBlocks(r, b.Blocks)
+	if sameAttributes(attrs, b.Attributes) && sameBlocks(blocks, b.Blocks) {
+		return b
+	}
+	ret := *b
+	ret.Attributes = attrs
+	ret.Blocks = blocks
+	return &ret
+}
+
 func (b *Body) Range() hcl.Range {
 	return b.SrcRange
 }
 
+// sortedBodyItems returns the attributes and blocks of the given body in the
+// order they appear in the source.
+func sortedBodyItems(body *Body) []Node {
+	items := make([]Node, 0, len(body.Attributes)+len(body.Blocks))
+	for _, attr := range body.Attributes {
+		items = append(items, attr)
+	}
+	for _, block := range body.Blocks {
+		items = append(items, block)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Range().Start.Byte < items[j].Range().Start.Byte
+	})
+	return items
+}
+
 func (b *Body) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
 	content, remainHCL, diags := b.PartialContent(schema)
 
@@ -233,6 +271,8 @@ func (b *Body) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Bod
 		hiddenAttrs:  hiddenAttrs,
 		hiddenBlocks: hiddenBlocks,
 
+		Comments: b.Comments,
+
 		SrcRange: b.SrcRange,
 		EndRange: b.EndRange,
 	}
@@ -276,6 +316,33 @@ func (b *Body) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
 	return attrs, diags
 }
 
+// RemainingItems returns the attributes and blocks in the body that have not
+// been consumed by an earlier call to PartialContent.
+//
+// This is the implementation of hcl.RemainingItems for native syntax bodies.
+func (b *Body) RemainingItems() []hcl.BodyItem {
+	var items []hcl.BodyItem
+	for name, attr := range b.Attributes {
+		if _, hidden := b.hiddenAttrs[name]; hidden {
+			continue
+		}
+		items = append(items, hcl.BodyItem{
+			Name:  name,
+			Range: attr.NameRange,
+		})
+	}
+	for _, block := range b.Blocks {
+		if _, hidden := b.hiddenBlocks[block.Type]; hidden {
+			continue
+		}
+		items = append(items, hcl.BodyItem{
+			Name:  block.Type,
+			Range: block.DefRange(),
+		})
+	}
+	return items
+}
+
 func (b *Body) MissingItemRange() hcl.Range {
 	return hcl.Range{
 		Filename: b.SrcRange.Filename,
@@ -293,6 +360,54 @@ func (a Attributes) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (a Attributes) rewriteChildNodes(r internalRewriteFunc) Node {
+	ret, _ := a.rewriteAttributes(r)
+	return ret
+}
+
+// rewriteAttributes is the implementation of rewriteChildNodes, which also
+// returns an error diagnostic for each attribute whose new name is already
+// used by another attribute. In that case the attribute that appears first
+// in the source is kept.
+func (a Attributes) rewriteAttributes(r internalRewriteFunc) (Attributes, hcl.Diagnostics) {
+	changed := false
+	newAttrs := make([]*Attribute, 0, len(a))
+	for _, attr := range a {
+		newAttr, _ := r(attr).(*Attribute)
+		if newAttr != attr {
+			changed = true
+		}
+		if newAttr != nil {
+			newAttrs = append(newAttrs, newAttr)
+		}
+	}
+	if !changed {
+		return a, nil
+	}
+
+	sort.Slice(newAttrs, func(i, j int) bool {
+		return newAttrs[i].SrcRange.Start.Byte < newAttrs[j].SrcRange.Start.Byte
+	})
+	var diags hcl.Diagnostics
+	ret := make(Attributes, len(newAttrs))
+	for _, attr := range newAttrs {
+		if existing, exists := ret[attr.Name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Attribute redefined",
+				Detail: fmt.Sprintf(
+					"A rewrite produced a second argument named %q, which was already set at %s. Each argument may be set only once.",
+					attr.Name, existing.NameRange.String(),
+				),
+				Subject: &attr.NameRange,
+			})
+			continue
+		}
+		ret[attr.Name] = attr
+	}
+	return ret, diags
+}
+
 // Range returns the range of some arbitrary point within the set of
 // attributes, or an invalid range if there are no attributes.
 //
@@ -317,6 +432,10 @@ type Attribute struct {
 	Name string
 	Expr Expression
 
+	// Comments are the comments associated with the attribute, if it was
+	// parsed by ParseConfigWithComments and any comments were found.
+	Comments *Comments
+
 	SrcRange    hcl.Range
 	NameRange   hcl.Range
 	EqualsRange hcl.Range
@@ -326,6 +445,16 @@ func (a *Attribute) walkChildNodes(w internalWalkFunc) {
 	w(a.Expr)
 }
 
+func (a *Attribute) rewriteChildNodes(r internalRewriteFunc) Node {
+	expr := rewriteExpr(r, a.Expr)
+	if expr == a.Expr {
+		return a
+	}
+	ret := *a
+	ret.Expr = expr
+	return &ret
+}
+
 func (a *Attribute) Range() hcl.Range {
 	return a.SrcRange
 }
@@ -353,6 +482,24 @@ func (bs Blocks) walkChildNodes(w internalWalkFunc) {
 	}
 }
 
+func (bs Blocks) rewriteChildNodes(r internalRewriteFunc) Node {
+	var ret Blocks
+	for i, block := range bs {
+		newBlock, _ := r(block).(*Block)
+		if ret == nil && newBlock != block {
+			ret = make(Blocks, i, len(bs))
+			copy(ret, bs[:i])
+		}
+		if ret != nil && newBlock != nil {
+			ret = append(ret, newBlock)
+		}
+	}
+	if ret == nil {
+		return bs
+	}
+	return ret
+}
+
 // Range returns the range of some arbitrary point within the list of
 // blocks, or an invalid range if there are no blocks.
 //
@@ -373,6 +520,10 @@ type Block struct {
 	Labels []string
 	Body   *Body
 
+	// Comments are the comments associated with the block, if it was parsed
+	// by ParseConfigWithComments and any comments were found.
+	Comments *Comments
+
 	TypeRange       hcl.Range
 	LabelRanges     []hcl.Range
 	OpenBraceRange  hcl.Range
@@ -383,6 +534,19 @@ func (b *Block) walkChildNodes(w internalWalkFunc) {
 	w(b.Body)
 }
 
+func (b *Block) rewriteChildNodes(r internalRewriteFunc) Node {
+	body, ok := r(b.Body).(*Body)
+	if !ok || body == nil {
+		panic(fmt.Sprintf("cannot replace the body of a block with %T", body))
+	}
+	if body == b.Body {
+		return b
+	}
+	ret := *b
+	ret.Body = body
+	return &ret
+}
+
 func (b *Block) Range() hcl.Range {
 	return hcl.RangeBetween(b.TypeRange, b.CloseBraceRange)
 }
@@ -83,6 +83,13 @@ func (e ChildScope) walkChildNodes(w internalWalkFunc) {
 	w(e.Expr)
 }
 
+func (e ChildScope) rewriteChildNodes(r internalRewriteFunc) Node {
+	return ChildScope{
+		LocalNames: e.LocalNames,
+		Expr:       rewriteExpr(r, e.Expr),
+	}
+}
+
 // Range returns the range of the expression that the ChildScope is
 // encapsulating. It isn't really very useful to call Range on a ChildScope.
 func (e ChildScope) Range() hcl.Range {
@@ -197,11 +197,8 @@ type mockExprVariable string
 
 func (e mockExprVariable) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
 	name := string(e)
-	for ctx != nil {
-		if val, ok := ctx.Variables[name]; ok {
-			return val, nil
-		}
-		ctx = ctx.Parent()
+	if val, ok, diags := ctx.LookupVariable(name, hcl.Range{}); ok {
+		return val, diags
 	}
 
 	// If we fall out here then there is no variable with the given name
@@ -6,6 +6,9 @@ package hclwrite
 import (
 	"bytes"
 	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
 type File struct {
@@ -68,6 +71,115 @@ func (c *comments) BuildTokens(to Tokens) Tokens {
 	return c.tokens.BuildTokens(to)
 }
 
+// list returns the source of each of the comments, excluding the newlines
+// that terminate single-line comments.
+func (c *comments) list() []string {
+	var ret []string
+	for _, tok := range c.tokens {
+		if tok.Type == hclsyntax.TokenComment {
+			ret = append(ret, strings.TrimRight(string(tok.Bytes), "\r\n"))
+		}
+	}
+	return ret
+}
+
+// setLineComment replaces the comments of the given comments node, which
+// must be followed by the end of a line, with the given line comment.
+func setLineComment(n *node, comment string) {
+	c := n.content.(*comments)
+
+	// The newline that ends the line is either consumed by an existing
+	// single-line comment or held by the node that follows, so we take
+	// ownership of it here to allow a new single-line comment to consume it
+	// in turn, as the parser would produce.
+	var newline bool
+	for _, tok := range c.tokens {
+		if tok.Type == hclsyntax.TokenNewline || bytes.HasSuffix(tok.Bytes, []byte{'\n'}) {
+			newline = true
+		}
+	}
+	if next := n.after; next != nil {
+		if toks, ok := next.content.(Tokens); ok && len(toks) == 1 && toks[0].Type == hclsyntax.TokenNewline {
+			newline = true
+			next.Detach()
+		}
+	}
+
+	if comment == "" {
+		c.tokens = nil
+		if newline {
+			c.tokens = Tokens{
+				{
+					Type:  hclsyntax.TokenNewline,
+					Bytes: []byte{'\n'},
+				},
+			}
+		}
+		return
+	}
+	c.tokens = commentTokens(comment, newline)
+	c.tokens[0].SpacesBefore = 1
+}
+
+// leadCommentTokens returns the tokens for the given comments, each written
+// on its own line, as described for Attribute.SetLeadComments.
+func leadCommentTokens(comments []string, indent int) Tokens {
+	var ret Tokens
+	for _, comment := range comments {
+		comment = strings.TrimRight(comment, "\r\n")
+		if !hasCommentDelimiter(comment) {
+			// Plain text might have multiple lines, each of which becomes
+			// a separate comment.
+			for _, line := range strings.Split(comment, "\n") {
+				ret = append(ret, commentTokens(line, true)...)
+			}
+			continue
+		}
+		// The parser associates a multi-line comment with the following
+		// item only if nothing but other comments separate them, so we
+		// write such comments on the same line as whatever follows.
+		ret = append(ret, commentTokens(comment, !strings.HasPrefix(comment, "/*"))...)
+	}
+	for _, tok := range ret {
+		if tok.Type == hclsyntax.TokenComment {
+			tok.SpacesBefore = indent
+		}
+	}
+	return ret
+}
+
+// commentTokens returns the tokens for a single comment, optionally followed
+// by a newline. Comment text without a delimiter is written as a "#" comment.
+func commentTokens(comment string, newline bool) Tokens {
+	comment = strings.TrimRight(comment, "\r\n")
+	if !hasCommentDelimiter(comment) {
+		comment = strings.TrimRight("# "+comment, " ")
+	}
+	tok := &Token{
+		Type:  hclsyntax.TokenComment,
+		Bytes: []byte(comment),
+	}
+	if !newline {
+		return Tokens{tok}
+	}
+	if strings.HasPrefix(comment, "/*") {
+		return Tokens{
+			tok,
+			{
+				Type:  hclsyntax.TokenNewline,
+				Bytes: []byte{'\n'},
+			},
+		}
+	}
+	// Single-line comments consume the newline that terminates them.
+	tok.Bytes = append(tok.Bytes, '\n')
+	return Tokens{tok}
+}
+
+func hasCommentDelimiter(comment string) bool {
+	return strings.HasPrefix(comment, "#") || strings.HasPrefix(comment, "//") || strings.HasPrefix(comment, "/*")
+}
+
 type identifier struct {
 	leafNode
 
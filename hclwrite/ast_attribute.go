@@ -4,6 +4,9 @@
 package hclwrite
 
 import (
+	"bytes"
+	"strings"
+
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
@@ -38,6 +41,12 @@ func (a *Attribute) init(name string, expr *Expression) {
 	a.expr = a.children.Append(expr)
 	a.expr.list = a.children
 	a.lineComments = a.children.Append(newComments(nil))
+
+	// An expression that ends with a heredoc already includes the newline
+	// that terminates the attribute.
+	if toks := expr.BuildTokens(nil); len(toks) != 0 && bytes.HasSuffix(toks[len(toks)-1].Bytes, []byte{'\n'}) {
+		return
+	}
 	a.children.AppendUnstructuredTokens(Tokens{
 		{
 			Type:  hclsyntax.TokenNewline,
@@ -46,10 +55,54 @@ func (a *Attribute) init(name string, expr *Expression) {
 	})
 }
 
+// Name returns the name of the attribute.
+func (a *Attribute) Name() string {
+	return string(a.name.content.(*identifier).token.Bytes)
+}
+
 func (a *Attribute) Expr() *Expression {
 	return a.expr.content.(*Expression)
 }
 
+// LeadComments returns the source of each of the comments on the lines
+// immediately before the attribute, including their comment delimiters but
+// excluding the newlines that terminate them.
+func (a *Attribute) LeadComments() []string {
+	return a.leadComments.content.(*comments).list()
+}
+
+// SetLeadComments replaces the comments on the lines immediately before the
+// attribute with the given comments, each written on its own line.
+//
+// Each comment may be either the complete source of a comment, including its
+// "#", "//" or "/*" delimiter, or plain text, which is written as one or more
+// "#" comments. A "/*" comment is written on the same line as whatever
+// follows it, since that is the only way it can be recognized as a lead
+// comment when the result is parsed again. Passing no comments removes any
+// existing lead comments.
+func (a *Attribute) SetLeadComments(lines []string) {
+	indent := a.name.content.(*identifier).token.SpacesBefore
+	a.leadComments.content.(*comments).tokens = leadCommentTokens(lines, indent)
+}
+
+// LineComment returns the source of the comment after the attribute on the
+// same line, including its comment delimiter, or an empty string if there is
+// no such comment. If there are multiple comments on the line then they are
+// separated by spaces.
+func (a *Attribute) LineComment() string {
+	return strings.Join(a.lineComments.content.(*comments).list(), " ")
+}
+
+// SetLineComment replaces the comment after the attribute on the same line
+// with the given comment, which may be either the complete source of a
+// comment or plain text to write as a "#" comment, as with SetLeadComments.
+// The comment must not contain newlines unless it is a "/*" comment.
+//
+// Passing an empty string removes any existing line comment.
+func (a *Attribute) SetLineComment(comment string) {
+	setLineComment(a.lineComments, comment)
+}
+
 // setName updates the name of the attribute.
 func (a *Attribute) setName(name string) {
 	nameObj := newIdentifier(newIdentToken(name))
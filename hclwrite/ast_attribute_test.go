@@ -0,0 +1,135 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAttributeComments(t *testing.T) {
+	tests := map[string]struct {
+		src      string
+		attr     string // the attribute to use, or empty for "a"
+		wantLead []string
+		wantLine string
+		setLead  []string
+		setLine  string
+		want     string
+		readOnly bool // if set, the comments are read but not changed
+	}{
+		"none": {
+			src:      "a = 1\n",
+			wantLead: nil,
+			wantLine: "",
+			setLead:  []string{"managed by tool X"},
+			setLine:  "do not edit",
+			want:     "# managed by tool X\na = 1 # do not edit\n",
+		},
+		"replace": {
+			src:      "# old\n// older\na = 1 // trailing\nb = 2\n",
+			wantLead: []string{"# old", "// older"},
+			wantLine: "// trailing",
+			setLead:  []string{"// new"},
+			setLine:  "/* inline */",
+			want:     "// new\na = 1 /* inline */\nb = 2\n",
+		},
+		"remove": {
+			src:      "# old\na = 1 # trailing\nb = 2\n",
+			wantLead: []string{"# old"},
+			wantLine: "# trailing",
+			setLead:  nil,
+			setLine:  "",
+			want:     "a = 1\nb = 2\n",
+		},
+		"multi-line text": {
+			src:     "a = 1\n",
+			setLead: []string{"first\nsecond", "/* block */"},
+			setLine: "x",
+			want:    "# first\n# second\n/* block */ a = 1 # x\n",
+		},
+		"no final newline": {
+			src:     "a = 1",
+			setLine: "x",
+			want:    "a = 1 # x",
+		},
+		"multiple line comments": {
+			src:      "a = 1 /* x */ # y\n",
+			wantLine: "/* x */ # y",
+			readOnly: true,
+			want:     "a = 1 /* x */ # y\n",
+		},
+		"aligned": {
+			src:      "long_name = 1 # one\nb = 2\n",
+			attr:     "b",
+			wantLine: "",
+			setLine:  "two",
+			want:     "long_name = 1 # one\nb         = 2 # two\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, diags := ParseConfig([]byte(test.src), "", hcl.Pos{Line: 1, Column: 1})
+			if len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+
+			attrName := test.attr
+			if attrName == "" {
+				attrName = "a"
+			}
+			attr := f.Body().GetAttribute(attrName)
+			if diff := cmp.Diff(test.wantLead, attr.LeadComments()); diff != "" {
+				t.Errorf("wrong lead comments\n%s", diff)
+			}
+			if got := attr.LineComment(); got != test.wantLine {
+				t.Errorf("wrong line comment %q; want %q", got, test.wantLine)
+			}
+
+			if !test.readOnly {
+				attr.SetLeadComments(test.setLead)
+				attr.SetLineComment(test.setLine)
+			}
+			got := string(f.Bytes())
+			if got != test.want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+			if formatted := string(Format([]byte(got))); formatted != got {
+				t.Errorf("result is not formatted\ngot:\n%s\nformatted:\n%s", got, formatted)
+			}
+
+			// The result must also parse with the comments where we put them.
+			f2, diags := ParseConfig([]byte(got), "", hcl.Pos{Line: 1, Column: 1})
+			if len(diags) != 0 {
+				t.Fatalf("result has diagnostics: %s", diags.Error())
+			}
+			attr2 := f2.Body().GetAttribute(attrName)
+			if diff := cmp.Diff(attr.LeadComments(), attr2.LeadComments()); diff != "" {
+				t.Errorf("lead comments not preserved after parsing\n%s", diff)
+			}
+			if attr.LineComment() != attr2.LineComment() {
+				t.Errorf("line comment %q not preserved after parsing; got %q", attr.LineComment(), attr2.LineComment())
+			}
+		})
+	}
+}
+
+func TestAttributeCommentsNew(t *testing.T) {
+	f := NewEmptyFile()
+	body := f.Body()
+	block := body.AppendNewBlock("b", nil)
+	attr := block.Body().SetAttributeValue("a", cty.NumberIntVal(1))
+	attr.SetLeadComments([]string{"lead"})
+	attr.SetLineComment("line")
+	attr.SetLineComment("replaced")
+
+	want := "b {\n  # lead\n  a = 1 # replaced\n}\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
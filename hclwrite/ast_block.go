@@ -4,6 +4,8 @@
 package hclwrite
 
 import (
+	"strings"
+
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -17,6 +19,7 @@ type Block struct {
 	open         *node
 	body         *node
 	close        *node
+	lineComments *node
 }
 
 func newBlock() *Block {
@@ -56,6 +59,9 @@ func (b *Block) init(typeName string, labels []string) {
 			Type:  hclsyntax.TokenCBrace,
 			Bytes: []byte{'}'},
 		},
+	})
+	b.lineComments = b.children.Append(newComments(nil))
+	b.children.AppendUnstructuredTokens(Tokens{
 		{
 			Type:  hclsyntax.TokenNewline,
 			Bytes: []byte{'\n'},
@@ -84,6 +90,33 @@ func (b *Block) SetType(typeName string) {
 	b.typeName.ReplaceWith(nameObj)
 }
 
+// LeadComments returns the source of each of the comments on the lines
+// immediately before the block, including their comment delimiters but
+// excluding the newlines that terminate them.
+func (b *Block) LeadComments() []string {
+	return b.leadComments.content.(*comments).list()
+}
+
+// SetLeadComments replaces the comments on the lines immediately before the
+// block with the given comments, each written on its own line, as described
+// for Attribute.SetLeadComments.
+func (b *Block) SetLeadComments(lines []string) {
+	indent := b.typeName.content.(*identifier).token.SpacesBefore
+	b.leadComments.content.(*comments).tokens = leadCommentTokens(lines, indent)
+}
+
+// LineComment returns the source of the comment after the closing brace of
+// the block on the same line, as described for Attribute.LineComment.
+func (b *Block) LineComment() string {
+	return strings.Join(b.lineComments.content.(*comments).list(), " ")
+}
+
+// SetLineComment replaces the comment after the closing brace of the block
+// on the same line, as described for Attribute.SetLineComment.
+func (b *Block) SetLineComment(comment string) {
+	setLineComment(b.lineComments, comment)
+}
+
 // Labels returns the labels of the block.
 func (b *Block) Labels() []string {
 	return b.labelsObj().Current()
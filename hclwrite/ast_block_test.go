@@ -487,3 +487,57 @@ func TestBlockSetLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockComments(t *testing.T) {
+	src := `# lead
+resource "a" "b" {
+  attr = 1
+} # after
+
+other {}
+`
+	f, diags := ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	blocks := f.Body().Blocks()
+
+	if diff := cmp.Diff([]string{"# lead"}, blocks[0].LeadComments()); diff != "" {
+		t.Errorf("wrong lead comments\n%s", diff)
+	}
+	if got, want := blocks[0].LineComment(), "# after"; got != want {
+		t.Errorf("wrong line comment %q; want %q", got, want)
+	}
+	if got := blocks[1].LeadComments(); len(got) != 0 {
+		t.Errorf("unexpected lead comments %#v", got)
+	}
+
+	blocks[0].SetLeadComments([]string{"managed by tool X", "// do not edit"})
+	blocks[0].SetLineComment("")
+	blocks[1].SetLeadComments([]string{"other"})
+	blocks[1].SetLineComment("end")
+
+	nested := blocks[0].Body().AppendNewBlock("nested", nil)
+	nested.SetLeadComments([]string{"nested comment"})
+	nested.SetLineComment("nested end")
+
+	want := `# managed by tool X
+// do not edit
+resource "a" "b" {
+  attr = 1
+  # nested comment
+  nested {
+  } # nested end
+}
+
+# other
+other {} # end
+`
+	got := string(f.Bytes())
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if formatted := string(Format([]byte(got))); formatted != got {
+		t.Errorf("result is not formatted\ngot:\n%s\nformatted:\n%s", got, formatted)
+	}
+}
@@ -4,7 +4,9 @@
 package hclwrite
 
 import (
+	"bytes"
 	"reflect"
+	"sort"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -27,6 +29,7 @@ func newBody() *Body {
 func (b *Body) appendItem(c nodeContent) *node {
 	nn := b.children.Append(c)
 	b.items.Add(nn)
+	setItemNode(nn)
 	return nn
 }
 
@@ -34,6 +37,7 @@ func (b *Body) appendItemNode(nn *node) *node {
 	nn.assertUnattached()
 	b.children.AppendNode(nn)
 	b.items.Add(nn)
+	setItemNode(nn)
 	return nn
 }
 
@@ -165,7 +169,7 @@ func (b *Body) SetAttributeRaw(name string, tokens Tokens) *Attribute {
 	if attr != nil {
 		attr.expr = attr.expr.ReplaceWith(expr)
 	} else {
-		attr := newAttribute()
+		attr = newAttribute()
 		attr.init(name, expr)
 		b.appendItem(attr)
 	}
@@ -186,7 +190,7 @@ func (b *Body) SetAttributeValue(name string, val cty.Value) *Attribute {
 	if attr != nil {
 		attr.expr = attr.expr.ReplaceWith(expr)
 	} else {
-		attr := newAttribute()
+		attr = newAttribute()
 		attr.init(name, expr)
 		b.appendItem(attr)
 	}
@@ -207,7 +211,7 @@ func (b *Body) SetAttributeTraversal(name string, traversal hcl.Traversal) *Attr
 	if attr != nil {
 		attr.expr = attr.expr.ReplaceWith(expr)
 	} else {
-		attr := newAttribute()
+		attr = newAttribute()
 		attr.init(name, expr)
 		b.appendItem(attr)
 	}
@@ -255,3 +259,290 @@ func (b *Body) AppendNewline() {
 		},
 	})
 }
+
+// Comments returns the source of each of the comments in the body that are
+// not associated with an attribute or block, such as those separated from
+// the next item by a blank line, in the order they appear. Each includes its
+// comment delimiter but excludes the newline that terminates it.
+func (b *Body) Comments() []string {
+	var ret []string
+	for n := b.children.first; n != nil; n = n.after {
+		if toks, ok := n.content.(Tokens); ok {
+			ret = append(ret, (&comments{tokens: toks}).list()...)
+		}
+	}
+	return ret
+}
+
+// AppendComments appends the given comments to the end of the receiving
+// body, each written on its own line, as described for
+// Attribute.SetLeadComments.
+//
+// Comments appended immediately before a new attribute or block will become
+// the lead comments of that item if the result is parsed again, so use
+// AppendNewline to separate them if that isn't intended.
+func (b *Body) AppendComments(lines []string) {
+	b.AppendUnstructuredTokens(leadCommentTokens(lines, 0))
+}
+
+// RemoveComments removes all of the comments in the body that are not
+// associated with an attribute or block, along with the lines they occupied.
+func (b *Body) RemoveComments() {
+	for n := b.children.first; n != nil; n = n.after {
+		toks, ok := n.content.(Tokens)
+		if !ok {
+			continue
+		}
+		var kept Tokens
+		for i := 0; i < len(toks); i++ {
+			tok := toks[i]
+			if tok.Type != hclsyntax.TokenComment {
+				kept = append(kept, tok)
+				continue
+			}
+			// A multi-line comment doesn't consume the newline that follows
+			// it, so we must remove that too if the comment was alone on its
+			// line.
+			alone := len(kept) == 0 || kept[len(kept)-1].Type == hclsyntax.TokenNewline
+			if alone && i+1 < len(toks) && toks[i+1].Type == hclsyntax.TokenNewline && !bytes.HasSuffix(tok.Bytes, []byte{'\n'}) {
+				i++
+			}
+		}
+		n.content = kept
+	}
+}
+
+// BodyItem is an item in a body, which is either an *Attribute or a *Block.
+type BodyItem interface {
+	nodeContent
+	isBodyItem()
+}
+
+func (a *Attribute) isBodyItem() {}
+func (b *Block) isBodyItem()     {}
+
+// Items returns a new slice of all of the attributes and blocks in the body,
+// in the order they appear.
+func (b *Body) Items() []BodyItem {
+	list := b.items.List()
+	ret := make([]BodyItem, 0, len(list))
+	for _, n := range list {
+		ret = append(ret, n.content.(BodyItem))
+	}
+	return ret
+}
+
+// InsertBefore inserts the given item into the body immediately before the
+// given reference item, which must already be in the body. If the item is
+// already in the body then it is moved, along with its comments. Otherwise
+// the item must not belong to any other body; use MoveBlock to move a block
+// from another body into this one first.
+//
+// Returns false, making no changes, if the reference item is not in the
+// body, if it is the same as the item, or if the item belongs to some other
+// body.
+func (b *Body) InsertBefore(item, ref BodyItem) bool {
+	refNode := b.items.FindNodeWithContent(ref)
+	if refNode == nil || item == ref || b.itemAttachedElsewhere(item) {
+		return false
+	}
+	n := b.detachItem(item)
+	b.children.InsertNode(refNode, n)
+	b.items.Add(n)
+	setItemNode(n)
+	return true
+}
+
+// InsertAfter is like InsertBefore except that it inserts the item
+// immediately after the reference item.
+func (b *Body) InsertAfter(item, ref BodyItem) bool {
+	refNode := b.items.FindNodeWithContent(ref)
+	if refNode == nil || item == ref || b.itemAttachedElsewhere(item) {
+		return false
+	}
+	ensureItemNewline(ref)
+	n := b.detachItem(item)
+	if refNode.after != nil {
+		b.children.InsertNode(refNode.after, n)
+	} else {
+		b.children.AppendNode(n)
+	}
+	b.items.Add(n)
+	setItemNode(n)
+	return true
+}
+
+// MoveBlock moves the given block, along with its comments, from the body to
+// the end of the given destination body. InsertBefore or InsertAfter can then
+// be used on the destination body to move it to a particular position.
+//
+// Returns false, making no changes, if the block is not in the body or if
+// the destination is the block's own body or a body nested inside it.
+func (b *Body) MoveBlock(block *Block, dest *Body) bool {
+	if b.items.FindNodeWithContent(block) == nil || blockContainsBody(block, dest) {
+		return false
+	}
+	n := b.detachItem(block)
+	dest.appendItemNode(n)
+	return true
+}
+
+// blockContainsBody returns true if the given body is the body of the given
+// block or of any block nested inside it.
+func blockContainsBody(block *Block, body *Body) bool {
+	if block.Body() == body {
+		return true
+	}
+	for _, nested := range block.Body().Blocks() {
+		if blockContainsBody(nested, body) {
+			return true
+		}
+	}
+	return false
+}
+
+// Swap exchanges the positions of the two given items, which must both be
+// in the body, along with their comments. Any blank lines between items stay
+// where they are.
+//
+// Returns false, making no changes, if either item is not in the body.
+func (b *Body) Swap(item1, item2 BodyItem) bool {
+	n1 := b.items.FindNodeWithContent(item1)
+	n2 := b.items.FindNodeWithContent(item2)
+	if n1 == nil || n2 == nil {
+		return false
+	}
+	ensureItemNewline(item1)
+	ensureItemNewline(item2)
+	n1.content, n2.content = n2.content, n1.content
+	setItemNode(n1)
+	setItemNode(n2)
+	return true
+}
+
+// SortAttributes sorts the attributes of the body using the given function,
+// which reports whether the first attribute should appear before the second.
+//
+// Attributes move along with their comments, and are sorted only within each
+// group of consecutive attributes, so that blocks, blank lines and
+// standalone comments between groups stay where they are. The sort is
+// stable.
+func (b *Body) SortAttributes(less func(a, b *Attribute) bool) {
+	var group []*node
+	sortGroup := func() {
+		attrs := make([]*Attribute, len(group))
+		for i, n := range group {
+			attrs[i] = n.content.(*Attribute)
+			ensureItemNewline(attrs[i])
+		}
+		sort.SliceStable(attrs, func(i, j int) bool {
+			return less(attrs[i], attrs[j])
+		})
+		for i, n := range group {
+			n.content = attrs[i]
+			setItemNode(n)
+		}
+		group = group[:0]
+	}
+
+	for n := b.children.first; n != nil; n = n.after {
+		if _, isAttr := n.content.(*Attribute); isAttr && b.items.Has(n) {
+			group = append(group, n)
+			continue
+		}
+		sortGroup()
+	}
+	sortGroup()
+}
+
+// detachItem removes the node for the given item from the body, if it's
+// there, and returns a new unattached node for the item.
+//
+// If the item was separated from its neighbors by blank lines on both sides
+// then we also remove one of those blank lines, so that the remaining items
+// keep their grouping.
+func (b *Body) detachItem(item BodyItem) *node {
+	ensureItemNewline(item)
+	if n := b.items.FindNodeWithContent(item); n != nil {
+		before, after := n.before, n.after
+		n.Detach()
+		b.items.Remove(n)
+		if isBlankLines(after) && (before == nil || isBlankLines(before)) {
+			after.Detach()
+		} else if isBlankLines(before) && after == nil {
+			before.Detach()
+		}
+	}
+	return newNode(item)
+}
+
+// isBlankLines returns true if the given node consists only of newlines.
+func isBlankLines(n *node) bool {
+	if n == nil {
+		return false
+	}
+	toks, ok := n.content.(Tokens)
+	if !ok || len(toks) == 0 {
+		return false
+	}
+	for _, tok := range toks {
+		if tok.Type != hclsyntax.TokenNewline {
+			return false
+		}
+	}
+	return true
+}
+
+// setItemNode records the given node as the parent of the body item it
+// contains, so that we can later tell whether the item belongs to a body.
+func setItemNode(n *node) {
+	switch item := n.content.(type) {
+	case *Attribute:
+		item.parent = n
+	case *Block:
+		item.parent = n
+	}
+}
+
+// itemAttachedElsewhere returns true if the given item currently belongs to
+// a body other than the receiver.
+func (b *Body) itemAttachedElsewhere(item BodyItem) bool {
+	var n *node
+	switch item := item.(type) {
+	case *Attribute:
+		n = item.parent
+	case *Block:
+		n = item.parent
+	}
+	if n == nil || n.list == nil || n.content != item {
+		// The item was never added to a body, or has since been removed.
+		return false
+	}
+	return !b.items.Has(n)
+}
+
+// ensureItemNewline adds a newline to the end of the given item if it
+// doesn't already end with one, which can be the case for the last item in a
+// file, so that it can be moved elsewhere.
+func ensureItemNewline(item BodyItem) {
+	toks := item.BuildTokens(nil)
+	if len(toks) != 0 {
+		last := toks[len(toks)-1]
+		if last.Type == hclsyntax.TokenNewline || bytes.HasSuffix(last.Bytes, []byte{'\n'}) {
+			return
+		}
+	}
+	var children *nodes
+	switch item := item.(type) {
+	case *Attribute:
+		children = item.children
+	case *Block:
+		children = item.children
+	}
+	children.AppendUnstructuredTokens(Tokens{
+		{
+			Type:  hclsyntax.TokenNewline,
+			Bytes: []byte{'\n'},
+		},
+	})
+}
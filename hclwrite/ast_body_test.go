@@ -1754,3 +1754,352 @@ bar {}
 	}
 
 }
+
+func TestBodyComments(t *testing.T) {
+	src := `# header
+
+/* note */
+a = 1 # line
+
+# lead
+b = 2
+
+# footer
+`
+	f, diags := ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	body := f.Body()
+
+	want := []string{"# header", "/* note */", "# footer"}
+	if diff := cmp.Diff(want, body.Comments()); diff != "" {
+		t.Errorf("wrong comments\n%s", diff)
+	}
+
+	body.RemoveComments()
+	body.AppendComments([]string{"generated by tool X", "// end"})
+
+	wantSrc := `
+a = 1 # line
+
+# lead
+b = 2
+
+# generated by tool X
+// end
+`
+	if got := string(f.Bytes()); got != wantSrc {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, wantSrc)
+	}
+	if diff := cmp.Diff([]string{"# generated by tool X", "// end"}, body.Comments()); diff != "" {
+		t.Errorf("wrong comments after update\n%s", diff)
+	}
+}
+
+func TestBodyInsertAndMove(t *testing.T) {
+	src := `# about a
+a = 1 # a line
+
+# about b
+b = 2
+c = 3
+
+block "x" {
+  d = 4
+}
+
+other {
+  # about e
+  e = 5
+}
+`
+	tests := map[string]struct {
+		edit func(t *testing.T, body *Body) bool
+		want string
+	}{
+		"insert new block before": {
+			func(t *testing.T, body *Body) bool {
+				block := NewBlock("new", nil)
+				block.SetLeadComments([]string{"about new"})
+				return body.InsertBefore(block, body.GetAttribute("c"))
+			},
+			`# about a
+a = 1 # a line
+
+# about b
+b = 2
+# about new
+new {
+}
+c = 3
+
+block "x" {
+  d = 4
+}
+
+other {
+  # about e
+  e = 5
+}
+`,
+		},
+		"insert before first": {
+			func(t *testing.T, body *Body) bool {
+				return body.InsertBefore(body.GetAttribute("c"), body.GetAttribute("a"))
+			},
+			`c = 3
+# about a
+a = 1 # a line
+
+# about b
+b = 2
+
+block "x" {
+  d = 4
+}
+
+other {
+  # about e
+  e = 5
+}
+`,
+		},
+		"move after removing blank line": {
+			func(t *testing.T, body *Body) bool {
+				return body.InsertAfter(body.GetAttribute("a"), body.GetAttribute("c"))
+			},
+			`# about b
+b = 2
+c = 3
+# about a
+a = 1 # a line
+
+block "x" {
+  d = 4
+}
+
+other {
+  # about e
+  e = 5
+}
+`,
+		},
+		"insert after last": {
+			func(t *testing.T, body *Body) bool {
+				return body.InsertAfter(body.Blocks()[0], body.Blocks()[1])
+			},
+			`# about a
+a = 1 # a line
+
+# about b
+b = 2
+c = 3
+
+other {
+  # about e
+  e = 5
+}
+block "x" {
+  d = 4
+}
+`,
+		},
+		"swap": {
+			func(t *testing.T, body *Body) bool {
+				return body.Swap(body.GetAttribute("a"), body.GetAttribute("c"))
+			},
+			`c = 3
+
+# about b
+b = 2
+# about a
+a = 1 # a line
+
+block "x" {
+  d = 4
+}
+
+other {
+  # about e
+  e = 5
+}
+`,
+		},
+		"move block between bodies": {
+			func(t *testing.T, body *Body) bool {
+				blocks := body.Blocks()
+				dest := blocks[1].Body()
+				if !body.MoveBlock(blocks[0], dest) {
+					t.Fatal("MoveBlock failed")
+				}
+				return dest.InsertBefore(blocks[0], dest.GetAttribute("e"))
+			},
+			`# about a
+a = 1 # a line
+
+# about b
+b = 2
+c = 3
+
+other {
+  block "x" {
+    d = 4
+  }
+  # about e
+  e = 5
+}
+`,
+		},
+		"move block into itself": {
+			func(t *testing.T, body *Body) bool {
+				block := body.Blocks()[0]
+				inner := block.Body().AppendNewBlock("inner", nil)
+				return !body.MoveBlock(block, block.Body()) &&
+					!body.MoveBlock(block, inner.Body())
+			},
+			`# about a
+a = 1 # a line
+
+# about b
+b = 2
+c = 3
+
+block "x" {
+  d = 4
+  inner {
+  }
+}
+
+other {
+  # about e
+  e = 5
+}
+`,
+		},
+		"reference not in body": {
+			func(t *testing.T, body *Body) bool {
+				return !body.InsertBefore(body.GetAttribute("a"), body.Blocks()[1].Body().GetAttribute("e")) &&
+					!body.InsertAfter(body.GetAttribute("a"), body.GetAttribute("a")) &&
+					!body.MoveBlock(NewBlock("free", nil), body) &&
+					!body.Swap(body.GetAttribute("a"), body.Blocks()[1].Body().GetAttribute("e"))
+			},
+			src,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, diags := ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+			if len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+			if !test.edit(t, f.Body()) {
+				t.Fatal("edit failed")
+			}
+			if got := string(f.Bytes()); got != test.want {
+				t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBodyMoveLastItemWithoutNewline(t *testing.T) {
+	f, diags := ParseConfig([]byte("a = 1\nb = 2"), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	body := f.Body()
+	body.InsertBefore(body.GetAttribute("b"), body.GetAttribute("a"))
+	if got, want := string(f.Bytes()), "b = 2\na = 1\n"; got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBodyInsertItemFromOtherBody(t *testing.T) {
+	f1, diags := ParseConfig([]byte("a = 1\nblk {\n  b = 2\n}\n"), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	f2, diags := ParseConfig([]byte("c = 3\n"), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	body1, body2 := f1.Body(), f2.Body()
+	blk := body1.Blocks()[0]
+	c := body2.GetAttribute("c")
+
+	if body2.InsertBefore(body1.GetAttribute("a"), c) {
+		t.Error("inserted an attribute that belongs to another file")
+	}
+	if body2.InsertAfter(blk, c) {
+		t.Error("inserted a block that belongs to another file")
+	}
+	if blk.Body().InsertAfter(c, blk.Body().GetAttribute("b")) {
+		t.Error("inserted an attribute that belongs to an outer body")
+	}
+
+	// Once the item has been removed from its body it can be inserted
+	// elsewhere.
+	a := body1.RemoveAttribute("a")
+	if !body2.InsertAfter(a, c) {
+		t.Error("failed to insert a removed attribute")
+	}
+	if !body1.MoveBlock(blk, body2) || !body2.InsertBefore(blk, c) {
+		t.Error("failed to insert a moved block")
+	}
+
+	if got, want := string(f1.Bytes()), ""; got != want {
+		t.Errorf("wrong first file\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := string(f2.Bytes()), "blk {\n  b = 2\n}\nc = 3\na = 1\n"; got != want {
+		t.Errorf("wrong second file\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBodySortAttributes(t *testing.T) {
+	src := `# about c
+c = 3
+a = 1 # a line
+b = 2
+
+z = 26
+y = 25
+block {}
+x = 24
+`
+	f, diags := ParseConfig([]byte(src), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	f.Body().SortAttributes(func(a, b *Attribute) bool {
+		return a.Name() < b.Name()
+	})
+
+	want := `a = 1 # a line
+b = 2
+# about c
+c = 3
+
+y = 25
+z = 26
+block {}
+x = 24
+`
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	var names []string
+	for _, item := range f.Body().Items() {
+		switch item := item.(type) {
+		case *Attribute:
+			names = append(names, item.Name())
+		case *Block:
+			names = append(names, item.Type())
+		}
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c", "y", "z", "block", "x"}, names); diff != "" {
+		t.Errorf("wrong items\n%s", diff)
+	}
+}
@@ -15,6 +15,10 @@ type Expression struct {
 	inTree
 
 	absTraversals nodeSet
+
+	// cons is set once the expression has been converted for editing as an
+	// object or tuple constructor or a function call.
+	cons *consExpr
 }
 
 func newExpression() *Expression {
@@ -130,10 +134,16 @@ func NewExpressionAbsTraversal(traversal hcl.Traversal) *Expression {
 // Variables returns the absolute traversals that exist within the receiving
 // expression.
 func (e *Expression) Variables() []*Traversal {
-	nodes := e.absTraversals.List()
-	ret := make([]*Traversal, len(nodes))
-	for i, node := range nodes {
-		ret[i] = node.content.(*Traversal)
+	ret := make([]*Traversal, 0, len(e.absTraversals))
+	for n := e.children.first; n != nil; n = n.after {
+		switch content := n.content.(type) {
+		case *Traversal:
+			if e.absTraversals.Has(n) {
+				ret = append(ret, content)
+			}
+		case *consItem:
+			ret = append(ret, content.variables()...)
+		}
 	}
 	return ret
 }
@@ -155,8 +165,7 @@ func (e *Expression) RenameVariablePrefix(search, replacement []string) {
 		panic(fmt.Sprintf("search and replacement length mismatch (%d and %d)", len(search), len(replacement)))
 	}
 Traversals:
-	for node := range e.absTraversals {
-		traversal := node.content.(*Traversal)
+	for _, traversal := range e.Variables() {
 		if len(traversal.steps) < len(search) {
 			// If it's shorter then it can't have our prefix
 			continue
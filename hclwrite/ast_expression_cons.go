@@ -0,0 +1,612 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ObjectCons is an editable view of an object constructor expression, such
+// as { a = 1, b = 2 }, as returned by Expression.ObjectCons.
+//
+// Changes made through the view are made directly to the underlying
+// expression, preserving any comments and other tokens outside of the items
+// that are changed.
+type ObjectCons struct {
+	expr *Expression
+	cons *consExpr
+}
+
+// TupleCons is an editable view of a tuple constructor expression, such as
+// [1, 2], as returned by Expression.TupleCons.
+//
+// Changes made through the view are made directly to the underlying
+// expression, preserving any comments and other tokens outside of the
+// elements that are changed.
+type TupleCons struct {
+	expr *Expression
+	cons *consExpr
+}
+
+// FunctionCall is an editable view of a function call expression, such as
+// max(1, 2), as returned by Expression.FunctionCall.
+//
+// Changes made through the view are made directly to the underlying
+// expression, preserving any comments and other tokens outside of the
+// arguments that are changed.
+type FunctionCall struct {
+	expr *Expression
+	cons *consExpr
+}
+
+// ObjectCons returns an editable view of the receiving expression if it is
+// an object constructor, or nil if it is not.
+func (e *Expression) ObjectCons() *ObjectCons {
+	cons := e.structure()
+	if cons == nil || cons.kind != consObject {
+		return nil
+	}
+	return &ObjectCons{expr: e, cons: cons}
+}
+
+// TupleCons returns an editable view of the receiving expression if it is a
+// tuple constructor, or nil if it is not.
+func (e *Expression) TupleCons() *TupleCons {
+	cons := e.structure()
+	if cons == nil || cons.kind != consTuple {
+		return nil
+	}
+	return &TupleCons{expr: e, cons: cons}
+}
+
+// FunctionCall returns an editable view of the receiving expression if it is
+// a function call, or nil if it is not.
+func (e *Expression) FunctionCall() *FunctionCall {
+	cons := e.structure()
+	if cons == nil || cons.kind != consFunctionCall {
+		return nil
+	}
+	return &FunctionCall{expr: e, cons: cons}
+}
+
+// Keys returns the keys of the items of the object in source order. Items
+// whose keys are not constant strings, such as those given by a
+// parenthesized expression, are omitted.
+func (o *ObjectCons) Keys() []string {
+	var ret []string
+	for _, item := range o.cons.itemList() {
+		if item.hasKey {
+			ret = append(ret, item.key)
+		}
+	}
+	return ret
+}
+
+// Get returns the value expression of the item with the given key, or nil if
+// there is no such item.
+func (o *ObjectCons) Get(key string) *Expression {
+	n := o.cons.keyNode(key)
+	if n == nil {
+		return nil
+	}
+	return n.content.(*consItem).valueExpr()
+}
+
+// Set replaces the value of the item with the given key with the given
+// expression, or appends a new item if there is no item with that key.
+// The given expression must not already be attached to another expression
+// or attribute.
+func (o *ObjectCons) Set(key string, expr *Expression) *Expression {
+	if n := o.cons.keyNode(key); n != nil {
+		n.content.(*consItem).setValue(expr)
+		return expr
+	}
+
+	item := newConsItem()
+	item.lead = item.children.Append(Tokens{})
+	item.keyExpr = item.children.Append(NewExpressionRaw(tokensForObjectKey(key)))
+	item.children.Append(Tokens{
+		{
+			Type:         hclsyntax.TokenEqual,
+			Bytes:        []byte{'='},
+			SpacesBefore: 1,
+		},
+	})
+	item.value = item.children.Append(expr)
+	item.trailing = item.children.Append(Tokens{})
+	item.key, item.hasKey = key, true
+	o.cons.appendItem(o.expr, item)
+	return expr
+}
+
+// SetValue is like Set but sets the value to an expression representing the
+// given literal value.
+func (o *ObjectCons) SetValue(key string, val cty.Value) *Expression {
+	return o.Set(key, NewExpressionLiteral(val))
+}
+
+// Remove removes the item with the given key, returning false if there is no
+// such item.
+func (o *ObjectCons) Remove(key string) bool {
+	n := o.cons.keyNode(key)
+	if n == nil {
+		return false
+	}
+	o.cons.removeItem(n)
+	return true
+}
+
+// Len returns the number of elements of the tuple.
+func (t *TupleCons) Len() int {
+	return len(t.cons.items)
+}
+
+// Get returns the expression for the element at the given index, or nil if
+// the index is out of range.
+func (t *TupleCons) Get(i int) *Expression {
+	return t.cons.get(i)
+}
+
+// Set replaces the element at the given index with the given expression,
+// returning false if the index is out of range. The given expression must
+// not already be attached to another expression or attribute.
+func (t *TupleCons) Set(i int, expr *Expression) bool {
+	return t.cons.set(i, expr)
+}
+
+// Remove removes the element at the given index, returning false if the
+// index is out of range.
+func (t *TupleCons) Remove(i int) bool {
+	return t.cons.remove(i)
+}
+
+// Append appends the given expression as a new element at the end of the
+// tuple.
+func (t *TupleCons) Append(expr *Expression) *Expression {
+	t.cons.appendValue(t.expr, expr)
+	return expr
+}
+
+// Name returns the name of the function being called.
+func (f *FunctionCall) Name() string {
+	return f.cons.name
+}
+
+// Len returns the number of arguments to the function.
+func (f *FunctionCall) Len() int {
+	return len(f.cons.items)
+}
+
+// Get returns the expression for the argument at the given index, or nil if
+// the index is out of range.
+func (f *FunctionCall) Get(i int) *Expression {
+	return f.cons.get(i)
+}
+
+// Set replaces the argument at the given index with the given expression,
+// returning false if the index is out of range. The given expression must
+// not already be attached to another expression or attribute.
+func (f *FunctionCall) Set(i int, expr *Expression) bool {
+	return f.cons.set(i, expr)
+}
+
+// Remove removes the argument at the given index, returning false if the
+// index is out of range.
+func (f *FunctionCall) Remove(i int) bool {
+	return f.cons.remove(i)
+}
+
+// Append appends the given expression as a new argument at the end of the
+// argument list.
+//
+// Append returns nil without changing the call if its last argument is
+// expanded with the "..." symbol, since that must remain the last argument.
+func (f *FunctionCall) Append(expr *Expression) *Expression {
+	if items := f.cons.itemList(); len(items) != 0 && items[len(items)-1].expands() {
+		return nil
+	}
+	f.cons.appendValue(f.expr, expr)
+	return expr
+}
+
+type consKind int
+
+const (
+	consObject consKind = iota
+	consTuple
+	consFunctionCall
+)
+
+// consExpr records the structure of an expression that has been converted
+// for editing as an object constructor, a tuple constructor or a function
+// call.
+//
+// The children of such an expression are the tokens up to and including the
+// opening bracket, then a consItem for each item, element or argument, and
+// finally the tokens from the closing bracket onwards.
+type consExpr struct {
+	kind consKind
+	name string // the name of the function, for a function call
+
+	open  *node
+	items nodeSet
+	close *node
+}
+
+// consItem is an item of an object constructor, or an element of a tuple
+// constructor or an argument of a function call. Its children are any
+// tokens on the lines before the item, then the key and the tokens up to the
+// value if it's an object item, then the value, then any separator and
+// comments after the value and the newline that ends its line.
+type consItem struct {
+	inTree
+
+	lead     *node
+	keyExpr  *node // nil unless an item of an object constructor
+	value    *node
+	trailing *node
+
+	key    string
+	hasKey bool
+}
+
+func newConsItem() *consItem {
+	return &consItem{
+		inTree: newInTree(),
+	}
+}
+
+func (i *consItem) valueExpr() *Expression {
+	return i.value.content.(*Expression)
+}
+
+func (i *consItem) setValue(expr *Expression) {
+	// The value is never the first or last child of an item, so we can
+	// insert the new value before the old one and then remove the old one.
+	n := newNode(expr)
+	i.children.InsertNode(i.value, n)
+	i.value.Detach()
+	i.value = n
+}
+
+func (i *consItem) variables() []*Traversal {
+	var ret []*Traversal
+	if i.keyExpr != nil {
+		ret = append(ret, i.keyExpr.content.(*Expression).Variables()...)
+	}
+	return append(ret, i.valueExpr().Variables()...)
+}
+
+// expands returns true if the item is a function argument that is expanded
+// with the "..." symbol.
+func (i *consItem) expands() bool {
+	toks := i.trailing.content.(Tokens)
+	return len(toks) != 0 && toks[0].Type == hclsyntax.TokenEllipsis
+}
+
+// commaIndex returns the index within the item's trailing tokens where its
+// comma is or belongs, which is after any "..." symbol.
+func (i *consItem) commaIndex() int {
+	if i.expands() {
+		return 1
+	}
+	return 0
+}
+
+// addComma ensures that the item is followed by a comma.
+func (i *consItem) addComma() {
+	toks := i.trailing.content.(Tokens)
+	at := i.commaIndex()
+	if len(toks) > at && toks[at].Type == hclsyntax.TokenComma {
+		return
+	}
+	ret := make(Tokens, 0, len(toks)+1)
+	ret = append(ret, toks[:at]...)
+	ret = append(ret, &Token{
+		Type:  hclsyntax.TokenComma,
+		Bytes: []byte{','},
+	})
+	i.trailing.content = append(ret, toks[at:]...)
+}
+
+// removeComma removes the comma that follows the item, if any.
+func (i *consItem) removeComma() {
+	toks := i.trailing.content.(Tokens)
+	at := i.commaIndex()
+	if len(toks) > at && toks[at].Type == hclsyntax.TokenComma {
+		ret := make(Tokens, 0, len(toks)-1)
+		ret = append(ret, toks[:at]...)
+		i.trailing.content = append(ret, toks[at+1:]...)
+	}
+}
+
+func (c *consExpr) itemList() []*consItem {
+	nodes := c.items.List()
+	ret := make([]*consItem, len(nodes))
+	for i, n := range nodes {
+		ret[i] = n.content.(*consItem)
+	}
+	return ret
+}
+
+func (c *consExpr) keyNode(key string) *node {
+	for _, n := range c.items.List() {
+		if item := n.content.(*consItem); item.hasKey && item.key == key {
+			return n
+		}
+	}
+	return nil
+}
+
+func (c *consExpr) get(i int) *Expression {
+	nodes := c.items.List()
+	if i < 0 || i >= len(nodes) {
+		return nil
+	}
+	return nodes[i].content.(*consItem).valueExpr()
+}
+
+func (c *consExpr) set(i int, expr *Expression) bool {
+	nodes := c.items.List()
+	if i < 0 || i >= len(nodes) {
+		return false
+	}
+	nodes[i].content.(*consItem).setValue(expr)
+	return true
+}
+
+func (c *consExpr) remove(i int) bool {
+	nodes := c.items.List()
+	if i < 0 || i >= len(nodes) {
+		return false
+	}
+	c.removeItem(nodes[i])
+	return true
+}
+
+func (c *consExpr) appendValue(e *Expression, expr *Expression) {
+	item := newConsItem()
+	item.lead = item.children.Append(Tokens{})
+	item.value = item.children.Append(expr)
+	item.trailing = item.children.Append(Tokens{})
+	c.appendItem(e, item)
+}
+
+// multiline returns true if the items are written one per line, rather
+// than all on the same line as the brackets.
+func (c *consExpr) multiline() bool {
+	if hasNewline(c.open.content.(Tokens)) || hasNewline(c.close.content.(Tokens)) {
+		return true
+	}
+	for _, item := range c.itemList() {
+		if hasNewline(item.lead.content.(Tokens)) || hasNewline(item.trailing.content.(Tokens)) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendItem adds the given new item after the existing items of the
+// receiver, which belongs to the given expression, laying it out in the same
+// way as the existing items.
+func (c *consExpr) appendItem(e *Expression, item *consItem) {
+	newline := Tokens{
+		{
+			Type:  hclsyntax.TokenNewline,
+			Bytes: []byte{'\n'},
+		},
+	}
+	items := c.itemList()
+	multiline := len(items) == 0 && c.multiline()
+
+	var last *consItem
+	var lastTrailing Tokens
+	if len(items) != 0 {
+		last = items[len(items)-1]
+		lastTrailing = last.trailing.content.(Tokens)
+	}
+
+	switch {
+	case last != nil && endsWithNewline(lastTrailing):
+		// Object items on separate lines don't need commas, so we use them
+		// only if the existing items do.
+		if c.kind != consObject || lastTrailing[0].Type == hclsyntax.TokenComma {
+			last.addComma()
+			item.addComma()
+		}
+		item.trailing.content = append(item.trailing.content.(Tokens), newline...)
+	case last != nil:
+		// The items are all on one line, or at least the last one is on the
+		// same line as the closing bracket, so the new item joins it there.
+		last.addComma()
+	case multiline:
+		if c.kind != consObject {
+			item.addComma()
+		}
+		item.trailing.content = append(item.trailing.content.(Tokens), newline...)
+	}
+
+	n := newNode(item)
+	e.children.InsertNode(c.close, n)
+	c.items.Add(n)
+}
+
+func (c *consExpr) removeItem(n *node) {
+	// If we're removing the last of several items on a single line then
+	// the item before it no longer needs its separator.
+	nodes := c.items.List()
+	if len(nodes) > 1 && nodes[len(nodes)-1] == n && !c.multiline() {
+		nodes[len(nodes)-2].content.(*consItem).removeComma()
+	}
+	n.Detach()
+	c.items.Remove(n)
+}
+
+// structure converts the receiver, if necessary, so that its children
+// describe its structure as an object or tuple constructor or a function
+// call, and returns that structure. It returns nil if the expression is not
+// one of these or its tokens can't be parsed.
+func (e *Expression) structure() *consExpr {
+	if e.cons != nil {
+		return e.cons
+	}
+
+	toks := e.BuildTokens(nil)
+	src := toks.Bytes()
+	nativeToks, diags := hclsyntax.LexExpression(src, "", hcl.InitialPos)
+	if diags.HasErrors() || len(nativeToks) != len(toks)+1 {
+		return nil
+	}
+	nativeExpr, diags := hclsyntax.ParseExpression(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	nativeToks = nativeToks[:len(toks)] // discard the EOF token
+	from := inputTokens{
+		nativeTokens: nativeToks,
+		writerTokens: toks,
+	}
+	tokenIndex := func(offset int) int {
+		return sort.Search(len(nativeToks), func(i int) bool {
+			return nativeToks[i].Range.Start.Byte >= offset
+		})
+	}
+
+	cons := &consExpr{
+		items: newNodeSet(),
+	}
+	var keys, values []hclsyntax.Expression
+	var openIdx, closeIdx int
+	switch nativeExpr := nativeExpr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		cons.kind = consObject
+		for _, item := range nativeExpr.Items {
+			keys = append(keys, item.KeyExpr)
+			values = append(values, item.ValueExpr)
+		}
+		openIdx = tokenIndex(nativeExpr.OpenRange.Start.Byte)
+		closeIdx = tokenIndex(nativeExpr.SrcRange.End.Byte) - 1
+	case *hclsyntax.TupleConsExpr:
+		cons.kind = consTuple
+		values = nativeExpr.Exprs
+		openIdx = tokenIndex(nativeExpr.OpenRange.Start.Byte)
+		closeIdx = tokenIndex(nativeExpr.SrcRange.End.Byte) - 1
+	case *hclsyntax.FunctionCallExpr:
+		cons.kind = consFunctionCall
+		cons.name = nativeExpr.Name
+		values = nativeExpr.Args
+		openIdx = tokenIndex(nativeExpr.OpenParenRange.Start.Byte)
+		closeIdx = tokenIndex(nativeExpr.CloseParenRange.Start.Byte)
+	default:
+		return nil
+	}
+
+	// Any newline after the opening bracket belongs to the bracket, so that
+	// the first item starts on the following line just as the others do.
+	start := openIdx + 1
+	if start < closeIdx && endsWithNewline(toks[start:start+1]) {
+		start++
+	}
+
+	e.children.Clear()
+	e.absTraversals.Clear()
+	cons.open = e.children.Append(toks[:start])
+	for i, value := range values {
+		item := newConsItem()
+		valueStart := tokenIndex(value.Range().Start.Byte)
+		valueEnd := tokenIndex(value.Range().End.Byte)
+
+		if keys != nil {
+			key := keys[i]
+			keyStart := tokenIndex(key.Range().Start.Byte)
+			keyEnd := tokenIndex(key.Range().End.Byte)
+			item.lead = item.children.Append(toks[start:keyStart])
+			item.keyExpr = parseExpression(key, from.Slice(keyStart, keyEnd))
+			item.children.AppendNode(item.keyExpr)
+			item.children.Append(toks[keyEnd:valueStart])
+			if v, diags := key.Value(nil); !diags.HasErrors() && v.Type() == cty.String && v.IsKnown() && !v.IsNull() {
+				item.key, item.hasKey = v.AsString(), true
+			}
+		} else {
+			item.lead = item.children.Append(toks[start:valueStart])
+		}
+		item.value = parseExpression(value, from.Slice(valueStart, valueEnd))
+		item.children.AppendNode(item.value)
+
+		// The item extends over any separator after it and any comments on
+		// the rest of its line.
+		end := valueEnd
+		if end < closeIdx && toks[end].Type == hclsyntax.TokenEllipsis {
+			end++
+		}
+		if end < closeIdx && toks[end].Type == hclsyntax.TokenComma {
+			end++
+		}
+		for end < closeIdx {
+			tok := toks[end]
+			if tok.Type == hclsyntax.TokenComment {
+				end++
+				if endsWithNewline(Tokens{tok}) {
+					break
+				}
+				continue
+			}
+			if tok.Type == hclsyntax.TokenNewline {
+				end++
+			}
+			break
+		}
+		item.trailing = item.children.Append(toks[valueEnd:end])
+		cons.items.Add(e.children.Append(item))
+		start = end
+	}
+	cons.close = e.children.Append(toks[start:])
+
+	e.cons = cons
+	return cons
+}
+
+// tokensForObjectKey returns the tokens for the given object key, which is
+// written as a bare identifier if possible or as a quoted string otherwise.
+func tokensForObjectKey(key string) Tokens {
+	if hclsyntax.ValidIdentifier(key) {
+		return Tokens{
+			{
+				Type:  hclsyntax.TokenIdent,
+				Bytes: []byte(key),
+			},
+		}
+	}
+	return TokensForValue(cty.StringVal(key))
+}
+
+func hasNewline(toks Tokens) bool {
+	for i := range toks {
+		if endsWithNewline(toks[i : i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithNewline returns true if the last of the given tokens ends a line,
+// either because it is a newline or a single-line comment.
+func endsWithNewline(toks Tokens) bool {
+	if len(toks) == 0 {
+		return false
+	}
+	last := toks[len(toks)-1]
+	switch last.Type {
+	case hclsyntax.TokenNewline:
+		return true
+	case hclsyntax.TokenComment:
+		return bytes.HasSuffix(last.Bytes, []byte{'\n'})
+	}
+	return false
+}
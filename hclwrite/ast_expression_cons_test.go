@@ -0,0 +1,335 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestExpressionObjectCons(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		edit func(t *testing.T, obj *ObjectCons)
+		want string
+	}{
+		"keys": {
+			"a = { b = 1, \"c d\" = 2, (e) = 3, f: 4 }\n",
+			func(t *testing.T, obj *ObjectCons) {
+				want := []string{"b", "c d", "f"}
+				if diff := cmp.Diff(want, obj.Keys()); diff != "" {
+					t.Errorf("wrong keys\n%s", diff)
+				}
+			},
+			"a = { b = 1, \"c d\" = 2, (e) = 3, f : 4 }\n",
+		},
+		"set existing": {
+			"a = { b = 1, c = 2 }\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.SetValue("b", cty.StringVal("x"))
+			},
+			"a = { b = \"x\", c = 2 }\n",
+		},
+		"set new single line": {
+			"a = { b = 1 }\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.SetValue("c", cty.True)
+				obj.SetValue("d e", cty.NumberIntVal(3))
+			},
+			"a = { b = 1, c = true, \"d e\" = 3 }\n",
+		},
+		"set new empty": {
+			"a = {}\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.SetValue("b", cty.NumberIntVal(1))
+			},
+			"a = { b = 1 }\n",
+		},
+		"set new multi-line": {
+			"a = {\n  b = 1 # one\n}\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.SetValue("c", cty.NumberIntVal(2))
+			},
+			"a = {\n  b = 1 # one\n  c = 2\n}\n",
+		},
+		"set new multi-line with commas": {
+			"a = {\n  b = 1,\n}\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.SetValue("c", cty.NumberIntVal(2))
+			},
+			"a = {\n  b = 1,\n  c = 2,\n}\n",
+		},
+		"remove multi-line": {
+			"a = {\n  # about b\n  b = 1 # one\n  # about c\n  c = 2 # two\n  d = 3\n}\n",
+			func(t *testing.T, obj *ObjectCons) {
+				if !obj.Remove("c") {
+					t.Error("c not removed")
+				}
+				if obj.Remove("z") {
+					t.Error("z removed")
+				}
+			},
+			"a = {\n  # about b\n  b = 1 # one\n  d = 3\n}\n",
+		},
+		"remove last single line": {
+			"a = { b = 1, c = 2 }\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.Remove("c")
+			},
+			"a = { b = 1 }\n",
+		},
+		"remove first single line": {
+			"a = { b = 1, c = 2 }\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.Remove("b")
+			},
+			"a = { c = 2 }\n",
+		},
+		"nested": {
+			"a = {\n  tags = { env = \"dev\" } # tags\n  list = [1]\n}\n",
+			func(t *testing.T, obj *ObjectCons) {
+				obj.Get("tags").ObjectCons().SetValue("env", cty.StringVal("prod"))
+				obj.Get("list").TupleCons().Append(NewExpressionLiteral(cty.NumberIntVal(2)))
+				if obj.Get("missing") != nil {
+					t.Error("found missing key")
+				}
+			},
+			"a = {\n  tags = { env = \"prod\" } # tags\n  list = [1, 2]\n}\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, diags := ParseConfig([]byte(test.src), "", hcl.Pos{Line: 1, Column: 1})
+			if len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+			obj := f.Body().GetAttribute("a").Expr().ObjectCons()
+			if obj == nil {
+				t.Fatal("not an object constructor")
+			}
+			test.edit(t, obj)
+			if got := string(f.Bytes()); got != test.want {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpressionTupleCons(t *testing.T) {
+	one := func() *Expression { return NewExpressionLiteral(cty.NumberIntVal(1)) }
+	tests := map[string]struct {
+		src  string
+		edit func(t *testing.T, tup *TupleCons)
+		want string
+	}{
+		"get and set": {
+			"a = [x, y, z]\n",
+			func(t *testing.T, tup *TupleCons) {
+				if got, want := tup.Len(), 3; got != want {
+					t.Errorf("wrong length %d; want %d", got, want)
+				}
+				if got := tup.Get(1).Variables(); len(got) != 1 {
+					t.Errorf("wrong variables %#v", got)
+				}
+				if tup.Get(3) != nil || tup.Set(-1, one()) {
+					t.Error("accepted an out of range index")
+				}
+				tup.Set(1, one())
+			},
+			"a = [x, 1, z]\n",
+		},
+		"append single line": {
+			"a = [1]\n",
+			func(t *testing.T, tup *TupleCons) {
+				tup.Append(NewExpressionLiteral(cty.StringVal("b")))
+			},
+			"a = [1, \"b\"]\n",
+		},
+		"append empty": {
+			"a = []\n",
+			func(t *testing.T, tup *TupleCons) {
+				tup.Append(one())
+			},
+			"a = [1]\n",
+		},
+		"append empty multi-line": {
+			"a = [\n]\n",
+			func(t *testing.T, tup *TupleCons) {
+				tup.Append(one())
+			},
+			"a = [\n  1,\n]\n",
+		},
+		"append multi-line": {
+			"a = [\n  0, # zero\n  2 # two\n]\n",
+			func(t *testing.T, tup *TupleCons) {
+				tup.Append(one())
+			},
+			"a = [\n  0, # zero\n  2, # two\n  1,\n]\n",
+		},
+		"append closing on last line": {
+			"a = [\n  0,\n  2]\n",
+			func(t *testing.T, tup *TupleCons) {
+				tup.Append(one())
+			},
+			"a = [\n  0,\n2, 1]\n",
+		},
+		"remove": {
+			"a = [\n  0,\n  # lead\n  1,\n  2,\n]\n",
+			func(t *testing.T, tup *TupleCons) {
+				if !tup.Remove(1) {
+					t.Error("not removed")
+				}
+				if tup.Remove(2) {
+					t.Error("removed out of range")
+				}
+			},
+			"a = [\n  0,\n  2,\n]\n",
+		},
+		"remove last single line": {
+			"a = [0, 1, 2]\n",
+			func(t *testing.T, tup *TupleCons) {
+				tup.Remove(2)
+			},
+			"a = [0, 1]\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, diags := ParseConfig([]byte(test.src), "", hcl.Pos{Line: 1, Column: 1})
+			if len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+			tup := f.Body().GetAttribute("a").Expr().TupleCons()
+			if tup == nil {
+				t.Fatal("not a tuple constructor")
+			}
+			test.edit(t, tup)
+			if got := string(f.Bytes()); got != test.want {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpressionFunctionCall(t *testing.T) {
+	f, diags := ParseConfig([]byte("a = max(1, local.b) # biggest\n"), "", hcl.Pos{Line: 1, Column: 1})
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	expr := f.Body().GetAttribute("a").Expr()
+	if expr.ObjectCons() != nil || expr.TupleCons() != nil {
+		t.Fatal("function call treated as a constructor")
+	}
+	call := expr.FunctionCall()
+	if call == nil {
+		t.Fatal("not a function call")
+	}
+	if got, want := call.Name(), "max"; got != want {
+		t.Errorf("wrong name %q; want %q", got, want)
+	}
+	if got, want := call.Len(), 2; got != want {
+		t.Errorf("wrong number of arguments %d; want %d", got, want)
+	}
+
+	call.Remove(0)
+	call.Append(NewExpressionLiteral(cty.NumberIntVal(3)))
+	call.Append(NewExpressionAbsTraversal(hcl.Traversal{
+		hcl.TraverseRoot{Name: "local"},
+		hcl.TraverseAttr{Name: "c"},
+	}))
+
+	// Variables and renaming still work on the outer expression after it has
+	// been converted for editing.
+	if got, want := len(expr.Variables()), 2; got != want {
+		t.Errorf("wrong number of variables %d; want %d", got, want)
+	}
+	expr.RenameVariablePrefix([]string{"local", "b"}, []string{"local", "d"})
+
+	want := "a = max(local.d, 3, local.c) # biggest\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("wrong result\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestExpressionFunctionCallExpandFinal(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		edit func(t *testing.T, call *FunctionCall)
+		want string
+	}{
+		"append refused": {
+			"x = f(a, xs...)\n",
+			func(t *testing.T, call *FunctionCall) {
+				if call.Append(NewExpressionLiteral(cty.True)) != nil {
+					t.Error("appended after an expanded argument")
+				}
+			},
+			"x = f(a, xs...)\n",
+		},
+		"append refused with only argument": {
+			"x = f(xs...)\n",
+			func(t *testing.T, call *FunctionCall) {
+				if call.Append(NewExpressionLiteral(cty.True)) != nil {
+					t.Error("appended after an expanded argument")
+				}
+			},
+			"x = f(xs...)\n",
+		},
+		"remove expanded": {
+			"x = f(a, b, xs...)\n",
+			func(t *testing.T, call *FunctionCall) {
+				call.Remove(2)
+			},
+			"x = f(a, b)\n",
+		},
+		"append after removing expanded": {
+			"x = f(a, xs...)\n",
+			func(t *testing.T, call *FunctionCall) {
+				call.Remove(1)
+				if call.Append(NewExpressionLiteral(cty.True)) == nil {
+					t.Error("append refused")
+				}
+			},
+			"x = f(a, true)\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, diags := ParseConfig([]byte(test.src), "", hcl.Pos{Line: 1, Column: 1})
+			if len(diags) != 0 {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+			call := f.Body().GetAttribute("x").Expr().FunctionCall()
+			if call == nil {
+				t.Fatal("not a function call")
+			}
+			test.edit(t, call)
+			got := string(f.Bytes())
+			if got != test.want {
+				t.Errorf("wrong result\ngot:  %q\nwant: %q", got, test.want)
+			}
+			if _, diags := ParseConfig([]byte(got), "", hcl.Pos{Line: 1, Column: 1}); diags.HasErrors() {
+				t.Errorf("result is not valid: %s", diags.Error())
+			}
+		})
+	}
+}
+
+func TestExpressionConsNotApplicable(t *testing.T) {
+	for _, src := range []string{"1", "a.b", "{ a = 1 } + 1", "[for x in y : x]"} {
+		expr := NewExpressionRaw(lexConfig([]byte(src)))
+		if expr.ObjectCons() != nil || expr.TupleCons() != nil || expr.FunctionCall() != nil {
+			t.Errorf("%s was treated as editable", src)
+		}
+		if got := string(expr.BuildTokens(nil).Bytes()); got != src {
+			t.Errorf("%s was changed to %s", src, got)
+		}
+	}
+}
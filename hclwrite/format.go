@@ -4,6 +4,9 @@
 package hclwrite
 
 import (
+	"bytes"
+	"io"
+
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
@@ -24,13 +27,30 @@ func format(tokens Tokens) {
 	// changing the SpacesBefore attribute on a token while leaving the
 	// other token attributes unchanged.
 
+	formatWithOptions(tokens, FormatOptions{})
+}
+
+// formatWithOptions is like format but produces the layout style described
+// by the given options, other than those that require adding or removing
+// tokens.
+func formatWithOptions(tokens Tokens, opts FormatOptions) {
+	indentWidth := 2
+	switch {
+	case opts.UseTabs:
+		// Each level of indent is then a single space that writeTabIndented
+		// will replace with a tab.
+		indentWidth = 1
+	case opts.IndentWidth > 0:
+		indentWidth = opts.IndentWidth
+	}
+
 	lines := linesForFormat(tokens)
-	formatIndent(lines)
+	formatIndent(lines, indentWidth)
 	formatSpaces(lines)
-	formatCells(lines)
+	formatCells(lines, !opts.NoAlignAssignments, !opts.NoAlignComments)
 }
 
-func formatIndent(lines []formatLine) {
+func formatIndent(lines []formatLine, indentWidth int) {
 	// Our methodology for indents is to take the input one line at a time
 	// and count the bracketing delimiters on each line. If a line has a net
 	// increase in open brackets, we increase the indent level by one and
@@ -73,7 +93,7 @@ func formatIndent(lines []formatLine) {
 
 		switch {
 		case netBrackets > 0:
-			line.lead[0].SpacesBefore = 2 * len(indents)
+			line.lead[0].SpacesBefore = indentWidth * len(indents)
 			indents = append(indents, netBrackets)
 		case netBrackets < 0:
 			closed := -netBrackets
@@ -93,9 +113,9 @@ func formatIndent(lines []formatLine) {
 					closed = 0
 				}
 			}
-			line.lead[0].SpacesBefore = 2 * len(indents)
+			line.lead[0].SpacesBefore = indentWidth * len(indents)
 		default:
-			line.lead[0].SpacesBefore = 2 * len(indents)
+			line.lead[0].SpacesBefore = indentWidth * len(indents)
 		}
 	}
 }
@@ -156,10 +176,23 @@ func formatSpaces(lines []formatLine) {
 	}
 }
 
-func formatCells(lines []formatLine) {
+func formatCells(lines []formatLine, alignAssign, alignComments bool) {
 	chainStart := -1
 	maxColumns := 0
 
+	// Without alignment, each cell is just separated from the one before
+	// by a single space.
+	if !alignAssign || !alignComments {
+		for _, line := range lines {
+			if !alignAssign && line.assign != nil {
+				line.assign[0].SpacesBefore = 1
+			}
+			if !alignComments && line.comment != nil {
+				line.comment[0].SpacesBefore = 1
+			}
+		}
+	}
+
 	// We'll deal with the "assign" cell first, since moving that will
 	// also impact the "comment" cell.
 	closeAssignChain := func(i int) {
@@ -172,7 +205,7 @@ func formatCells(lines []formatLine) {
 		maxColumns = 0
 	}
 	for i, line := range lines {
-		if line.assign == nil {
+		if line.assign == nil || !alignAssign {
 			if chainStart != -1 {
 				closeAssignChain(i)
 			}
@@ -201,7 +234,7 @@ func formatCells(lines []formatLine) {
 		maxColumns = 0
 	}
 	for i, line := range lines {
-		if line.comment == nil {
+		if line.comment == nil || !alignComments {
 			if chainStart != -1 {
 				closeCommentChain(i)
 			}
@@ -428,13 +461,136 @@ func linesForFormat(tokens Tokens) []formatLine {
 	return lines
 }
 
+// collapseBlankLines returns the given tokens with each run of consecutive
+// blank lines reduced to a single blank line.
+func collapseBlankLines(tokens Tokens) Tokens {
+	ret := make(Tokens, 0, len(tokens))
+	blanks := 0
+	for i, tok := range tokens {
+		if tok.Type == hclsyntax.TokenNewline && (i == 0 || tokenIsNewline(tokens[i-1])) {
+			blanks++
+			if blanks > 1 {
+				continue
+			}
+		} else {
+			blanks = 0
+		}
+		ret = append(ret, tok)
+	}
+	return ret
+}
+
+// formatTrailingCommas returns the given tokens with a comma added after the
+// last element of each multi-line tuple constructor if add is set, or with
+// any such comma removed otherwise.
+//
+// A tuple constructor is multi-line if its closing bracket is at the start
+// of a line. For expressions are left unchanged, because they can't have
+// a trailing comma.
+func formatTrailingCommas(tokens Tokens, add bool) Tokens {
+	// For each open bracket we record whether it starts a tuple constructor
+	// whose trailing comma we can change.
+	var tuples []bool
+	ret := make(Tokens, 0, len(tokens))
+	for i, tok := range tokens {
+		switch tokenBracketChange(tok) {
+		case 1:
+			tuples = append(tuples, tok.Type == hclsyntax.TokenOBrack && tokenStartsTuple(tokens, i))
+		case -1:
+			if len(tuples) == 0 {
+				break
+			}
+			tuple := tuples[len(tuples)-1]
+			tuples = tuples[:len(tuples)-1]
+			if !tuple || tok.Type != hclsyntax.TokenCBrack || len(ret) == 0 || !tokenIsNewline(ret[len(ret)-1]) {
+				break
+			}
+
+			// The last element ends with the last token before the closing
+			// bracket that isn't a newline or comment.
+			last := len(ret) - 1
+			for last >= 0 && (ret[last].Type == hclsyntax.TokenNewline || ret[last].Type == hclsyntax.TokenComment) {
+				last--
+			}
+			switch {
+			case last < 0 || ret[last].Type == hclsyntax.TokenOBrack:
+				// An empty tuple has no last element.
+			case ret[last].Type == hclsyntax.TokenComma && !add:
+				ret = append(ret[:last], ret[last+1:]...)
+			case ret[last].Type != hclsyntax.TokenComma && add:
+				ret = append(ret[:last+1], append(Tokens{{
+					Type:  hclsyntax.TokenComma,
+					Bytes: []byte{','},
+				}}, ret[last+1:]...)...)
+			}
+		}
+		ret = append(ret, tok)
+	}
+	return ret
+}
+
+// tokenStartsTuple returns true if the open bracket at the given index
+// starts a tuple constructor, rather than an index or splat operator or a
+// for expression.
+func tokenStartsTuple(tokens Tokens, i int) bool {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].Type == hclsyntax.TokenNewline || tokens[j].Type == hclsyntax.TokenComment {
+			continue
+		}
+		if tokens[j].Type == hclsyntax.TokenIdent && string(tokens[j].Bytes) == "for" {
+			return false
+		}
+		break
+	}
+	if i == 0 {
+		return true
+	}
+	switch prev := tokens[i-1]; prev.Type {
+	case hclsyntax.TokenIdent:
+		// The "in" keyword of a for expression can be followed by a tuple
+		// constructor, but any other identifier is being indexed.
+		return string(prev.Bytes) == "in"
+	case hclsyntax.TokenNumberLit, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenCBrace,
+		hclsyntax.TokenCQuote, hclsyntax.TokenCHeredoc:
+		return false
+	}
+	return true
+}
+
+// writeTabIndented is like Tokens.WriteTo, but writes the spaces before the
+// first token on each line as tab characters, for use with tokens formatted
+// with an indent width of one.
+func writeTabIndented(wr io.Writer, tokens Tokens) (int64, error) {
+	var n int64
+	lineStart := true
+	for _, tok := range tokens {
+		space := []byte{' '}
+		if lineStart {
+			space = []byte{'\t'}
+		}
+		thisN, err := wr.Write(bytes.Repeat(space, tok.SpacesBefore))
+		n += int64(thisN)
+		if err != nil {
+			return n, err
+		}
+		thisN, err = wr.Write(tok.Bytes)
+		n += int64(thisN)
+		if err != nil {
+			return n, err
+		}
+		lineStart = len(tok.Bytes) != 0 && tok.Bytes[len(tok.Bytes)-1] == '\n'
+	}
+	return n, nil
+}
+
 func tokenIsNewline(tok *Token) bool {
 	switch tok.Type {
 	case hclsyntax.TokenNewline:
 		return true
-	case hclsyntax.TokenComment:
+	case hclsyntax.TokenComment, hclsyntax.TokenCHeredoc:
 		// Single line tokens (# and //) consume their terminating newline,
-		// so we need to treat them as newline tokens as well.
+		// so we need to treat them as newline tokens as well. The same is
+		// true of heredoc closing markers generated by TokensForExpression.
 		if len(tok.Bytes) > 0 && tok.Bytes[len(tok.Bytes)-1] == '\n' {
 			return true
 		}
@@ -650,6 +650,64 @@ module "x" {
 
 }
 
+func TestFormatWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		opts  FormatOptions
+		input string
+		want  string
+	}{
+		"defaults": {
+			FormatOptions{},
+			"a {\nb = 1 # one\nccc = 2 # two\n}\n",
+			"a {\n  b   = 1 # one\n  ccc = 2 # two\n}\n",
+		},
+		"indent width": {
+			FormatOptions{IndentWidth: 4},
+			"a {\nb {\nc = 1\n}\n}\n",
+			"a {\n    b {\n        c = 1\n    }\n}\n",
+		},
+		"tabs": {
+			FormatOptions{UseTabs: true, IndentWidth: 4},
+			"a {\nb = 1 # one\nccc = <<EOT\n  hello\nEOT\n}\n",
+			"a {\n\tb   = 1 # one\n\tccc = <<EOT\n  hello\nEOT\n}\n",
+		},
+		"no alignment": {
+			FormatOptions{NoAlignAssignments: true, NoAlignComments: true},
+			"b = 1   # one\nccc  = 2 # two\n",
+			"b = 1 # one\nccc = 2 # two\n",
+		},
+		"no comment alignment": {
+			FormatOptions{NoAlignComments: true},
+			"b = 1   # one\nccc  = 2 # two\n",
+			"b   = 1 # one\nccc = 2 # two\n",
+		},
+		"collapse blank lines": {
+			FormatOptions{CollapseBlankLines: true},
+			"a = 1\n\n\n# comment\n\n\n\nb = <<EOT\nx\n\n\nEOT\n",
+			"a = 1\n\n# comment\n\nb = <<EOT\nx\n\n\nEOT\n",
+		},
+		"add trailing commas": {
+			FormatOptions{TrailingCommas: TrailingCommasAlways},
+			"a = [\n1,\n2 # two\n]\nb = [1, 2]\nc = [\n]\nd = [for x in [\n1\n] : x\n]\ne = f(\n1\n)\n",
+			"a = [\n  1,\n  2, # two\n]\nb = [1, 2]\nc = [\n]\nd = [for x in [\n  1,\n  ] : x\n]\ne = f(\n  1\n)\n",
+		},
+		"remove trailing commas": {
+			FormatOptions{TrailingCommas: TrailingCommasNever},
+			"a = [\n1,\n2, # two\n]\nb = [1, 2,]\nc = x[\n0\n]\n",
+			"a = [\n  1,\n  2 # two\n]\nb = [1, 2, ]\nc = x[\n  0\n]\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := string(FormatWithOptions([]byte(test.input), test.opts))
+			if got != test.want {
+				t.Errorf("wrong result\ninput:\n%s\ngot:\n%s\nwant:\n%s", test.input, got, test.want)
+			}
+		})
+	}
+}
+
 func TestLinesForFormat(t *testing.T) {
 	tests := []struct {
 		tokens Tokens
@@ -0,0 +1,457 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/apparentlymart/go-textseg/v15/textseg"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// wrapEdit is a change to the tokens of a line to break it into several
+// lines: the given tokens are inserted before the token at the given index,
+// which is removed first if remove is set.
+type wrapEdit struct {
+	at     int
+	remove bool
+	insert Tokens
+}
+
+// wrapLongLines formats the given tokens with the given options, breaking
+// any line that is longer than opts.MaxWidth across several lines where that
+// is possible, and returns the resulting tokens.
+//
+// Each pass breaks the outermost construct on each long line that both
+// starts and ends on that line, and then formats the result again so that
+// the next pass can measure the new lines. We stop once there is nothing
+// left that we can break, and so formatting the result again produces no
+// further changes.
+func wrapLongLines(tokens Tokens, opts FormatOptions) Tokens {
+	tabWidth := 0
+	if opts.UseTabs {
+		tabWidth = opts.IndentWidth
+		if tabWidth <= 0 {
+			tabWidth = 4
+		}
+	}
+
+	for {
+		formatWithOptions(tokens, opts)
+
+		var edits []wrapEdit
+		var enclosing []wrapBracket
+		start := 0
+		for _, line := range linesForFormat(tokens) {
+			n := len(line.lead) + len(line.assign) + len(line.comment)
+			lineToks := tokens[start : start+n]
+
+			outer := wrapBracket{ty: hclsyntax.TokenNil}
+			if len(enclosing) != 0 {
+				outer = enclosing[len(enclosing)-1]
+			}
+			if !outer.forExpr && formatLineWidth(line, tabWidth) > opts.MaxWidth {
+				for _, edit := range wrapLineEdits(lineToks, outer.ty, opts) {
+					edit.at += start
+					edits = append(edits, edit)
+				}
+			}
+
+			for i, tok := range lineToks {
+				switch tokenBracketChange(tok) {
+				case 1:
+					enclosing = append(enclosing, wrapBracket{
+						ty:      tok.Type,
+						forExpr: outer.forExpr || tokenStartsForExpr(tokens[start:], i),
+					})
+				case -1:
+					if len(enclosing) != 0 {
+						enclosing = enclosing[:len(enclosing)-1]
+					}
+				}
+			}
+			start += n
+		}
+		if len(edits) == 0 {
+			return tokens
+		}
+
+		ret := make(Tokens, 0, len(tokens)+len(edits)*2)
+		e := 0
+		for i := 0; i <= len(tokens); i++ {
+			remove := false
+			for ; e < len(edits) && edits[e].at == i; e++ {
+				remove = remove || edits[e].remove
+				ret = append(ret, edits[e].insert...)
+			}
+			if i < len(tokens) && !remove {
+				ret = append(ret, tokens[i])
+			}
+		}
+		tokens = ret
+	}
+}
+
+// formatLineWidth returns the number of columns that the given formatted line
+// occupies, up to the end of its first line of text if it contains tokens
+// that span several lines, such as a heredoc template.
+//
+// If tabWidth is not zero then the line is indented with tabs that each
+// occupy that number of columns.
+func formatLineWidth(line formatLine, tabWidth int) int {
+	width := 0
+	if tabWidth > 0 && len(line.lead) != 0 {
+		width += line.lead[0].SpacesBefore * (tabWidth - 1)
+	}
+	for _, cell := range []Tokens{line.lead, line.assign, line.comment} {
+		for _, tok := range cell {
+			width += tok.SpacesBefore
+			text := tok.Bytes
+			nl := bytes.IndexByte(text, '\n')
+			if nl >= 0 {
+				text = text[:nl]
+			}
+			count, _ := textseg.TokenCount(text, textseg.ScanGraphemeClusters)
+			width += count
+			if nl >= 0 {
+				return width
+			}
+		}
+	}
+	return width
+}
+
+// wrapBracket describes a bracket that is open at the start of a line.
+type wrapBracket struct {
+	ty hclsyntax.TokenType
+
+	// forExpr is set if the bracket starts a for expression or is nested
+	// inside one. We don't break anything inside for expressions, whose
+	// parts don't fit our model of items on separate lines.
+	forExpr bool
+}
+
+// wrapCandidate is a construct on a single line that could be broken across
+// several lines.
+type wrapCandidate struct {
+	depth int
+	start int
+	edits []wrapEdit
+}
+
+// wrapLineEdits returns the edits that break the outermost construct in the
+// given line of tokens that can be broken, or nil if there is no such
+// construct. outer is the type of the innermost bracket that is open at the
+// start of the line, or TokenNil if there is none.
+func wrapLineEdits(line Tokens, outer hclsyntax.TokenType, opts FormatOptions) []wrapEdit {
+	// The content of templates is never broken, so we exclude all of the
+	// tokens between their delimiters.
+	inTemplate := make([]bool, len(line))
+	nesting := 0
+	for i, tok := range line {
+		switch tok.Type {
+		case hclsyntax.TokenOQuote, hclsyntax.TokenOHeredoc:
+			nesting++
+			continue
+		case hclsyntax.TokenCQuote, hclsyntax.TokenCHeredoc:
+			nesting--
+			continue
+		}
+		inTemplate[i] = nesting > 0
+	}
+
+	var candidates []wrapCandidate
+	var open []int
+	forDepth := -1 // the depth of the outermost for expression, if any
+	for i, tok := range line {
+		if inTemplate[i] {
+			continue
+		}
+		switch {
+		case tokenBracketChange(tok) > 0:
+			if forDepth < 0 && tokenStartsForExpr(line, i) {
+				forDepth = len(open)
+			}
+			open = append(open, i)
+		case tokenBracketChange(tok) < 0:
+			if len(open) == 0 {
+				continue
+			}
+			o := open[len(open)-1]
+			open = open[:len(open)-1]
+			if forDepth >= 0 && len(open) > forDepth {
+				continue
+			}
+			if len(open) == forDepth {
+				forDepth = -1
+			}
+			if edits := wrapBracketEdits(line, inTemplate, o, i, opts); edits != nil {
+				candidates = append(candidates, wrapCandidate{len(open), o, edits})
+			}
+		case tok.Type == hclsyntax.TokenQuestion:
+			if forDepth >= 0 {
+				continue
+			}
+			innermost := outer
+			if len(open) != 0 {
+				innermost = line[open[len(open)-1]].Type
+			}
+			if start, edits := wrapConditionalEdits(line, inTemplate, i, innermost); edits != nil {
+				candidates = append(candidates, wrapCandidate{len(open), start, edits})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// A conditional that starts at the same place as a bracket contains
+	// it, so it must come first among candidates of the same depth.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+		return candidates[i].start < candidates[j].start
+	})
+	edits := candidates[0].edits
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].at < edits[j].at
+	})
+	return edits
+}
+
+// wrapBracketEdits returns the edits that break the content of the brackets
+// at the given indices across several lines, with each item, element or
+// argument on a line of its own, or nil if they can't be broken.
+func wrapBracketEdits(line Tokens, inTemplate []bool, o, c int, opts FormatOptions) []wrapEdit {
+	if c == o+1 {
+		return nil // nothing to break
+	}
+	for _, tok := range line[o:c] {
+		if tok.Type == hclsyntax.TokenOHeredoc {
+			return nil
+		}
+	}
+
+	splitCommas := true
+	tuple := false
+	switch line[o].Type {
+	case hclsyntax.TokenOBrace:
+		// Newlines are significant inside braces, and so we can't break a
+		// for expression there.
+		if tokenStartsForExpr(line, o) {
+			return nil
+		}
+	case hclsyntax.TokenOBrack:
+		switch {
+		case tokenStartsForExpr(line, o):
+			splitCommas = false
+		case tokenStartsTuple(line, o):
+			tuple = true
+		default:
+			return nil // an index or splat operator
+		}
+	case hclsyntax.TokenOParen:
+	default:
+		return nil // a template sequence
+	}
+
+	edits := []wrapEdit{{at: o + 1, insert: wrapNewline()}}
+	if splitCommas {
+		depth := 0
+		for i := o + 1; i < c; i++ {
+			if inTemplate[i] {
+				continue
+			}
+			depth += tokenBracketChange(line[i])
+			if depth != 0 || line[i].Type != hclsyntax.TokenComma {
+				continue
+			}
+			switch {
+			case line[o].Type == hclsyntax.TokenOBrace:
+				// Object items on separate lines don't need commas.
+				edit := wrapEdit{at: i, remove: true}
+				if i != c-1 {
+					edit.insert = wrapNewline()
+				}
+				edits = append(edits, edit)
+			case i != c-1:
+				edits = append(edits, wrapEdit{at: i + 1, insert: wrapNewline()})
+			}
+		}
+	}
+	if tuple {
+		// A multi-line tuple has a trailing comma unless the options say
+		// otherwise, matching what formatTrailingCommas would do.
+		hasComma := line[c-1].Type == hclsyntax.TokenComma
+		switch never := opts.TrailingCommas == TrailingCommasNever; {
+		case hasComma && never:
+			edits = append(edits, wrapEdit{at: c - 1, remove: true})
+		case !hasComma && !never:
+			edits = append(edits, wrapEdit{at: c, insert: Tokens{wrapComma()}})
+		}
+	}
+	return append(edits, wrapEdit{at: c, insert: wrapNewline()})
+}
+
+// wrapConditionalEdits returns the start index of the conditional expression
+// whose question mark is at the given index, and the edits that break it
+// across several lines with the condition and each result on a line of their
+// own, or nil edits if it can't be broken. innermost is the type of the
+// innermost bracket that contains the conditional, or TokenNil if none.
+//
+// Newlines are significant outside of parentheses and brackets, so unless the
+// conditional is already the whole content of a pair of parentheses we add
+// parentheses around it.
+func wrapConditionalEdits(line Tokens, inTemplate []bool, q int, innermost hclsyntax.TokenType) (int, []wrapEdit) {
+	// The colon is the first at our depth that isn't part of a nested
+	// conditional.
+	k := -1
+	depth, nested := 0, 0
+	for i := q + 1; i < len(line) && k < 0; i++ {
+		if inTemplate[i] {
+			continue
+		}
+		depth += tokenBracketChange(line[i])
+		switch {
+		case depth < 0:
+			return 0, nil
+		case depth > 0:
+		case line[i].Type == hclsyntax.TokenQuestion:
+			nested++
+		case line[i].Type == hclsyntax.TokenColon && nested > 0:
+			nested--
+		case line[i].Type == hclsyntax.TokenColon:
+			k = i
+		}
+	}
+	if k < 0 {
+		return 0, nil
+	}
+
+	start := 0
+	depth = 0
+Start:
+	for i := q - 1; i >= 0; i-- {
+		if inTemplate[i] {
+			continue
+		}
+		depth -= tokenBracketChange(line[i])
+		switch {
+		case depth < 0:
+			start = i + 1
+			break Start
+		case depth > 0:
+		case wrapConditionalDelimiter(line[i]):
+			start = i + 1
+			break Start
+		}
+	}
+
+	end := len(line)
+	depth = 0
+End:
+	for i := k + 1; i < len(line); i++ {
+		if inTemplate[i] {
+			continue
+		}
+		depth += tokenBracketChange(line[i])
+		switch {
+		case depth < 0:
+			end = i
+			break End
+		case depth > 0:
+		case line[i].Type == hclsyntax.TokenNewline || line[i].Type == hclsyntax.TokenComment ||
+			line[i].Type == hclsyntax.TokenComma || line[i].Type == hclsyntax.TokenFatArrow:
+			end = i
+			break End
+		}
+	}
+
+	edits := []wrapEdit{
+		{at: q, insert: wrapNewline()},
+		{at: k, insert: wrapNewline()},
+	}
+	parenthesized := start > 0 && end < len(line) &&
+		line[start-1].Type == hclsyntax.TokenOParen && line[end].Type == hclsyntax.TokenCParen &&
+		(start < 2 || line[start-2].Type != hclsyntax.TokenIdent)
+	switch {
+	case parenthesized:
+		edits = append(edits,
+			wrapEdit{at: start, insert: wrapNewline()},
+			wrapEdit{at: end, insert: wrapNewline()},
+		)
+	case start == 0 && outerIgnoresNewlines(innermost) && restIsLineEnd(line[end:]):
+		// The conditional is already on lines of its own inside brackets
+		// where newlines are not significant.
+	default:
+		edits = append(edits,
+			wrapEdit{at: start, insert: append(Tokens{{Type: hclsyntax.TokenOParen, Bytes: []byte{'('}}}, wrapNewline()...)},
+			wrapEdit{at: end, insert: append(wrapNewline(), &Token{Type: hclsyntax.TokenCParen, Bytes: []byte{')'}})},
+		)
+	}
+	return start, edits
+}
+
+// wrapConditionalDelimiter returns true if the given token ends the
+// expression before it, so that a conditional expression after it starts
+// just after it.
+func wrapConditionalDelimiter(tok *Token) bool {
+	switch tok.Type {
+	case hclsyntax.TokenEqual, hclsyntax.TokenComma, hclsyntax.TokenColon,
+		hclsyntax.TokenQuestion, hclsyntax.TokenFatArrow:
+		return true
+	}
+	return false
+}
+
+func outerIgnoresNewlines(ty hclsyntax.TokenType) bool {
+	return ty == hclsyntax.TokenOParen || ty == hclsyntax.TokenOBrack
+}
+
+// restIsLineEnd returns true if the given tokens are just an optional comma
+// followed by the end of a line.
+func restIsLineEnd(toks Tokens) bool {
+	if len(toks) != 0 && toks[0].Type == hclsyntax.TokenComma {
+		toks = toks[1:]
+	}
+	for _, tok := range toks {
+		if tok.Type != hclsyntax.TokenNewline && tok.Type != hclsyntax.TokenComment {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenStartsForExpr returns true if the open bracket at the given index
+// starts a for expression, which may begin on a later line.
+func tokenStartsForExpr(tokens Tokens, i int) bool {
+	for j := i + 1; j < len(tokens); j++ {
+		switch tok := tokens[j]; tok.Type {
+		case hclsyntax.TokenNewline, hclsyntax.TokenComment:
+			continue
+		case hclsyntax.TokenIdent:
+			return string(tok.Bytes) == "for"
+		}
+		return false
+	}
+	return false
+}
+
+func wrapNewline() Tokens {
+	return Tokens{
+		{
+			Type:  hclsyntax.TokenNewline,
+			Bytes: []byte{'\n'},
+		},
+	}
+}
+
+func wrapComma() *Token {
+	return &Token{
+		Type:  hclsyntax.TokenComma,
+		Bytes: []byte{','},
+	}
+}
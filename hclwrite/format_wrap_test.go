@@ -0,0 +1,101 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestFormatWithOptionsMaxWidth(t *testing.T) {
+	tests := map[string]struct {
+		opts  FormatOptions
+		input string
+		want  string
+	}{
+		"short": {
+			FormatOptions{MaxWidth: 20},
+			"a = [1, 2]\nb = f(x) ? 1 : 2\n",
+			"a = [1, 2]\nb = f(x) ? 1 : 2\n",
+		},
+		"tuple": {
+			FormatOptions{MaxWidth: 20},
+			"a = [\"one\", \"two\", \"three\"] # numbers\n",
+			"a = [\n  \"one\",\n  \"two\",\n  \"three\",\n] # numbers\n",
+		},
+		"tuple without trailing comma": {
+			FormatOptions{MaxWidth: 20, TrailingCommas: TrailingCommasNever},
+			"a = [\"one\", \"two\", \"three\",]\n",
+			"a = [\n  \"one\",\n  \"two\",\n  \"three\"\n]\n",
+		},
+		"object": {
+			FormatOptions{MaxWidth: 20},
+			"a = { b = 1, cc = [1, 2], d = 3, }\n",
+			"a = {\n  b  = 1\n  cc = [1, 2]\n  d  = 3\n}\n",
+		},
+		"nested": {
+			FormatOptions{MaxWidth: 24},
+			"blk {\n  a = { b = f(1, x.y[0], \"long string\") }\n}\n",
+			"blk {\n  a = {\n    b = f(\n      1,\n      x.y[0],\n      \"long string\"\n    )\n  }\n}\n",
+		},
+		"conditional": {
+			FormatOptions{MaxWidth: 20},
+			"a = b == c ? \"yes\" : \"no\"\n",
+			"a = (\n  b == c\n  ? \"yes\"\n  : \"no\"\n)\n",
+		},
+		"conditional in parentheses": {
+			FormatOptions{MaxWidth: 20},
+			"a = (b == c ? \"yes\" : \"no\")\n",
+			"a = (\n  b == c\n  ? \"yes\"\n  : \"no\"\n)\n",
+		},
+		"conditional containing brackets": {
+			FormatOptions{MaxWidth: 22},
+			"a = b ? [1, 2] : [3, 4, 5, 6, 7, 8]\n",
+			"a = (\n  b\n  ? [1, 2]\n  : [3, 4, 5, 6, 7, 8]\n)\n",
+		},
+		"conditional argument": {
+			FormatOptions{MaxWidth: 24},
+			"a = f(1, bbbb ? \"yes\" : \"no\")\n",
+			"a = f(\n  1,\n  bbbb ? \"yes\" : \"no\"\n)\n",
+		},
+		"for expression": {
+			FormatOptions{MaxWidth: 20},
+			"a = [for x in f(y, z) : upper(x) if x != \"\"]\nb = { for k, v in c : k => v }\n",
+			"a = [\n  for x in f(y, z) : upper(x) if x != \"\"\n]\nb = { for k, v in c : k => v }\n",
+		},
+		"unbreakable": {
+			FormatOptions{MaxWidth: 10},
+			"a = \"${f(1, 2)} is a long string\"\nb = x[\"long index\"]\n",
+			"a = \"${f(1, 2)} is a long string\"\nb = x[\"long index\"]\n",
+		},
+		"heredoc": {
+			FormatOptions{MaxWidth: 20},
+			"a = [<<EOT\nhello, world, this is long\nEOT\n, 2]\nb = f(1, 2, 3, 4, 5, 6)\n",
+			"a = [<<EOT\nhello, world, this is long\nEOT\n, 2]\nb = f(\n  1,\n  2,\n  3,\n  4,\n  5,\n  6\n)\n",
+		},
+		"tabs": {
+			FormatOptions{MaxWidth: 20, UseTabs: true},
+			"blk {\n  a = [\"one\", \"two\"]\n}\n",
+			"blk {\n\ta = [\n\t\t\"one\",\n\t\t\"two\",\n\t]\n}\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := string(FormatWithOptions([]byte(test.input), test.opts))
+			if got != test.want {
+				t.Fatalf("wrong result\ninput:\n%s\ngot:\n%s\nwant:\n%s", test.input, got, test.want)
+			}
+
+			if again := string(FormatWithOptions([]byte(got), test.opts)); again != got {
+				t.Errorf("result changed when formatted again\ngot:\n%s", again)
+			}
+			if _, diags := hclsyntax.ParseConfig([]byte(got), "", hcl.InitialPos); diags.HasErrors() {
+				t.Errorf("result is not valid: %s", diags.Error())
+			}
+		})
+	}
+}
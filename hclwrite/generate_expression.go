@@ -0,0 +1,504 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TokensForExpression returns a sequence of tokens that represents the given
+// native syntax expression, which may have been produced by the parser or
+// constructed or modified in memory.
+//
+// The result is in canonical form, with parentheses added only where they
+// are needed to preserve the structure of the expression, and so parsing the
+// result produces an expression equivalent to the given one, though without
+// any redundant parentheses that the given expression may have contained
+// only implicitly. Since the native syntax AST does not retain the form of
+// template sequences, templates containing conditional and repetition
+// constructs are written using template directives. A top-level template
+// that ends with a newline and spans at least two lines is written as a
+// heredoc, even if it was given as a quoted string with escaped newlines,
+// and the result then ends with the newline that terminates the heredoc.
+// Templates nested inside other expressions are always written as quoted
+// strings.
+//
+// The given expression must not contain any unknown values or any
+// hclsyntax.ExprSyntaxError nodes, and TokensForExpression will panic if
+// it does.
+func TokensForExpression(expr hclsyntax.Expression) Tokens {
+	toks := appendTokensForExpression(expr, nil, exprPrecLowest, true)
+	format(toks) // fiddle with the SpacesBefore field to get canonical spacing
+	return toks
+}
+
+// These are the precedence levels of the different kinds of expression,
+// from lowest to highest, as understood by the native syntax parser.
+const (
+	exprPrecLowest = iota
+	exprPrecOr
+	exprPrecAnd
+	exprPrecEquality
+	exprPrecComparison
+	exprPrecAdditive
+	exprPrecMultiplicative
+	exprPrecUnary
+	exprPrecPostfix
+)
+
+type exprOperator struct {
+	Type  hclsyntax.TokenType
+	Bytes string
+	Prec  int
+}
+
+var exprOperators = map[*hclsyntax.Operation]exprOperator{
+	hclsyntax.OpLogicalOr:          {hclsyntax.TokenOr, "||", exprPrecOr},
+	hclsyntax.OpLogicalAnd:         {hclsyntax.TokenAnd, "&&", exprPrecAnd},
+	hclsyntax.OpEqual:              {hclsyntax.TokenEqualOp, "==", exprPrecEquality},
+	hclsyntax.OpNotEqual:           {hclsyntax.TokenNotEqual, "!=", exprPrecEquality},
+	hclsyntax.OpGreaterThan:        {hclsyntax.TokenGreaterThan, ">", exprPrecComparison},
+	hclsyntax.OpGreaterThanOrEqual: {hclsyntax.TokenGreaterThanEq, ">=", exprPrecComparison},
+	hclsyntax.OpLessThan:           {hclsyntax.TokenLessThan, "<", exprPrecComparison},
+	hclsyntax.OpLessThanOrEqual:    {hclsyntax.TokenLessThanEq, "<=", exprPrecComparison},
+	hclsyntax.OpAdd:                {hclsyntax.TokenPlus, "+", exprPrecAdditive},
+	hclsyntax.OpSubtract:           {hclsyntax.TokenMinus, "-", exprPrecAdditive},
+	hclsyntax.OpMultiply:           {hclsyntax.TokenStar, "*", exprPrecMultiplicative},
+	hclsyntax.OpDivide:             {hclsyntax.TokenSlash, "/", exprPrecMultiplicative},
+	hclsyntax.OpModulo:             {hclsyntax.TokenPercent, "%", exprPrecMultiplicative},
+	hclsyntax.OpLogicalNot:         {hclsyntax.TokenBang, "!", exprPrecUnary},
+	hclsyntax.OpNegate:             {hclsyntax.TokenMinus, "-", exprPrecUnary},
+}
+
+func exprPrecedence(expr hclsyntax.Expression) int {
+	switch e := expr.(type) {
+	case *hclsyntax.ConditionalExpr:
+		return exprPrecLowest
+	case *hclsyntax.BinaryOpExpr:
+		return exprOperators[e.Op].Prec
+	case *hclsyntax.UnaryOpExpr:
+		return exprPrecUnary
+	case *hclsyntax.SplatExpr:
+		// A splat expression can't be the source of another postfix
+		// operator, since that operator would then be interpreted as part
+		// of the splat expression itself.
+		return exprPrecUnary
+	default:
+		return exprPrecPostfix
+	}
+}
+
+// appendTokensForExpression appends the tokens for the given expression,
+// wrapping it in parentheses if its precedence is lower than minPrec. A
+// multi-line template is written as a heredoc only if root is true.
+func appendTokensForExpression(expr hclsyntax.Expression, toks Tokens, minPrec int, root bool) Tokens {
+	if exprPrecedence(expr) < minPrec {
+		toks = append(toks, newToken(hclsyntax.TokenOParen, "("))
+		toks = appendTokensForExpression(expr, toks, exprPrecLowest, false)
+		return append(toks, newToken(hclsyntax.TokenCParen, ")"))
+	}
+
+	switch e := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		return appendTokensForValue(e.Val, toks)
+
+	case *hclsyntax.ScopeTraversalExpr:
+		return appendTokensForTraversal(e.Traversal, toks)
+
+	case *hclsyntax.RelativeTraversalExpr:
+		toks = appendTokensForExpression(e.Source, toks, exprPrecPostfix, false)
+		return appendTokensForTraversal(e.Traversal, toks)
+
+	case *hclsyntax.ParenthesesExpr:
+		toks = append(toks, newToken(hclsyntax.TokenOParen, "("))
+		toks = appendTokensForExpression(e.Expression, toks, exprPrecLowest, false)
+		return append(toks, newToken(hclsyntax.TokenCParen, ")"))
+
+	case *hclsyntax.FunctionCallExpr:
+		for i, name := range strings.Split(e.Name, "::") {
+			if i > 0 {
+				toks = append(toks, newToken(hclsyntax.TokenDoubleColon, "::"))
+			}
+			toks = append(toks, newIdentToken(name))
+		}
+		toks = append(toks, newToken(hclsyntax.TokenOParen, "("))
+		for i, arg := range e.Args {
+			if i > 0 {
+				toks = append(toks, newToken(hclsyntax.TokenComma, ","))
+			}
+			toks = appendTokensForExpression(arg, toks, exprPrecLowest, false)
+		}
+		if e.ExpandFinal && len(e.Args) > 0 {
+			toks = append(toks, newToken(hclsyntax.TokenEllipsis, "..."))
+		}
+		return append(toks, newToken(hclsyntax.TokenCParen, ")"))
+
+	case *hclsyntax.ConditionalExpr:
+		toks = appendTokensForExpression(e.Condition, toks, exprPrecOr, false)
+		toks = append(toks, newToken(hclsyntax.TokenQuestion, "?"))
+		toks = appendTokensForExpression(e.TrueResult, toks, exprPrecLowest, false)
+		toks = append(toks, newToken(hclsyntax.TokenColon, ":"))
+		return appendTokensForExpression(e.FalseResult, toks, exprPrecLowest, false)
+
+	case *hclsyntax.BinaryOpExpr:
+		op, ok := exprOperators[e.Op]
+		if !ok {
+			panic(fmt.Sprintf("unsupported binary operation %#v", e.Op))
+		}
+		// Binary operators are left-associative, so an operand on the right
+		// with the same precedence must be in parentheses.
+		toks = appendTokensForExpression(e.LHS, toks, op.Prec, false)
+		toks = append(toks, newToken(op.Type, op.Bytes))
+		return appendTokensForExpression(e.RHS, toks, op.Prec+1, false)
+
+	case *hclsyntax.UnaryOpExpr:
+		op, ok := exprOperators[e.Op]
+		if !ok {
+			panic(fmt.Sprintf("unsupported unary operation %#v", e.Op))
+		}
+		toks = append(toks, newToken(op.Type, op.Bytes))
+		return appendTokensForExpression(e.Val, toks, exprPrecUnary, false)
+
+	case *hclsyntax.IndexExpr:
+		toks = appendTokensForExpression(e.Collection, toks, exprPrecPostfix, false)
+		toks = append(toks, newToken(hclsyntax.TokenOBrack, "["))
+		toks = appendTokensForExpression(e.Key, toks, exprPrecLowest, false)
+		return append(toks, newToken(hclsyntax.TokenCBrack, "]"))
+
+	case *hclsyntax.TupleConsExpr:
+		toks = append(toks, newToken(hclsyntax.TokenOBrack, "["))
+		for i, elem := range e.Exprs {
+			if i > 0 {
+				toks = append(toks, newToken(hclsyntax.TokenComma, ","))
+			}
+			toks = appendTokensForExpression(elem, toks, exprPrecLowest, false)
+		}
+		return append(toks, newToken(hclsyntax.TokenCBrack, "]"))
+
+	case *hclsyntax.ObjectConsExpr:
+		toks = append(toks, newToken(hclsyntax.TokenOBrace, "{"))
+		if len(e.Items) > 0 {
+			toks = append(toks, newToken(hclsyntax.TokenNewline, "\n"))
+		}
+		for _, item := range e.Items {
+			toks = appendTokensForObjectKey(item.KeyExpr, toks)
+			toks = append(toks, newToken(hclsyntax.TokenEqual, "="))
+			toks = appendTokensForExpression(item.ValueExpr, toks, exprPrecLowest, false)
+			toks = append(toks, newToken(hclsyntax.TokenNewline, "\n"))
+		}
+		return append(toks, newToken(hclsyntax.TokenCBrace, "}"))
+
+	case *hclsyntax.ObjectConsKeyExpr:
+		return appendTokensForObjectKey(e, toks)
+
+	case *hclsyntax.ForExpr:
+		open, close := newToken(hclsyntax.TokenOBrack, "["), newToken(hclsyntax.TokenCBrack, "]")
+		if e.KeyExpr != nil {
+			open, close = newToken(hclsyntax.TokenOBrace, "{"), newToken(hclsyntax.TokenCBrace, "}")
+		}
+		toks = append(toks, open)
+		toks = appendTokensForForIntro(e, toks)
+		toks = append(toks, newToken(hclsyntax.TokenColon, ":"))
+		if e.KeyExpr != nil {
+			toks = appendTokensForExpression(e.KeyExpr, toks, exprPrecLowest, false)
+			toks = append(toks, newToken(hclsyntax.TokenFatArrow, "=>"))
+		}
+		toks = appendTokensForExpression(e.ValExpr, toks, exprPrecLowest, false)
+		if e.Group {
+			toks = append(toks, newToken(hclsyntax.TokenEllipsis, "..."))
+		}
+		if e.CondExpr != nil {
+			toks = append(toks, newIdentToken("if"))
+			toks = appendTokensForExpression(e.CondExpr, toks, exprPrecLowest, false)
+		}
+		return append(toks, close)
+
+	case *hclsyntax.SplatExpr:
+		toks = appendTokensForExpression(e.Source, toks, exprPrecPostfix, false)
+		toks = append(
+			toks,
+			newToken(hclsyntax.TokenOBrack, "["),
+			newToken(hclsyntax.TokenStar, "*"),
+			newToken(hclsyntax.TokenCBrack, "]"),
+		)
+		// The "each" expression is written in terms of the splat's symbol,
+		// which produces no tokens of its own, so any traversals from it
+		// follow directly after the splat operator.
+		return appendTokensForExpression(e.Each, toks, exprPrecLowest, false)
+
+	case *hclsyntax.AnonSymbolExpr:
+		return toks
+
+	case *hclsyntax.TemplateExpr:
+		if root && templateIsHeredoc(e) {
+			return appendTokensForHeredoc(e, toks)
+		}
+		toks = append(toks, newToken(hclsyntax.TokenOQuote, `"`))
+		toks = appendTokensForTemplateParts(e.Parts, toks, false)
+		return append(toks, newToken(hclsyntax.TokenCQuote, `"`))
+
+	case *hclsyntax.TemplateWrapExpr, *hclsyntax.TemplateJoinExpr:
+		toks = append(toks, newToken(hclsyntax.TokenOQuote, `"`))
+		toks = appendTokensForTemplateParts([]hclsyntax.Expression{e}, toks, false)
+		return append(toks, newToken(hclsyntax.TokenCQuote, `"`))
+
+	default:
+		panic(fmt.Sprintf("cannot produce tokens for %T", expr))
+	}
+}
+
+// appendTokensForObjectKey appends the tokens for the key of an item in an
+// object constructor, adding parentheses if necessary to ensure that the key
+// is interpreted as an expression rather than as a literal attribute name.
+func appendTokensForObjectKey(expr hclsyntax.Expression, toks Tokens) Tokens {
+	forceNonLiteral := false
+	if keyExpr, ok := expr.(*hclsyntax.ObjectConsKeyExpr); ok {
+		expr = keyExpr.Wrapped
+		forceNonLiteral = keyExpr.ForceNonLiteral
+	} else {
+		switch expr.(type) {
+		case *hclsyntax.TemplateExpr, *hclsyntax.LiteralValueExpr:
+			// These can't be mistaken for attribute names.
+		default:
+			forceNonLiteral = true
+		}
+	}
+	if _, isParens := expr.(*hclsyntax.ParenthesesExpr); forceNonLiteral && !isParens {
+		toks = append(toks, newToken(hclsyntax.TokenOParen, "("))
+		toks = appendTokensForExpression(expr, toks, exprPrecLowest, false)
+		return append(toks, newToken(hclsyntax.TokenCParen, ")"))
+	}
+	return appendTokensForExpression(expr, toks, exprPrecLowest, false)
+}
+
+// appendTokensForForIntro appends the "for" keyword and the symbol and
+// collection clauses that introduce both for expressions and for directives.
+func appendTokensForForIntro(e *hclsyntax.ForExpr, toks Tokens) Tokens {
+	toks = append(toks, newIdentToken("for"))
+	if e.KeyVar != "" {
+		toks = append(toks, newIdentToken(e.KeyVar), newToken(hclsyntax.TokenComma, ","))
+	}
+	toks = append(toks, newIdentToken(e.ValVar), newIdentToken("in"))
+	return appendTokensForExpression(e.CollExpr, toks, exprPrecLowest, false)
+}
+
+// appendTokensForTemplateParts appends the tokens for the given template
+// parts, for either a quoted template or a heredoc.
+func appendTokensForTemplateParts(parts []hclsyntax.Expression, toks Tokens, heredoc bool) Tokens {
+	for _, part := range parts {
+		switch e := part.(type) {
+		case *hclsyntax.LiteralValueExpr:
+			if e.Val.Type() == cty.String && e.Val.IsKnown() && !e.Val.IsNull() {
+				toks = appendTokensForTemplateLiteral(e.Val.AsString(), toks, heredoc)
+				continue
+			}
+
+		case *hclsyntax.TemplateWrapExpr:
+			toks = appendTokensForTemplateParts([]hclsyntax.Expression{e.Wrapped}, toks, heredoc)
+			continue
+
+		case *hclsyntax.ConditionalExpr:
+			trueTmpl, trueOK := e.TrueResult.(*hclsyntax.TemplateExpr)
+			falseTmpl, falseOK := e.FalseResult.(*hclsyntax.TemplateExpr)
+			if !trueOK || !falseOK {
+				break
+			}
+			toks = appendTokensForTemplateControl(toks, func(toks Tokens) Tokens {
+				toks = append(toks, newIdentToken("if"))
+				return appendTokensForExpression(e.Condition, toks, exprPrecLowest, false)
+			})
+			toks = appendTokensForTemplateParts(trueTmpl.Parts, toks, heredoc)
+			if !templateIsEmpty(falseTmpl) {
+				toks = appendTokensForTemplateControl(toks, func(toks Tokens) Tokens {
+					return append(toks, newIdentToken("else"))
+				})
+				toks = appendTokensForTemplateParts(falseTmpl.Parts, toks, heredoc)
+			}
+			toks = appendTokensForTemplateControl(toks, func(toks Tokens) Tokens {
+				return append(toks, newIdentToken("endif"))
+			})
+			continue
+
+		case *hclsyntax.TemplateJoinExpr:
+			forExpr, ok := e.Tuple.(*hclsyntax.ForExpr)
+			var body *hclsyntax.TemplateExpr
+			if ok && forExpr.KeyExpr == nil && forExpr.CondExpr == nil {
+				body, ok = forExpr.ValExpr.(*hclsyntax.TemplateExpr)
+			}
+			if !ok || body == nil {
+				// There's no syntax for joining an arbitrary sequence other
+				// than a for directive, so we'll produce an equivalent one.
+				forExpr = &hclsyntax.ForExpr{
+					ValVar:   "v",
+					CollExpr: e.Tuple,
+				}
+				body = &hclsyntax.TemplateExpr{
+					Parts: []hclsyntax.Expression{
+						&hclsyntax.ScopeTraversalExpr{
+							Traversal: hcl.Traversal{hcl.TraverseRoot{Name: "v"}},
+						},
+					},
+				}
+			}
+			toks = appendTokensForTemplateControl(toks, func(toks Tokens) Tokens {
+				return appendTokensForForIntro(forExpr, toks)
+			})
+			toks = appendTokensForTemplateParts(body.Parts, toks, heredoc)
+			toks = appendTokensForTemplateControl(toks, func(toks Tokens) Tokens {
+				return append(toks, newIdentToken("endfor"))
+			})
+			continue
+		}
+
+		// Anything else is written as an interpolation sequence.
+		toks = append(toks, newToken(hclsyntax.TokenTemplateInterp, "${"))
+		toks = appendTokensForExpression(part, toks, exprPrecLowest, false)
+		toks = append(toks, newToken(hclsyntax.TokenTemplateSeqEnd, "}"))
+	}
+	return toks
+}
+
+func appendTokensForTemplateControl(toks Tokens, content func(Tokens) Tokens) Tokens {
+	toks = append(toks, newToken(hclsyntax.TokenTemplateControl, "%{"))
+	toks = content(toks)
+	return append(toks, newToken(hclsyntax.TokenTemplateSeqEnd, "}"))
+}
+
+func appendTokensForTemplateLiteral(s string, toks Tokens, heredoc bool) Tokens {
+	if s == "" {
+		return toks
+	}
+	if heredoc {
+		return append(toks, &Token{
+			Type:  hclsyntax.TokenStringLit,
+			Bytes: escapeTemplateSequences(s),
+		})
+	}
+	return append(toks, &Token{
+		Type:  hclsyntax.TokenQuotedLit,
+		Bytes: escapeQuotedStringLit(s),
+	})
+}
+
+// appendTokensForHeredoc appends the tokens for the given template, written
+// as a heredoc. The caller must first check that templateIsHeredoc returns
+// true for the template.
+func appendTokensForHeredoc(e *hclsyntax.TemplateExpr, toks Tokens) Tokens {
+	marker := heredocMarker(e)
+	toks = append(toks, &Token{
+		Type:  hclsyntax.TokenOHeredoc,
+		Bytes: []byte("<<" + marker + "\n"),
+	})
+	toks = appendTokensForTemplateParts(e.Parts, toks, true)
+	return append(toks, &Token{
+		Type:  hclsyntax.TokenCHeredoc,
+		Bytes: []byte(marker + "\n"),
+	})
+}
+
+// templateIsHeredoc returns true if the given template spans multiple lines
+// and can be written as a heredoc without changing its result, which requires
+// that it end with a newline and that it not contain any characters that
+// would need to be escaped.
+func templateIsHeredoc(e *hclsyntax.TemplateExpr) bool {
+	if len(e.Parts) == 0 {
+		return false
+	}
+	last, ok := templateLiteral(e.Parts[len(e.Parts)-1])
+	if !ok || !strings.HasSuffix(last, "\n") {
+		return false
+	}
+
+	// A template with only a single trailing newline is clearer as a quoted
+	// string, so we require at least one other newline.
+	newlines := 0
+	for _, part := range e.Parts {
+		if s, ok := templateLiteral(part); ok {
+			newlines += strings.Count(s, "\n")
+		}
+	}
+	if newlines < 2 {
+		return false
+	}
+
+	valid := true
+	hclsyntax.VisitAll(e, func(node hclsyntax.Node) hcl.Diagnostics {
+		s, ok := templateLiteral(node)
+		if !ok {
+			return nil
+		}
+		for _, r := range s {
+			if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+				valid = false
+			}
+		}
+		return nil
+	})
+	return valid
+}
+
+// heredocMarker chooses a marker for a heredoc that doesn't appear alone on
+// any line of the given template.
+func heredocMarker(e *hclsyntax.TemplateExpr) string {
+	lines := make(map[string]struct{})
+	hclsyntax.VisitAll(e, func(node hclsyntax.Node) hcl.Diagnostics {
+		if s, ok := templateLiteral(node); ok {
+			for _, line := range strings.Split(s, "\n") {
+				lines[strings.TrimSpace(line)] = struct{}{}
+			}
+		}
+		return nil
+	})
+	marker := "EOT"
+	for i := 2; ; i++ {
+		if _, exists := lines[marker]; !exists {
+			return marker
+		}
+		marker = fmt.Sprintf("EOT%d", i)
+	}
+}
+
+func templateIsEmpty(e *hclsyntax.TemplateExpr) bool {
+	for _, part := range e.Parts {
+		if s, ok := templateLiteral(part); !ok || s != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func templateLiteral(node hclsyntax.Node) (string, bool) {
+	lit, ok := node.(*hclsyntax.LiteralValueExpr)
+	if !ok || lit.Val.Type() != cty.String || !lit.Val.IsKnown() || lit.Val.IsNull() {
+		return "", false
+	}
+	return lit.Val.AsString(), true
+}
+
+// escapeTemplateSequences escapes the template sequence introducers in the
+// given literal string, for use in a heredoc.
+func escapeTemplateSequences(s string) []byte {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		buf = append(buf, s[i])
+		if (s[i] == '$' || s[i] == '%') && i+1 < len(s) && s[i+1] == '{' {
+			// Double up our template introducer symbol to escape it.
+			buf = append(buf, s[i])
+		}
+	}
+	return buf
+}
+
+func newToken(ty hclsyntax.TokenType, src string) *Token {
+	return &Token{
+		Type:  ty,
+		Bytes: []byte(src),
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hclwrite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestTokensForExpression(t *testing.T) {
+	tests := map[string]struct {
+		Src  string
+		Want string
+	}{
+		"literal": {
+			`1.5`,
+			`1.5`,
+		},
+		"traversal": {
+			`a . b [0]`,
+			`a.b[0]`,
+		},
+		"relative traversal": {
+			`foo()[0] .bar`,
+			`foo()[0].bar`,
+		},
+		"index": {
+			`a[b+1]`,
+			`a[b + 1]`,
+		},
+		"function call": {
+			`upper( "a" ,b )`,
+			`upper("a", b)`,
+		},
+		"function call with expansion": {
+			`max(1, xs...)`,
+			`max(1, xs...)`,
+		},
+		"namespaced function call": {
+			`provider::foo::bar(1)`,
+			`provider::foo::bar(1)`,
+		},
+		"tuple": {
+			`[1,2 , 3]`,
+			`[1, 2, 3]`,
+		},
+		"empty object": {
+			`{}`,
+			`{}`,
+		},
+		"object": {
+			"{a=1, \"b c\" = 2, (d) = 3, upper(e) = 4}",
+			"{\n  a        = 1\n  \"b c\"    = 2\n  (d)      = 3\n  upper(e) = 4\n}",
+		},
+		"binary operators": {
+			`a+b*c`,
+			`a + b * c`,
+		},
+		"parentheses": {
+			`(a+b)*c`,
+			`(a + b) * c`,
+		},
+		"unary operators": {
+			`!a && -b < 0`,
+			`!a && -b < 0`,
+		},
+		"conditional": {
+			`a ? b : c ? d : e`,
+			`a ? b : c ? d : e`,
+		},
+		"tuple for": {
+			`[for i, v in xs: v if i > 0]`,
+			`[for i, v in xs : v if i > 0]`,
+		},
+		"object for": {
+			`{for k, v in m: v => k...}`,
+			`{ for k, v in m : v => k... }`,
+		},
+		"splat": {
+			`a[*].b`,
+			`a[*].b`,
+		},
+		"legacy splat": {
+			`a.*.b`,
+			`a[*].b`,
+		},
+		"splat of splat": {
+			`(a[*].b)[*].c`,
+			`(a[*].b)[*].c`,
+		},
+		"template": {
+			`"Hello, ${name}!"`,
+			`"Hello, ${name}!"`,
+		},
+		"template with escapes": {
+			`"a\tb$${c}%%{d}\""`,
+			`"a\tb$${c}%%{d}\""`,
+		},
+		"template wrap": {
+			`"${a}"`,
+			`"${a}"`,
+		},
+		"template if": {
+			`"%{if a}b%{endif}"`,
+			`"%{if a}b%{endif}"`,
+		},
+		"template if else": {
+			`"%{ if a }b%{ else }c%{ endif }"`,
+			`"%{if a}b%{else}c%{endif}"`,
+		},
+		"template for": {
+			`"%{for i, v in xs}${i}=${v} %{endfor}"`,
+			`"%{for i, v in xs}${i}=${v} %{endfor}"`,
+		},
+		"heredoc": {
+			"<<EOT\nHello,\n${name}!\nEOT\n",
+			"<<EOT\nHello,\n${name}!\nEOT\n",
+		},
+		"heredoc with marker in content": {
+			"<<EOF\nEOT\n$${a}\nEOF\n",
+			"<<EOT2\nEOT\n$${a}\nEOT2\n",
+		},
+		"indented heredoc": {
+			"<<-EOT\n  a\n  b\n  EOT\n",
+			"<<EOT\na\nb\nEOT\n",
+		},
+		"heredoc in tuple": {
+			"[<<EOT\na\nb\nEOT\n]",
+			`["a\nb\n"]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(test.Src), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics: %s", diags.Error())
+			}
+
+			got := string(TokensForExpression(expr).Bytes())
+			if got != test.Want {
+				t.Fatalf("wrong result\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+			if formatted := string(Format([]byte(got))); formatted != got {
+				t.Errorf("result is not canonical\ngot:\n%s\nformatted:\n%s", got, formatted)
+			}
+
+			// The result must parse to an equivalent expression, which we
+			// check by rendering it again.
+			reparsed, diags := hclsyntax.ParseExpression([]byte(got), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("result does not parse: %s", diags.Error())
+			}
+			if again := string(TokensForExpression(reparsed).Bytes()); again != got {
+				t.Errorf("result does not round-trip\ngot:\n%s\nthen:\n%s", got, again)
+			}
+		})
+	}
+}
+
+func TestTokensForExpressionConstructed(t *testing.T) {
+	a := &hclsyntax.ScopeTraversalExpr{Traversal: hcl.Traversal{hcl.TraverseRoot{Name: "a"}}}
+	b := &hclsyntax.ScopeTraversalExpr{Traversal: hcl.Traversal{hcl.TraverseRoot{Name: "b"}}}
+	c := &hclsyntax.ScopeTraversalExpr{Traversal: hcl.Traversal{hcl.TraverseRoot{Name: "c"}}}
+	binary := func(lhs hclsyntax.Expression, op *hclsyntax.Operation, rhs hclsyntax.Expression) hclsyntax.Expression {
+		return &hclsyntax.BinaryOpExpr{LHS: lhs, Op: op, RHS: rhs}
+	}
+	lit := func(s string) hclsyntax.Expression {
+		return &hclsyntax.LiteralValueExpr{Val: cty.StringVal(s)}
+	}
+
+	tests := map[string]struct {
+		Expr hclsyntax.Expression
+		Want string
+		Vars map[string]cty.Value
+	}{
+		"lower precedence on left": {
+			binary(binary(a, hclsyntax.OpAdd, b), hclsyntax.OpMultiply, c),
+			`(a + b) * c`,
+			map[string]cty.Value{"a": cty.NumberIntVal(1), "b": cty.NumberIntVal(2), "c": cty.NumberIntVal(3)},
+		},
+		"same precedence on left": {
+			binary(binary(a, hclsyntax.OpSubtract, b), hclsyntax.OpSubtract, c),
+			`a - b - c`,
+			map[string]cty.Value{"a": cty.NumberIntVal(1), "b": cty.NumberIntVal(2), "c": cty.NumberIntVal(3)},
+		},
+		"same precedence on right": {
+			binary(a, hclsyntax.OpSubtract, binary(b, hclsyntax.OpSubtract, c)),
+			`a - (b - c)`,
+			map[string]cty.Value{"a": cty.NumberIntVal(1), "b": cty.NumberIntVal(2), "c": cty.NumberIntVal(3)},
+		},
+		"conditional as operand": {
+			binary(&hclsyntax.ConditionalExpr{Condition: a, TrueResult: b, FalseResult: c}, hclsyntax.OpAdd, c),
+			`(a ? b : c) + c`,
+			map[string]cty.Value{"a": cty.True, "b": cty.NumberIntVal(2), "c": cty.NumberIntVal(3)},
+		},
+		"conditional as condition": {
+			&hclsyntax.ConditionalExpr{
+				Condition:   &hclsyntax.ConditionalExpr{Condition: a, TrueResult: b, FalseResult: c},
+				TrueResult:  lit("yes"),
+				FalseResult: lit("no"),
+			},
+			`(a ? b : c) ? "yes" : "no"`,
+			map[string]cty.Value{"a": cty.True, "b": cty.False, "c": cty.True},
+		},
+		"negated sum": {
+			&hclsyntax.UnaryOpExpr{Op: hclsyntax.OpNegate, Val: binary(a, hclsyntax.OpAdd, b)},
+			`-(a + b)`,
+			map[string]cty.Value{"a": cty.NumberIntVal(1), "b": cty.NumberIntVal(2)},
+		},
+		"index of conditional": {
+			&hclsyntax.IndexExpr{
+				Collection: &hclsyntax.ConditionalExpr{Condition: a, TrueResult: b, FalseResult: c},
+				Key:        &hclsyntax.LiteralValueExpr{Val: cty.Zero},
+			},
+			`(a ? b : c)[0]`,
+			map[string]cty.Value{"a": cty.False, "b": cty.ListVal([]cty.Value{cty.Zero}), "c": cty.ListVal([]cty.Value{cty.NumberIntVal(1)})},
+		},
+		"bare key": {
+			&hclsyntax.ObjectConsExpr{
+				Items: []hclsyntax.ObjectConsItem{
+					{KeyExpr: a, ValueExpr: b},
+				},
+			},
+			"{\n  (a) = b\n}",
+			map[string]cty.Value{"a": cty.StringVal("x"), "b": cty.True},
+		},
+		"literal in template": {
+			&hclsyntax.TemplateExpr{
+				Parts: []hclsyntax.Expression{
+					lit("a "),
+					&hclsyntax.LiteralValueExpr{Val: cty.NumberIntVal(1)},
+				},
+			},
+			`"a ${1}"`,
+			nil,
+		},
+		"join without directive form": {
+			&hclsyntax.TemplateJoinExpr{Tuple: a},
+			`"%{for v in a}${v}%{endfor}"`,
+			map[string]cty.Value{"a": cty.TupleVal([]cty.Value{cty.StringVal("x"), cty.StringVal("y")})},
+		},
+		"empty template": {
+			&hclsyntax.TemplateExpr{},
+			`""`,
+			nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := string(TokensForExpression(test.Expr).Bytes())
+			if got != test.Want {
+				t.Fatalf("wrong result\ngot:\n%s\nwant:\n%s", got, test.Want)
+			}
+			if formatted := string(Format([]byte(got))); formatted != got {
+				t.Errorf("result is not canonical\ngot:\n%s\nformatted:\n%s", got, formatted)
+			}
+
+			reparsed, diags := hclsyntax.ParseExpression([]byte(got), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("result does not parse: %s", diags.Error())
+			}
+			ctx := &hcl.EvalContext{Variables: test.Vars}
+			want, diags := test.Expr.Value(ctx)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics evaluating original: %s", diags.Error())
+			}
+			gotVal, diags := reparsed.Value(ctx)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics evaluating result: %s", diags.Error())
+			}
+			if !gotVal.RawEquals(want) {
+				t.Errorf("wrong value\ngot:  %#v\nwant: %#v", gotVal, want)
+			}
+		})
+	}
+}
+
+func TestTokensForExpressionGenerateConsistency(t *testing.T) {
+	// Rendering an expression that was parsed from the output of the other
+	// token generation functions must produce the same result.
+	want := TokensForFunctionCall(
+		"foo",
+		TokensForValue(cty.StringVal("a\nb")),
+		TokensForTraversal(hcl.Traversal{hcl.TraverseRoot{Name: "x"}, hcl.TraverseAttr{Name: "y"}}),
+		TokensForTuple([]Tokens{TokensForValue(cty.NumberIntVal(1))}),
+	).Bytes()
+	expr, diags := hclsyntax.ParseExpression(want, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	got := TokensForExpression(expr).Bytes()
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestTokensForExpressionHeredocAttribute(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte("\"a\\nb\\n\""), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	f := NewEmptyFile()
+	f.Body().SetAttributeRaw("x", TokensForExpression(expr))
+	f.Body().SetAttributeValue("y", cty.True)
+
+	got := string(f.Bytes())
+	want := "x = <<EOT\na\nb\nEOT\ny = true\n"
+	if got != want {
+		t.Errorf("wrong result\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if _, diags := hclsyntax.ParseConfig([]byte(got), "", hcl.InitialPos); diags.HasErrors() {
+		t.Errorf("result is not valid: %s", diags.Error())
+	}
+}
@@ -154,7 +154,11 @@ func (ns *nodes) InsertNode(pos *node, n *node) {
 		ns.last = n
 	} else {
 		// inserts n before pos.
-		pos.before.after = n
+		if pos.before != nil {
+			pos.before.after = n
+		} else {
+			ns.first = n
+		}
 		n.before = pos.before
 		pos.before = n
 		n.after = pos
@@ -337,11 +337,13 @@ func parseBlock(nativeBlock *hclsyntax.Block, from, leadComments, lineComments,
 
 	// stragglers
 	children.AppendUnstructuredTokens(from.Tokens())
-	if lineComments.Len() > 0 {
-		// blocks don't actually have line comments, so we'll just treat
-		// them as extra stragglers
-		children.AppendUnstructuredTokens(lineComments.Tokens())
+
+	{
+		cn := newNode(newComments(lineComments.Tokens()))
+		block.lineComments = cn
+		children.AppendNode(cn)
 	}
+
 	children.AppendUnstructuredTokens(newline.Tokens())
 
 	return newNode(block)
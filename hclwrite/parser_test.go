@@ -281,6 +281,9 @@ func TestParse(t *testing.T) {
 								Type: "Tokens",
 								Val:  "}",
 							},
+							{
+								Type: "comments",
+							},
 							{
 								Type: "Tokens",
 								Val:  "\n",
@@ -325,6 +328,9 @@ func TestParse(t *testing.T) {
 								Type: "Tokens",
 								Val:  "}",
 							},
+							{
+								Type: "comments",
+							},
 							{
 								Type: "Tokens",
 								Val:  "\n",
@@ -369,6 +375,9 @@ func TestParse(t *testing.T) {
 								Type: "Tokens",
 								Val:  "}",
 							},
+							{
+								Type: "comments",
+							},
 							{
 								Type: "Tokens",
 								Val:  "\n",
@@ -427,6 +436,9 @@ func TestParse(t *testing.T) {
 								Type: "Tokens",
 								Val:  "}",
 							},
+							{
+								Type: "comments",
+							},
 							{
 								Type: "Tokens",
 								Val:  "\n",
@@ -503,6 +515,9 @@ func TestParse(t *testing.T) {
 								Type: "Tokens",
 								Val:  "}",
 							},
+							{
+								Type: "comments",
+							},
 							{
 								Type: "Tokens",
 								Val:  "\n",
@@ -46,3 +46,86 @@ func Format(src []byte) []byte {
 	tokens.WriteTo(buf)
 	return buf.Bytes()
 }
+
+// FormatOptions customizes the layout style produced by FormatWithOptions.
+// The zero value selects the same canonical style that Format produces.
+type FormatOptions struct {
+	// IndentWidth is the number of spaces to indent each nesting level by.
+	// Zero selects the default of two spaces. If UseTabs is set then
+	// IndentWidth is instead the number of columns that each tab occupies
+	// when measuring lines against MaxWidth, with zero selecting four.
+	IndentWidth int
+
+	// UseTabs indents each nesting level with a single tab character rather
+	// than with spaces. Vertical alignment within lines still uses spaces.
+	UseTabs bool
+
+	// NoAlignAssignments and NoAlignComments disable the vertical alignment
+	// of the equals signs of attributes and of line comments, respectively,
+	// on consecutive lines, leaving a single space before each instead.
+	NoAlignAssignments bool
+	NoAlignComments    bool
+
+	// CollapseBlankLines replaces each run of consecutive blank lines with a
+	// single blank line.
+	CollapseBlankLines bool
+
+	// TrailingCommas decides whether multi-line tuple constructors, whose
+	// closing bracket is on a line of its own, have a comma after their
+	// last element.
+	TrailingCommas TrailingCommas
+
+	// MaxWidth, if greater than zero, is the number of columns that lines
+	// should fit within. Longer lines are broken by placing the items of
+	// object and tuple constructors and the arguments of function calls on
+	// lines of their own, and by breaking conditional expressions before
+	// their "?" and ":" symbols, starting with the outermost construct on
+	// each line. Lines that can't be broken in this way are left long.
+	MaxWidth int
+}
+
+// TrailingCommas is the type of FormatOptions.TrailingCommas.
+type TrailingCommas int
+
+const (
+	// TrailingCommasPreserve leaves any trailing commas as they are.
+	TrailingCommasPreserve TrailingCommas = iota
+
+	// TrailingCommasAlways adds a comma after the last element wherever one
+	// is missing.
+	TrailingCommasAlways
+
+	// TrailingCommasNever removes any comma after the last element.
+	TrailingCommasNever
+)
+
+// FormatWithOptions is like Format but produces the layout style described
+// by the given options.
+//
+// Unlike Format, FormatWithOptions may add or remove newline, comma and
+// parenthesis tokens as well as changing whitespace, if the options call for
+// that. Formatting its result again with the same options produces no
+// further changes.
+func FormatWithOptions(src []byte, opts FormatOptions) []byte {
+	tokens := lexConfig(src)
+	if opts.CollapseBlankLines {
+		tokens = collapseBlankLines(tokens)
+	}
+	if opts.TrailingCommas != TrailingCommasPreserve {
+		tokens = formatTrailingCommas(tokens, opts.TrailingCommas == TrailingCommasAlways)
+	}
+	if opts.MaxWidth > 0 {
+		tokens = wrapLongLines(tokens, opts)
+	} else {
+		formatWithOptions(tokens, opts)
+	}
+	buf := &bytes.Buffer{}
+	if opts.UseTabs {
+		//nolint:errcheck // FIXME: Propogate errors upward.
+		writeTabIndented(buf, tokens)
+	} else {
+		//nolint:errcheck // FIXME: Propogate errors upward.
+		tokens.WriteTo(buf)
+	}
+	return buf.Bytes()
+}
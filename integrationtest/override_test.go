@@ -0,0 +1,143 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package integrationtest
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TestOverrideFiles is an integration test of hcl.OverrideFiles combining
+// a native syntax file with overrides from both native syntax and JSON,
+// checking that nested blocks are merged deeply and that the resulting
+// attributes retain the source ranges of the definitions that took effect.
+func TestOverrideFiles(t *testing.T) {
+	parser := hclparse.NewParser()
+	base, diags := parser.ParseHCL([]byte(`
+region = "us-east-1"
+
+service "web" {
+  image    = "web:1"
+  replicas = 2
+
+  port {
+    number = 80
+  }
+}
+
+service "worker" {
+  image = "worker:1"
+}
+`), "main.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	nativeOverride, diags := parser.ParseHCL([]byte(`
+service "web" {
+  image = "web:2"
+}
+`), "main_override.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	jsonOverride, diags := parser.ParseJSON([]byte(`{
+  "region": "eu-west-1",
+  "service": {
+    "web": {
+      "port": {
+        "number": 8080
+      }
+    },
+    "batch": {
+      "image": "batch:1"
+    }
+  }
+}`), "override.json")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	body := hcl.OverrideFiles([]*hcl.File{base, nativeOverride, jsonOverride})
+
+	content, diags := body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "region", Required: true},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "service", LabelNames: []string{"name"}},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	region := content.Attributes["region"]
+	if got, want := region.Range.Filename, "override.json"; got != want {
+		t.Errorf("wrong filename for region %q; want %q", got, want)
+	}
+	if got, _ := region.Expr.Value(nil); !got.RawEquals(cty.StringVal("eu-west-1")) {
+		t.Errorf("wrong region %#v", got)
+	}
+
+	var names []string
+	for _, block := range content.Blocks {
+		names = append(names, block.Labels[0])
+	}
+	if got, want := len(names), 3; got != want {
+		t.Fatalf("wrong number of services %d (%v); want %d", got, names, want)
+	}
+	if names[0] != "web" || names[1] != "worker" || names[2] != "batch" {
+		t.Errorf("wrong services %v", names)
+	}
+
+	web := content.Blocks[0]
+	if got, want := web.DefRange.Filename, "main.hcl"; got != want {
+		t.Errorf("wrong filename for web block %q; want %q", got, want)
+	}
+	webContent, diags := web.Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "image"},
+			{Name: "replicas"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "port"},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	image := webContent.Attributes["image"]
+	if got, want := image.Range.Filename, "main_override.hcl"; got != want {
+		t.Errorf("wrong filename for image %q; want %q", got, want)
+	}
+	if got, want := image.Range.Start.Line, 3; got != want {
+		t.Errorf("wrong line for image %d; want %d", got, want)
+	}
+	if got, _ := image.Expr.Value(nil); !got.RawEquals(cty.StringVal("web:2")) {
+		t.Errorf("wrong image %#v", got)
+	}
+	replicas := webContent.Attributes["replicas"]
+	if got, want := replicas.Range.Filename, "main.hcl"; got != want {
+		t.Errorf("wrong filename for replicas %q; want %q", got, want)
+	}
+
+	if got, want := len(webContent.Blocks), 1; got != want {
+		t.Fatalf("wrong number of port blocks %d; want %d", got, want)
+	}
+	portAttrs, diags := webContent.Blocks[0].Body.JustAttributes()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	number := portAttrs["number"]
+	if got, _ := number.Expr.Value(nil); !got.RawEquals(cty.NumberIntVal(8080)) {
+		t.Errorf("wrong port number %#v", got)
+	}
+	if got, want := number.Range.Filename, "override.json"; got != want {
+		t.Errorf("wrong filename for port number %q; want %q", got, want)
+	}
+}
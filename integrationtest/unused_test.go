@@ -0,0 +1,162 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package integrationtest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/dynblock"
+	"github.com/hashicorp/hcl/v2/ext/transform"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// TestUsageTrackingBody is an integration test of hcl.UsageTrackingBody
+// that checks that usage is tracked through merged bodies, dynamic block
+// expansion and deep transforms, for both native syntax and JSON.
+func TestUsageTrackingBody(t *testing.T) {
+	parser := hclparse.NewParser()
+	nativeFile, diags := parser.ParseHCL([]byte(`
+name       = "example"
+unused_top = 1
+
+service "web" {
+  image     = "web:1"
+  typo_attr = 2
+
+  dynamic "port" {
+    for_each = [80, 443]
+    content {
+      number = port.value
+      extra  = true
+    }
+  }
+}
+
+ignored_block {
+}
+`), "main.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	jsonFile, diags := parser.ParseJSON([]byte(`{
+  "region": "us-east-1",
+  "stray": 1
+}`), "extra.json")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	nativeBody := hcl.NewUsageTrackingBody(nativeFile.Body)
+	jsonBody := hcl.NewUsageTrackingBody(jsonFile.Body)
+
+	body := hcl.MergeBodies([]hcl.Body{nativeBody, jsonBody})
+	body = dynblock.Expand(body, nil)
+	body = transform.Deep(body, transform.TransformerFunc(func(body hcl.Body) hcl.Body {
+		return body
+	}))
+
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "name"},
+			{Name: "region"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "service", LabelNames: []string{"name"}},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	if got, want := len(content.Blocks), 1; got != want {
+		t.Fatalf("wrong number of service blocks %d; want %d", got, want)
+	}
+
+	serviceContent, _, diags := content.Blocks[0].Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "image"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "port"},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	if got, want := len(serviceContent.Blocks), 2; got != want {
+		t.Fatalf("wrong number of port blocks %d; want %d", got, want)
+	}
+	for _, block := range serviceContent.Blocks {
+		_, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{
+				{Name: "number"},
+			},
+		})
+		if diags.HasErrors() {
+			t.Fatalf("unexpected problems: %s", diags.Error())
+		}
+	}
+
+	var got []string
+	for _, item := range nativeBody.UnusedItems() {
+		got = append(got, item.Range.String()+" "+item.Name)
+	}
+	for _, item := range jsonBody.UnusedItems() {
+		got = append(got, item.Range.String()+" "+item.Name)
+	}
+	want := []string{
+		"main.hcl:3,1-11 unused_top",
+		"main.hcl:7,3-12 typo_attr",
+		"main.hcl:13,7-12 extra",
+		"main.hcl:18,1-14 ignored_block",
+		"extra.json:3,3-10 stray",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong unused items\n%s", diff)
+	}
+}
+
+func TestUsageTrackingBodyMerged(t *testing.T) {
+	parser := hclparse.NewParser()
+	a, diags := parser.ParseHCL([]byte("a = 1\nb = 2\n"), "a.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	b, diags := parser.ParseJSON([]byte(`{"c": 3, "d": 4}`), "b.json")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	// Tracking can also wrap a merged body, rather than the individual files.
+	body := hcl.NewUsageTrackingBody(hcl.MergeFiles([]*hcl.File{a, b}))
+	_, remain, diags := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "a"}},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	_, _, diags = remain.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "d"}},
+	})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+
+	var got []string
+	for _, item := range body.UnusedItems() {
+		got = append(got, item.Name)
+	}
+	want := []string{"b", "c"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong unused items\n%s", diff)
+	}
+
+	if _, diags := body.JustAttributes(); diags.HasErrors() {
+		t.Fatalf("unexpected problems: %s", diags.Error())
+	}
+	if got := body.UnusedItems(); len(got) != 0 {
+		t.Errorf("unexpected unused items after JustAttributes: %#v", got)
+	}
+}
@@ -6,6 +6,8 @@ package json
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hashicorp/hcl/v2"
 )
 
 type navigation struct {
@@ -71,3 +73,101 @@ func navigationStepsRev(v node, offset int) []string {
 
 	return nil
 }
+
+// Implementation of hcled.Symbols
+//
+// The structure of a JSON file depends on the schema used to decode it, so
+// we treat each property whose value is an object as a block containing the
+// nested properties, and all other properties as attributes.
+func (n navigation) WalkSymbols(cb func(depth int, block bool, name string, labels []string, rng, nameRange hcl.Range)) {
+	walkObjectSymbols(n.root, 0, cb)
+}
+
+func walkObjectSymbols(v node, depth int, cb func(depth int, block bool, name string, labels []string, rng, nameRange hcl.Range)) {
+	obj, ok := v.(*objectVal)
+	if !ok {
+		return
+	}
+	for _, attr := range obj.Attrs {
+		rng := hcl.RangeBetween(attr.NameRange, attr.Value.Range())
+		if _, isObj := attr.Value.(*objectVal); isObj {
+			cb(depth, true, attr.Name, nil, rng, attr.NameRange)
+			walkObjectSymbols(attr.Value, depth+1, cb)
+			continue
+		}
+		cb(depth, false, attr.Name, nil, rng, attr.NameRange)
+	}
+}
+
+// Implementation of hcled.FoldingRanges
+func (n navigation) FoldingRanges() []hcl.Range {
+	var ret []hcl.Range
+	var visit func(v node)
+	visit = func(v node) {
+		switch tv := v.(type) {
+		case *objectVal:
+			if tv.SrcRange.End.Line > tv.SrcRange.Start.Line {
+				ret = append(ret, tv.SrcRange)
+			}
+			for _, attr := range tv.Attrs {
+				visit(attr.Value)
+			}
+		case *arrayVal:
+			if tv.SrcRange.End.Line > tv.SrcRange.Start.Line {
+				ret = append(ret, tv.SrcRange)
+			}
+			for _, elem := range tv.Values {
+				visit(elem)
+			}
+		}
+	}
+	visit(n.root)
+	return ret
+}
+
+// Implementation of hcled.SelectionRanges
+func (n navigation) SelectionRanges(offset int) []hcl.Range {
+	var ret []hcl.Range
+	v := n.root
+	for v != nil && containsOffsetInclusive(v.Range(), offset) {
+		ret = append(ret, v.Range())
+
+		var next node
+		switch tv := v.(type) {
+		case *objectVal:
+			for _, attr := range tv.Attrs {
+				rng := hcl.RangeBetween(attr.NameRange, attr.Value.Range())
+				if !containsOffsetInclusive(rng, offset) {
+					continue
+				}
+				ret = append(ret, rng)
+				if offset <= attr.NameRange.End.Byte {
+					ret = append(ret, attr.NameRange)
+				} else {
+					next = attr.Value
+				}
+				break
+			}
+		case *arrayVal:
+			for _, elem := range tv.Values {
+				if containsOffsetInclusive(elem.Range(), offset) {
+					next = elem
+					break
+				}
+			}
+		}
+		v = next
+	}
+
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret
+}
+
+// containsOffsetInclusive is like hcl.Range.ContainsOffset except that it
+// also accepts an offset at the end of the range, which is where a cursor
+// is placed after typing the last character of a value.
+func containsOffsetInclusive(rng hcl.Range, offset int) bool {
+	return offset >= rng.Start.Byte && offset <= rng.End.Byte
+}
@@ -219,6 +219,32 @@ func (b *body) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
 	return attrs, diags
 }
 
+// RemainingItems returns the properties of the JSON object that have not been
+// consumed by an earlier call to PartialContent.
+//
+// This is the implementation of hcl.RemainingItems for JSON bodies. Without
+// a schema there is no way to distinguish between attributes and blocks, so
+// the range of each item is the range of its property name.
+func (b *body) RemainingItems() []hcl.BodyItem {
+	jsonAttrs, _ := b.collectDeepAttrs(b.val, nil)
+
+	var items []hcl.BodyItem
+	for _, attr := range jsonAttrs {
+		if attr.Name == "//" {
+			// Ignore "//" keys, as in Content.
+			continue
+		}
+		if _, hidden := b.hiddenAttrs[attr.Name]; hidden {
+			continue
+		}
+		items = append(items, hcl.BodyItem{
+			Name:  attr.Name,
+			Range: attr.NameRange,
+		})
+	}
+	return items
+}
+
 func (b *body) MissingItemRange() hcl.Range {
 	switch tv := b.val.(type) {
 	case *objectVal:
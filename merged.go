@@ -5,6 +5,7 @@ package hcl
 
 import (
 	"fmt"
+	"strings"
 )
 
 // MergeFiles combines the given files to produce a single body that contains
@@ -140,6 +141,16 @@ func (mb mergedBodies) MissingItemRange() Range {
 	return mb[0].MissingItemRange()
 }
 
+// RemainingItems implements RemainingItems by combining the remaining items
+// of all of the merged bodies.
+func (mb mergedBodies) RemainingItems() []BodyItem {
+	var items []BodyItem
+	for _, body := range mb {
+		items = append(items, RemainingItems(body)...)
+	}
+	return items
+}
+
 func (mb mergedBodies) mergedContent(schema *BodySchema, partial bool) (*BodyContent, Body, Diagnostics) {
 	// We need to produce a new schema with none of the attributes marked as
 	// required, since _any one_ of our bodies can contribute an attribute value.
@@ -225,3 +236,195 @@ func (mb mergedBodies) mergedContent(schema *BodySchema, partial bool) (*BodyCon
 	leftoverBody := MergeBodies(mergedLeftovers)
 	return content, leftoverBody, diags
 }
+
+// OverrideFiles is like MergeFiles except that configuration in later files
+// overrides configuration in earlier files, rather than conflicting with it.
+// See OverrideBodies for details.
+func OverrideFiles(files []*File) Body {
+	var bodies []Body
+	for _, file := range files {
+		bodies = append(bodies, file.Body)
+	}
+	return OverrideBodies(bodies)
+}
+
+// OverrideBodies combines the given bodies into a single body where content
+// in later bodies overrides content in earlier bodies, as is commonly used
+// to implement "override files" that adjust the configuration in other
+// files without modifying them.
+//
+// The rules for combining the bodies are as follows:
+//
+//   - An attribute in a later body replaces any attribute of the same name
+//     from earlier bodies.
+//   - A block in a later body whose type and labels match a block from an
+//     earlier body is merged into that block, by recursively applying these
+//     same rules to the bodies of the two blocks. The merged block retains
+//     the type and label ranges of the earlier block.
+//   - If a body has several blocks with the same type and labels, as is
+//     common for nested blocks without labels, they are matched with the
+//     earlier blocks by position: the second such block in the later body
+//     is merged into the second such block from the earlier bodies.
+//   - Any block that doesn't match an earlier block is appended after the
+//     blocks from earlier bodies.
+//
+// Attributes and blocks retain the source ranges of the body they came from,
+// so diagnostics about them refer to the definition that actually took
+// effect. Because OverrideBodies works only with the Body interface, it can
+// combine bodies from any syntax, including mixing native syntax and JSON.
+//
+// As with MergeBodies, required attributes are satisfied if they are present
+// in any of the bodies, but there is no contextual information with which to
+// return good diagnostics when they are missing.
+func OverrideBodies(bodies []Body) Body {
+	if len(bodies) == 0 {
+		return emptyBody
+	}
+	if len(bodies) == 1 {
+		return bodies[0]
+	}
+
+	// As with MergeBodies, we flatten nested override bodies, which is
+	// possible because applying overrides is associative.
+	var flattened []Body
+	for _, body := range bodies {
+		if children, isOverride := body.(overrideBodies); isOverride {
+			flattened = append(flattened, children...)
+		} else {
+			flattened = append(flattened, body)
+		}
+	}
+	return overrideBodies(flattened)
+}
+
+type overrideBodies []Body
+
+func (ob overrideBodies) Content(schema *BodySchema) (*BodyContent, Diagnostics) {
+	content, _, diags := ob.overrideContent(schema, false)
+	return content, diags
+}
+
+func (ob overrideBodies) PartialContent(schema *BodySchema) (*BodyContent, Body, Diagnostics) {
+	return ob.overrideContent(schema, true)
+}
+
+func (ob overrideBodies) JustAttributes() (Attributes, Diagnostics) {
+	attrs := make(map[string]*Attribute)
+	var diags Diagnostics
+
+	for _, body := range ob {
+		thisAttrs, thisDiags := body.JustAttributes()
+		diags = append(diags, thisDiags...)
+
+		for name, attr := range thisAttrs {
+			attrs[name] = attr
+		}
+	}
+
+	return attrs, diags
+}
+
+func (ob overrideBodies) MissingItemRange() Range {
+	// we use the first body's missing item range, because the first body is
+	// the one that all of the others are overriding.
+	return ob[0].MissingItemRange()
+}
+
+// RemainingItems implements RemainingItems by combining the remaining items
+// of all of the bodies.
+func (ob overrideBodies) RemainingItems() []BodyItem {
+	return mergedBodies(ob).RemainingItems()
+}
+
+func (ob overrideBodies) overrideContent(schema *BodySchema, partial bool) (*BodyContent, Body, Diagnostics) {
+	// As in mergedBodies, we need to produce a new schema with none of the
+	// attributes marked as required, and check for required attributes
+	// ourselves at the end.
+	overrideSchema := &BodySchema{
+		Blocks: schema.Blocks,
+	}
+	for _, attrS := range schema.Attributes {
+		overrideAttrS := attrS
+		overrideAttrS.Required = false
+		overrideSchema.Attributes = append(overrideSchema.Attributes, overrideAttrS)
+	}
+
+	var leftovers []Body
+	content := &BodyContent{
+		Attributes: map[string]*Attribute{},
+	}
+	var diags Diagnostics
+
+	// blockIndex tracks the indices in content.Blocks of the blocks of each
+	// distinct type and set of labels, in the order they were defined.
+	blockIndex := make(map[string][]int)
+
+	for _, body := range ob {
+		var thisContent *BodyContent
+		var thisLeftovers Body
+		var thisDiags Diagnostics
+
+		if partial {
+			thisContent, thisLeftovers, thisDiags = body.PartialContent(overrideSchema)
+		} else {
+			thisContent, thisDiags = body.Content(overrideSchema)
+		}
+
+		if thisLeftovers != nil {
+			leftovers = append(leftovers, thisLeftovers)
+		}
+		diags = append(diags, thisDiags...)
+
+		for name, attr := range thisContent.Attributes {
+			content.Attributes[name] = attr
+		}
+
+		// Blocks are matched positionally within each distinct type and
+		// set of labels, so that e.g. the second "foo" block in this body
+		// is merged with the second "foo" block from the earlier bodies.
+		seen := make(map[string]int)
+		var newBlocks []*Block
+		for _, block := range thisContent.Blocks {
+			key := overrideBlockKey(block)
+			n := seen[key]
+			seen[key]++
+
+			if existing := blockIndex[key]; n < len(existing) {
+				i := existing[n]
+				merged := *content.Blocks[i]
+				merged.Body = OverrideBodies([]Body{merged.Body, block.Body})
+				content.Blocks[i] = &merged
+				continue
+			}
+			newBlocks = append(newBlocks, block)
+		}
+		for _, block := range newBlocks {
+			key := overrideBlockKey(block)
+			blockIndex[key] = append(blockIndex[key], len(content.Blocks))
+			content.Blocks = append(content.Blocks, block)
+		}
+	}
+
+	for _, attrS := range schema.Attributes {
+		if !attrS.Required {
+			continue
+		}
+
+		if content.Attributes[attrS.Name] == nil {
+			diags = diags.Append(&Diagnostic{
+				Severity: DiagError,
+				Summary:  "Missing required argument",
+				Detail: fmt.Sprintf(
+					"The argument %q is required, but was not set.",
+					attrS.Name,
+				),
+			})
+		}
+	}
+
+	return content, OverrideBodies(leftovers), diags
+}
+
+func overrideBlockKey(block *Block) string {
+	return strings.Join(append([]string{block.Type}, block.Labels...), "\x00")
+}
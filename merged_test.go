@@ -561,6 +561,136 @@ func TestMergeBodiesPartialContent(t *testing.T) {
 	}
 }
 
+func TestOverrideBodiesContent(t *testing.T) {
+	tests := []struct {
+		Bodies    []Body
+		Schema    *BodySchema
+		Want      *BodyContent
+		DiagCount int
+	}{
+		{
+			[]Body{},
+			&BodySchema{
+				Attributes: []AttributeSchema{
+					{
+						Name:     "name",
+						Required: true,
+					},
+				},
+			},
+			&BodyContent{
+				Attributes: map[string]*Attribute{},
+			},
+			1,
+		},
+		{
+			[]Body{
+				&testMergedBodiesVictim{
+					Name:          "first",
+					HasAttributes: []string{"name", "age"},
+				},
+				&testMergedBodiesVictim{
+					Name:          "second",
+					HasAttributes: []string{"name"},
+				},
+			},
+			&BodySchema{
+				Attributes: []AttributeSchema{
+					{
+						Name:     "name",
+						Required: true,
+					},
+					{
+						Name: "age",
+					},
+				},
+			},
+			&BodyContent{
+				Attributes: map[string]*Attribute{
+					"name": &Attribute{
+						Name:      "name",
+						NameRange: Range{Filename: "second"},
+					},
+					"age": &Attribute{
+						Name:      "age",
+						NameRange: Range{Filename: "first"},
+					},
+				},
+			},
+			0,
+		},
+		{
+			[]Body{
+				&testMergedBodiesVictim{
+					Name:      "first",
+					HasBlocks: map[string]int{"pizza": 1},
+				},
+				&testMergedBodiesVictim{
+					Name:      "second",
+					HasBlocks: map[string]int{"pizza": 2},
+				},
+			},
+			&BodySchema{
+				Blocks: []BlockHeaderSchema{
+					{
+						Type: "pizza",
+					},
+				},
+			},
+			&BodyContent{
+				Attributes: map[string]*Attribute{},
+				Blocks: Blocks{
+					{
+						Type:     "pizza",
+						DefRange: Range{Filename: "first"},
+						Body:     overrideBodies{nil, nil},
+					},
+					{
+						Type:     "pizza",
+						DefRange: Range{Filename: "second"},
+					},
+				},
+			},
+			0,
+		},
+		{
+			[]Body{
+				&testMergedBodiesVictim{
+					Name:      "first",
+					DiagCount: 1,
+				},
+				&testMergedBodiesVictim{
+					Name:      "second",
+					DiagCount: 1,
+				},
+			},
+			&BodySchema{},
+			&BodyContent{
+				Attributes: map[string]*Attribute{},
+			},
+			2,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			merged := OverrideBodies(test.Bodies)
+			got, diags := merged.Content(test.Schema)
+
+			if len(diags) != test.DiagCount {
+				t.Errorf("Wrong number of diagnostics %d; want %d", len(diags), test.DiagCount)
+				for _, diag := range diags {
+					t.Logf(" - %s", diag)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.Want) {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", spew.Sdump(got), spew.Sdump(test.Want))
+			}
+		})
+	}
+}
+
 type testMergedBodiesVictim struct {
 	Name          string
 	HasAttributes []string
@@ -0,0 +1,248 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"bufio"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/apparentlymart/go-textseg/v15/textseg"
+)
+
+// UTF16Pos represents a single position in a source file as a zero-based
+// line number and a zero-based offset within that line measured in UTF-16
+// code units.
+//
+// This is the position representation used by the Language Server Protocol
+// and by many text editors, which differs from Pos both in being zero-based
+// and in counting UTF-16 code units rather than grapheme clusters. For
+// example, an emoji outside of the Basic Multilingual Plane counts as one
+// column in a Pos but two characters in a UTF16Pos.
+type UTF16Pos struct {
+	Line      int
+	Character int
+}
+
+// UTF16Range represents a span of characters between two UTF16Pos values.
+// Start is inclusive and End is exclusive.
+type UTF16Range struct {
+	Start, End UTF16Pos
+}
+
+// LineIndex is a precomputed index of the line boundaries in a source
+// buffer, which allows conversions between byte offsets, Pos values and
+// UTF16Pos values.
+//
+// Finding the line for a position takes O(log n) time in the number of lines,
+// after which only the bytes of that single line are examined.
+//
+// A LineIndex is immutable once constructed and so is safe for concurrent use.
+type LineIndex struct {
+	filename string
+	src      []byte
+
+	// starts and ends are the byte offsets of the start and end of each
+	// line, with ends excluding the newline sequence.
+	starts []int
+	ends   []int
+}
+
+// NewLineIndex builds a LineIndex for the given source buffer, which is
+// typically the Bytes field of a File. Ranges returned by the index will
+// refer to the given filename.
+//
+// The index retains the given buffer, so the caller must not modify it while
+// the index is in use.
+func NewLineIndex(src []byte, filename string) *LineIndex {
+	idx := &LineIndex{
+		filename: filename,
+		src:      src,
+	}
+
+	sc := NewRangeScanner(src, filename, bufio.ScanLines)
+	for sc.Scan() {
+		rng := sc.Range()
+		idx.starts = append(idx.starts, rng.Start.Byte)
+		idx.ends = append(idx.ends, rng.End.Byte)
+	}
+
+	// The scanner doesn't produce a token for the empty line after a
+	// trailing newline, but editors consider that to be a line in its own
+	// right, and so do we.
+	if len(src) == 0 || src[len(src)-1] == '\n' {
+		idx.starts = append(idx.starts, len(src))
+		idx.ends = append(idx.ends, len(src))
+	}
+
+	return idx
+}
+
+// LineCount returns the number of lines in the indexed buffer.
+//
+// A buffer that ends with a newline is considered to have an additional
+// empty line after it.
+func (idx *LineIndex) LineCount() int {
+	return len(idx.starts)
+}
+
+// PosForOffset returns the Pos for the given byte offset into the buffer.
+//
+// Offsets outside of the buffer are clamped to its bounds. An offset in the
+// middle of a multi-byte character produces the position of that character.
+func (idx *LineIndex) PosForOffset(offset int) Pos {
+	offset = idx.clampOffset(offset)
+	line := idx.lineForOffset(offset)
+	return Pos{
+		Byte:   offset,
+		Line:   line + 1,
+		Column: idx.column(line, offset),
+	}
+}
+
+// RangeForOffsets returns the Range between the two given byte offsets,
+// using the same rules as PosForOffset.
+func (idx *LineIndex) RangeForOffsets(start, end int) Range {
+	return Range{
+		Filename: idx.filename,
+		Start:    idx.PosForOffset(start),
+		End:      idx.PosForOffset(end),
+	}
+}
+
+// UTF16PosForOffset returns the UTF16Pos for the given byte offset into the
+// buffer.
+//
+// Offsets outside of the buffer are clamped to its bounds. An offset in the
+// middle of a multi-byte character produces the position of that character.
+func (idx *LineIndex) UTF16PosForOffset(offset int) UTF16Pos {
+	offset = idx.clampOffset(offset)
+	line := idx.lineForOffset(offset)
+	end := min(offset, idx.ends[line])
+
+	chars := 0
+	b := idx.src[idx.starts[line]:end]
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(b) {
+			// A truncated sequence at the end of our slice means the offset
+			// points into the middle of a character, which we count as
+			// being at the start of that character.
+			break
+		}
+		chars += utf16Len(r)
+		b = b[size:]
+	}
+
+	return UTF16Pos{Line: line, Character: chars}
+}
+
+// OffsetForUTF16Pos returns the byte offset into the buffer corresponding to
+// the given UTF16Pos.
+//
+// Lines before the start of the buffer or after the end produce the offset
+// of the start or end of the buffer, respectively. Characters beyond the end
+// of a line produce the offset of the end of that line, and a character
+// offset in the middle of a UTF-16 surrogate pair produces the offset of the
+// start of the character the pair encodes.
+func (idx *LineIndex) OffsetForUTF16Pos(pos UTF16Pos) int {
+	switch {
+	case pos.Line < 0:
+		return 0
+	case pos.Line >= len(idx.starts):
+		return len(idx.src)
+	}
+
+	offset := idx.starts[pos.Line]
+	end := idx.ends[pos.Line]
+	chars := 0
+	for offset < end && chars < pos.Character {
+		r, size := utf8.DecodeRune(idx.src[offset:end])
+		chars += utf16Len(r)
+		if chars > pos.Character {
+			// The requested position is in the middle of a surrogate pair.
+			break
+		}
+		offset += size
+	}
+	return offset
+}
+
+// PosForUTF16Pos returns the Pos corresponding to the given UTF16Pos, using
+// the same rules as OffsetForUTF16Pos.
+func (idx *LineIndex) PosForUTF16Pos(pos UTF16Pos) Pos {
+	return idx.PosForOffset(idx.OffsetForUTF16Pos(pos))
+}
+
+// UTF16PosForPos returns the UTF16Pos corresponding to the given Pos.
+//
+// Only the Byte field of the given position is used, so the result is
+// correct even if Line and Column were not populated.
+func (idx *LineIndex) UTF16PosForPos(pos Pos) UTF16Pos {
+	return idx.UTF16PosForOffset(pos.Byte)
+}
+
+// UTF16RangeForRange returns the UTF16Range corresponding to the given Range,
+// using the same rules as UTF16PosForPos.
+func (idx *LineIndex) UTF16RangeForRange(rng Range) UTF16Range {
+	return UTF16Range{
+		Start: idx.UTF16PosForPos(rng.Start),
+		End:   idx.UTF16PosForPos(rng.End),
+	}
+}
+
+// RangeForUTF16Range returns the Range corresponding to the given
+// UTF16Range, using the same rules as OffsetForUTF16Pos.
+func (idx *LineIndex) RangeForUTF16Range(rng UTF16Range) Range {
+	return idx.RangeForOffsets(
+		idx.OffsetForUTF16Pos(rng.Start),
+		idx.OffsetForUTF16Pos(rng.End),
+	)
+}
+
+func (idx *LineIndex) clampOffset(offset int) int {
+	return min(max(offset, 0), len(idx.src))
+}
+
+// lineForOffset returns the zero-based index of the line containing the
+// given offset, which must already be within the bounds of the buffer.
+func (idx *LineIndex) lineForOffset(offset int) int {
+	i := sort.Search(len(idx.starts), func(i int) bool {
+		return idx.starts[i] > offset
+	})
+	return max(i-1, 0)
+}
+
+// column returns the one-based column number, in grapheme clusters, of
+// the given offset within the given zero-based line.
+func (idx *LineIndex) column(line, offset int) int {
+	start := idx.starts[line]
+	b := idx.src[start:idx.ends[line]]
+	col := 1
+	pos := start
+	for len(b) > 0 && pos < offset {
+		adv, gr, err := textseg.ScanGraphemeClusters(b, true)
+		if err != nil || adv == 0 {
+			break
+		}
+		if pos+len(gr) > offset {
+			// The offset is in the middle of this grapheme cluster.
+			break
+		}
+		col++
+		pos += adv
+		b = b[adv:]
+	}
+	return col
+}
+
+// utf16Len returns the number of UTF-16 code units needed to encode the
+// given rune. Invalid UTF-8 sequences decode as utf8.RuneError and so count
+// as a single code unit, as they would once replaced by an editor.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,133 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLineIndex(t *testing.T) {
+	// The second line contains a character outside of the Basic Multilingual
+	// Plane, which takes four bytes in UTF-8 and two code units in UTF-16,
+	// and a latin letter with a combining diacritic, which is two runes
+	// but only one grapheme cluster.
+	src := []byte("a = 1\r\nb = \"\U0001F600e\u0301x\"\n\nc = 2\n")
+	idx := NewLineIndex(src, "test.hcl")
+
+	if got, want := idx.LineCount(), 5; got != want {
+		t.Fatalf("wrong line count %d; want %d", got, want)
+	}
+
+	tests := []struct {
+		Offset int
+		Pos    Pos
+		UTF16  UTF16Pos
+	}{
+		{0, Pos{Byte: 0, Line: 1, Column: 1}, UTF16Pos{0, 0}},
+		{4, Pos{Byte: 4, Line: 1, Column: 5}, UTF16Pos{0, 4}},
+		{5, Pos{Byte: 5, Line: 1, Column: 6}, UTF16Pos{0, 5}},
+		{7, Pos{Byte: 7, Line: 2, Column: 1}, UTF16Pos{1, 0}},
+		{11, Pos{Byte: 11, Line: 2, Column: 5}, UTF16Pos{1, 4}},  // the opening quote
+		{12, Pos{Byte: 12, Line: 2, Column: 6}, UTF16Pos{1, 5}},  // the emoji
+		{16, Pos{Byte: 16, Line: 2, Column: 7}, UTF16Pos{1, 7}},  // the e
+		{19, Pos{Byte: 19, Line: 2, Column: 8}, UTF16Pos{1, 9}},  // the x
+		{20, Pos{Byte: 20, Line: 2, Column: 9}, UTF16Pos{1, 10}}, // the closing quote
+		{22, Pos{Byte: 22, Line: 3, Column: 1}, UTF16Pos{2, 0}},  // the empty line
+		{23, Pos{Byte: 23, Line: 4, Column: 1}, UTF16Pos{3, 0}},
+		{29, Pos{Byte: 29, Line: 5, Column: 1}, UTF16Pos{4, 0}}, // end of file
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("offset %d", test.Offset), func(t *testing.T) {
+			if got := idx.PosForOffset(test.Offset); got != test.Pos {
+				t.Errorf("wrong Pos\ngot:  %#v\nwant: %#v", got, test.Pos)
+			}
+			if got := idx.UTF16PosForOffset(test.Offset); got != test.UTF16 {
+				t.Errorf("wrong UTF16Pos\ngot:  %#v\nwant: %#v", got, test.UTF16)
+			}
+			if got := idx.OffsetForUTF16Pos(test.UTF16); got != test.Offset {
+				t.Errorf("wrong offset from UTF16Pos %d; want %d", got, test.Offset)
+			}
+			if got := idx.PosForUTF16Pos(test.UTF16); got != test.Pos {
+				t.Errorf("wrong Pos from UTF16Pos\ngot:  %#v\nwant: %#v", got, test.Pos)
+			}
+			if got := idx.UTF16PosForPos(Pos{Byte: test.Offset}); got != test.UTF16 {
+				t.Errorf("wrong UTF16Pos from Pos\ngot:  %#v\nwant: %#v", got, test.UTF16)
+			}
+		})
+	}
+}
+
+func TestLineIndexOutOfRange(t *testing.T) {
+	src := []byte("a = \"\U0001F600\"\nb = 2")
+	idx := NewLineIndex(src, "test.hcl")
+
+	if got, want := idx.LineCount(), 2; got != want {
+		t.Fatalf("wrong line count %d; want %d", got, want)
+	}
+
+	offsetTests := []struct {
+		Pos  UTF16Pos
+		Want int
+	}{
+		{UTF16Pos{-1, 0}, 0},
+		{UTF16Pos{0, 6}, 5},    // middle of the surrogate pair
+		{UTF16Pos{0, 100}, 10}, // beyond the end of the line
+		{UTF16Pos{1, 100}, 16},
+		{UTF16Pos{2, 0}, 16},
+	}
+	for _, test := range offsetTests {
+		if got := idx.OffsetForUTF16Pos(test.Pos); got != test.Want {
+			t.Errorf("wrong offset for %#v: got %d, want %d", test.Pos, got, test.Want)
+		}
+	}
+
+	posTests := []struct {
+		Offset int
+		Want   Pos
+		Want16 UTF16Pos
+	}{
+		{-5, Pos{Byte: 0, Line: 1, Column: 1}, UTF16Pos{0, 0}},
+		{7, Pos{Byte: 7, Line: 1, Column: 6}, UTF16Pos{0, 5}}, // middle of the emoji
+		{100, Pos{Byte: 16, Line: 2, Column: 6}, UTF16Pos{1, 5}},
+	}
+	for _, test := range posTests {
+		if got := idx.PosForOffset(test.Offset); got != test.Want {
+			t.Errorf("wrong Pos for offset %d\ngot:  %#v\nwant: %#v", test.Offset, got, test.Want)
+		}
+		if got := idx.UTF16PosForOffset(test.Offset); got != test.Want16 {
+			t.Errorf("wrong UTF16Pos for offset %d\ngot:  %#v\nwant: %#v", test.Offset, got, test.Want16)
+		}
+	}
+
+	rng := idx.RangeForUTF16Range(UTF16Range{
+		Start: UTF16Pos{0, 4},
+		End:   UTF16Pos{0, 8},
+	})
+	wantRng := Range{
+		Filename: "test.hcl",
+		Start:    Pos{Byte: 4, Line: 1, Column: 5},
+		End:      Pos{Byte: 10, Line: 1, Column: 8},
+	}
+	if rng != wantRng {
+		t.Errorf("wrong range\ngot:  %#v\nwant: %#v", rng, wantRng)
+	}
+	if got, want := idx.UTF16RangeForRange(rng), (UTF16Range{UTF16Pos{0, 4}, UTF16Pos{0, 8}}); got != want {
+		t.Errorf("wrong UTF16Range\ngot:  %#v\nwant: %#v", got, want)
+	}
+}
+
+func TestLineIndexEmpty(t *testing.T) {
+	idx := NewLineIndex(nil, "")
+	if got, want := idx.LineCount(), 1; got != want {
+		t.Fatalf("wrong line count %d; want %d", got, want)
+	}
+	if got, want := idx.PosForOffset(0), InitialPos; got != want {
+		t.Errorf("wrong Pos\ngot:  %#v\nwant: %#v", got, want)
+	}
+	if got, want := idx.OffsetForUTF16Pos(UTF16Pos{0, 3}), 0; got != want {
+		t.Errorf("wrong offset %d; want %d", got, want)
+	}
+}
@@ -71,19 +71,20 @@ func (t Traversal) TraverseAbs(ctx *EvalContext) (cty.Value, Diagnostics) {
 	root := split.Abs[0].(TraverseRoot)
 	name := root.Name
 
-	thisCtx := ctx
-	hasNonNil := false
-	for thisCtx != nil {
-		if thisCtx.Variables == nil {
-			thisCtx = thisCtx.parent
-			continue
+	val, exists, diags := ctx.LookupVariable(name, root.SrcRange)
+	if exists {
+		if diags.HasErrors() {
+			return cty.DynamicVal, diags
 		}
-		hasNonNil = true
-		val, exists := thisCtx.Variables[name]
-		if exists {
-			return split.Rel.TraverseRel(val)
+		val, moreDiags := split.Rel.TraverseRel(val)
+		return val, append(diags, moreDiags...)
+	}
+
+	hasNonNil := false
+	for thisCtx := ctx; thisCtx != nil; thisCtx = thisCtx.parent {
+		if thisCtx.Variables != nil || thisCtx.Resolver != nil {
+			hasNonNil = true
 		}
-		thisCtx = thisCtx.parent
 	}
 
 	if !hasNonNil {
@@ -98,7 +99,7 @@ func (t Traversal) TraverseAbs(ctx *EvalContext) (cty.Value, Diagnostics) {
 	}
 
 	suggestions := make([]string, 0, len(ctx.Variables))
-	thisCtx = ctx
+	thisCtx := ctx
 	for thisCtx != nil {
 		for k := range thisCtx.Variables {
 			suggestions = append(suggestions, k)
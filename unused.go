@@ -0,0 +1,236 @@
+// Copyright IBM Corp. 2014, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"sort"
+	"sync"
+)
+
+// BodyItem describes an attribute or block within a body.
+type BodyItem struct {
+	// Name is the name of an attribute or the type of a block.
+	Name string
+
+	// Range is the range of the name of an attribute or the definition
+	// range of a block.
+	Range Range
+}
+
+// RemainingItems returns the attributes and blocks in the given body that
+// have not been consumed by the PartialContent call that produced it, or
+// all of the attributes and blocks if the body was not produced by
+// PartialContent.
+//
+// A particular Body implementation can support this function by offering a
+// method called RemainingItems that takes no arguments and returns
+// []BodyItem. If the given body does not support this function then the
+// result is always empty.
+//
+// Some syntaxes cannot distinguish between attributes and blocks without a
+// schema, so the result may describe a block as if it were an attribute.
+func RemainingItems(body Body) []BodyItem {
+	type remainingItems interface {
+		RemainingItems() []BodyItem
+	}
+
+	if ri, supported := body.(remainingItems); supported {
+		return ri.RemainingItems()
+	}
+	return nil
+}
+
+// UsageTrackingBody is a Body that wraps another body and records every
+// schema used to retrieve its content, and the content of any nested blocks,
+// so that it can report which items were never consumed.
+//
+// This is intended to allow applications to warn about configuration that
+// was accepted but then ignored, when the various parts of a configuration
+// are decoded separately, often using several calls to PartialContent.
+//
+// The wrapper should be applied directly to the bodies returned by the
+// parser, and then the wrapper used in place of the original body. It can
+// be passed to functions that combine or wrap bodies, such as MergeBodies
+// or the Expand function of the dynblock extension, because such functions
+// retrieve content from the wrapped body only through its methods. The
+// wrapped body must support RemainingItems, as is the case for bodies from
+// both the native syntax and JSON, and for bodies produced by MergeBodies
+// and OverrideBodies.
+//
+// A UsageTrackingBody is safe for concurrent use if the body it wraps is.
+type UsageTrackingBody struct {
+	body  Body
+	usage *bodyUsage
+}
+
+var _ Body = (*UsageTrackingBody)(nil)
+
+// NewUsageTrackingBody returns a UsageTrackingBody that tracks the usage of
+// the given body.
+func NewUsageTrackingBody(body Body) *UsageTrackingBody {
+	return &UsageTrackingBody{
+		body:  body,
+		usage: newBodyUsage(body),
+	}
+}
+
+func (b *UsageTrackingBody) Content(schema *BodySchema) (*BodyContent, Diagnostics) {
+	b.usage.recordSchema(schema)
+	content, diags := b.body.Content(schema)
+	return b.usage.trackContent(content), diags
+}
+
+func (b *UsageTrackingBody) PartialContent(schema *BodySchema) (*BodyContent, Body, Diagnostics) {
+	b.usage.recordSchema(schema)
+	content, remain, diags := b.body.PartialContent(schema)
+	if remain != nil {
+		// The remaining body is a different view of the same underlying
+		// body, so it shares our record of usage.
+		remain = &UsageTrackingBody{
+			body:  remain,
+			usage: b.usage,
+		}
+	}
+	return b.usage.trackContent(content), remain, diags
+}
+
+func (b *UsageTrackingBody) JustAttributes() (Attributes, Diagnostics) {
+	b.usage.mu.Lock()
+	b.usage.justAttributes = true
+	b.usage.mu.Unlock()
+	return b.body.JustAttributes()
+}
+
+func (b *UsageTrackingBody) MissingItemRange() Range {
+	return b.body.MissingItemRange()
+}
+
+// RemainingItems implements RemainingItems by delegating to the wrapped
+// body, so that usage-tracking bodies can themselves be tracked.
+func (b *UsageTrackingBody) RemainingItems() []BodyItem {
+	return RemainingItems(b.body)
+}
+
+// UnusedItems returns the attributes and blocks in the wrapped body and the
+// bodies of any of its nested blocks that were not matched by any of the
+// schemas used to retrieve their content so far, ordered by their source
+// ranges.
+//
+// A body whose JustAttributes method was called is considered to be
+// entirely used. If a nested block was matched but its body was never
+// decoded then all of that body's content is reported as unused.
+func (b *UsageTrackingBody) UnusedItems() []BodyItem {
+	items := b.usage.unusedItems()
+	sort.SliceStable(items, func(i, j int) bool {
+		ri, rj := items[i].Range, items[j].Range
+		if ri.Filename != rj.Filename {
+			return ri.Filename < rj.Filename
+		}
+		return ri.Start.Byte < rj.Start.Byte
+	})
+	return items
+}
+
+// bodyUsage is the record of usage for a particular body, shared between
+// all of the UsageTrackingBody values that wrap different views of it.
+type bodyUsage struct {
+	mu sync.Mutex
+
+	// body is the body as it was before any content was consumed.
+	body Body
+
+	attrs          map[string]struct{}
+	blocks         map[string]BlockHeaderSchema
+	justAttributes bool
+
+	// children records the usage of the bodies of nested blocks, keyed by
+	// the blocks' definition ranges so that blocks retrieved multiple times
+	// share a single record.
+	children     map[Range]*bodyUsage
+	childrenKeys []Range
+}
+
+func newBodyUsage(body Body) *bodyUsage {
+	return &bodyUsage{
+		body:     body,
+		attrs:    make(map[string]struct{}),
+		blocks:   make(map[string]BlockHeaderSchema),
+		children: make(map[Range]*bodyUsage),
+	}
+}
+
+func (u *bodyUsage) recordSchema(schema *BodySchema) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, attrS := range schema.Attributes {
+		u.attrs[attrS.Name] = struct{}{}
+	}
+	for _, blockS := range schema.Blocks {
+		if _, exists := u.blocks[blockS.Type]; !exists {
+			u.blocks[blockS.Type] = blockS
+		}
+	}
+}
+
+// trackContent returns a copy of the given content where the body of each
+// block is wrapped to track its usage.
+func (u *bodyUsage) trackContent(content *BodyContent) *BodyContent {
+	if content == nil || len(content.Blocks) == 0 {
+		return content
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	ret := &BodyContent{
+		Attributes:       content.Attributes,
+		MissingItemRange: content.MissingItemRange,
+		Blocks:           make(Blocks, len(content.Blocks)),
+	}
+	for i, block := range content.Blocks {
+		child, exists := u.children[block.DefRange]
+		if !exists {
+			child = newBodyUsage(block.Body)
+			u.children[block.DefRange] = child
+			u.childrenKeys = append(u.childrenKeys, block.DefRange)
+		}
+
+		newBlock := *block
+		newBlock.Body = &UsageTrackingBody{
+			body:  block.Body,
+			usage: child,
+		}
+		ret.Blocks[i] = &newBlock
+	}
+	return ret
+}
+
+func (u *bodyUsage) unusedItems() []BodyItem {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var items []BodyItem
+	if !u.justAttributes {
+		schema := &BodySchema{}
+		for name := range u.attrs {
+			schema.Attributes = append(schema.Attributes, AttributeSchema{Name: name})
+		}
+		for _, blockS := range u.blocks {
+			schema.Blocks = append(schema.Blocks, blockS)
+		}
+
+		// Any diagnostics were already returned when the content was
+		// first retrieved, so we ignore them here.
+		_, remain, _ := u.body.PartialContent(schema)
+		if remain != nil {
+			items = append(items, RemainingItems(remain)...)
+		}
+	}
+
+	for _, key := range u.childrenKeys {
+		items = append(items, u.children[key].unusedItems()...)
+	}
+	return items
+}